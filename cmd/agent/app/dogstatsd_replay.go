@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo"
 	"google.golang.org/grpc"
@@ -32,6 +33,9 @@ var (
 	dsdVerboseReplay    bool
 	dsdMmapReplay       bool
 	dsdReplayIterations int
+	dsdReplaySpeed      float64
+	dsdReplayRate       float64
+	dsdOfflineReplay    bool
 )
 
 const (
@@ -44,6 +48,9 @@ func init() {
 	dogstatsdReplayCmd.Flags().BoolVarP(&dsdVerboseReplay, "verbose", "v", false, "Verbose replay.")
 	dogstatsdReplayCmd.Flags().BoolVarP(&dsdMmapReplay, "mmap", "m", true, "Mmap file for replay. Set to false to load the entire file into memory instead")
 	dogstatsdReplayCmd.Flags().IntVarP(&dsdReplayIterations, "loops", "l", defaultIterations, "Number of iterationsi to replay.")
+	dogstatsdReplayCmd.Flags().Float64VarP(&dsdReplaySpeed, "speed", "s", 1, "Speed multiplier of the original cadence of the capture, 2 replays it twice as fast.")
+	dogstatsdReplayCmd.Flags().Float64VarP(&dsdReplayRate, "pps", "r", 0, "Replay the packets at a constant rate of packets per second instead of the original cadence.")
+	dogstatsdReplayCmd.Flags().BoolVarP(&dsdOfflineReplay, "offline", "o", false, "Process the capture in-process with the current DogStatsD configuration, without a running agent, and print the resulting contexts and parse errors. Only the parsing, mapping and filtering are applied: the aggregator cardinality limits and histogram aggregates are not.")
 }
 
 var dogstatsdReplayCmd = &cobra.Command{
@@ -71,7 +78,31 @@ var dogstatsdReplayCmd = &cobra.Command{
 	},
 }
 
+func dogstatsdOfflineReplay() error {
+	reader, err := replay.NewTrafficCaptureReader(dsdReplayFilePath, 1, dsdMmapReplay)
+	if reader != nil {
+		defer reader.Close()
+	}
+
+	if err != nil {
+		fmt.Printf("could not open: %s\n", dsdReplayFilePath)
+		return err
+	}
+
+	fmt.Printf("Processing dogstatsd traffic offline...\n\n")
+
+	report, err := dogstatsd.ReplayOffline(reader)
+	if err != nil {
+		fmt.Printf("There was an issue reading the capture, the report is partial: %v\n\n", err)
+	}
+	fmt.Print(report.Format(dsdVerboseReplay))
+	return err
+}
+
 func dogstatsdReplay() error {
+	if dsdOfflineReplay {
+		return dogstatsdOfflineReplay()
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -129,6 +160,10 @@ func dogstatsdReplay() error {
 		return err
 	}
 
+	if err := reader.SetPacing(replay.Pacing{Speed: dsdReplaySpeed, PacketsPerSecond: dsdReplayRate}); err != nil {
+		return err
+	}
+
 	s := config.Datadog.GetString("dogstatsd_socket")
 	if s == "" {
 		return fmt.Errorf("Dogstatsd UNIX socket disabled")
@@ -171,10 +206,23 @@ func dogstatsdReplay() error {
 		fmt.Printf("API refused to set the tagger state, tag enrichment will be unavailable for this capture.\n")
 	}
 
+	send := func(msg *pb.UnixDogstatsdMsg) error {
+		n, oobn, err := conn.(*net.UnixConn).WriteMsgUnix(
+			msg.Payload[:msg.PayloadSize], replay.GetUcredsForPid(msg.Pid), addr)
+		if err != nil {
+			return err
+		}
+
+		if dsdVerboseReplay {
+			fmt.Printf("Sent Payload: %d bytes, and OOB: %d bytes\n", n, oobn)
+		}
+		return nil
+	}
+
 	breaker := false
 	for i := 0; (i < dsdReplayIterations || dsdReplayIterations == 0) && !breaker; i++ {
 
-		// enable reading at natural rate
+		// enable reading at the configured pace
 		ready := make(chan struct{})
 		go reader.Read(ready)
 
@@ -187,16 +235,16 @@ func dogstatsdReplay() error {
 			case msg := <-reader.Traffic:
 				// The cadence is enforced by the reader. The reader will only write to
 				// the traffic channel when it estimates the payload should be submitted.
-				n, oobn, err := conn.(*net.UnixConn).WriteMsgUnix(
-					msg.Payload[:msg.PayloadSize], replay.GetUcredsForPid(msg.Pid), addr)
-				if err != nil {
+				if err := send(msg); err != nil {
 					return err
 				}
-
-				if dsdVerboseReplay {
-					fmt.Printf("Sent Payload: %d bytes, and OOB: %d bytes\n", n, oobn)
-				}
 			case <-reader.Done:
+				// send the packets still buffered in the traffic channel
+				for len(reader.Traffic) > 0 {
+					if err := send(<-reader.Traffic); err != nil {
+						return err
+					}
+				}
 				break replay
 			case <-done:
 				breaker = true
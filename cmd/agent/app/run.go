@@ -370,6 +370,7 @@ func StartAgent() error {
 	forwarderOpts.EnabledFeatures = forwarder.SetFeature(forwarderOpts.EnabledFeatures, forwarder.CoreFeatures)
 	opts := aggregator.DefaultDemultiplexerOptions(forwarderOpts)
 	opts.UseContainerLifecycleForwarder = config.Datadog.GetBool("container_lifecycle.enabled")
+	opts.UseOpenMetricsEndpoint = config.Datadog.GetBool("openmetrics_endpoint.enabled")
 	demux = aggregator.InitAndStartAgentDemultiplexer(opts, hostname)
 	demux.Aggregator().AddAgentStartupTelemetry(version.AgentVersion)
 
@@ -413,7 +414,7 @@ func StartAgent() error {
 		if config.Datadog.GetBool("log_enabled") {
 			log.Warn(`"log_enabled" is deprecated, use "logs_enabled" instead`)
 		}
-		if err := logs.Start(func() *autodiscovery.AutoConfig { return common.AC }); err != nil {
+		if err := logs.Start(func() *autodiscovery.AutoConfig { return common.AC }, demux); err != nil {
 			log.Error("Could not start logs-agent: ", err)
 		}
 	} else {
@@ -189,6 +189,7 @@ func runAgent(ctx context.Context) (err error) {
 	opts.UseOrchestratorForwarder = false
 	opts.UseEventPlatformForwarder = false
 	opts.UseContainerLifecycleForwarder = false
+	opts.UseOpenMetricsEndpoint = config.Datadog.GetBool("openmetrics_endpoint.enabled")
 	hname, err := util.GetHostname(context.TODO())
 	if err != nil {
 		log.Warnf("Error getting hostname: %s", err)
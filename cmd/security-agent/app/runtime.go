@@ -203,7 +203,7 @@ func newRuntimeReporter(stopper restart.Stopper, sourceName, sourceType string,
 	stopper.Add(auditor)
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, nil, endpoints, context)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, nil, endpoints, context, nil)
 	pipelineProvider.Start()
 	stopper.Add(pipelineProvider)
 
@@ -109,6 +109,7 @@ var (
 	aggregatorEventsFlushed                    = expvar.Int{}
 	aggregatorNumberOfFlush                    = expvar.Int{}
 	aggregatorDogstatsdMetricSample            = expvar.Int{}
+	aggregatorDogstatsdTimestampedMetricSample = expvar.Int{}
 	aggregatorChecksMetricSample               = expvar.Int{}
 	aggregatorCheckHistogramBucketMetricSample = expvar.Int{}
 	aggregatorServiceCheck                     = expvar.Int{}
@@ -117,6 +118,8 @@ var (
 	aggregatorOrchestratorMetadata             = expvar.Int{}
 	aggregatorOrchestratorMetadataErrors       = expvar.Int{}
 	aggregatorDogstatsdContexts                = expvar.Int{}
+	aggregatorDogstatsdContextsDropped         = expvar.Int{}
+	aggregatorDogstatsdContextsTagsStripped    = expvar.Int{}
 	aggregatorEventPlatformEvents              = expvar.Map{}
 	aggregatorEventPlatformEventsErrors        = expvar.Map{}
 	aggregatorContainerLifecycleEvents         = expvar.Int{}
@@ -130,6 +133,8 @@ var (
 		nil, "Count of hostname update")
 	tlmDogstatsdContexts = telemetry.NewGauge("aggregator", "dogstatsd_contexts",
 		nil, "Count the number of dogstatsd contexts in the aggregator")
+	tlmDogstatsdContextsLimited = telemetry.NewCounter("aggregator", "dogstatsd_contexts_limited",
+		[]string{"action"}, "Count the number of new dogstatsd contexts exceeding the cardinality limits, by action")
 
 	// Hold series to be added to aggregated series on each flush
 	recurrentSeries     metrics.Series
@@ -160,6 +165,7 @@ func init() {
 	aggregatorExpvars.Set("EventsFlushed", &aggregatorEventsFlushed)
 	aggregatorExpvars.Set("NumberOfFlush", &aggregatorNumberOfFlush)
 	aggregatorExpvars.Set("DogstatsdMetricSample", &aggregatorDogstatsdMetricSample)
+	aggregatorExpvars.Set("DogstatsdTimestampedMetricSample", &aggregatorDogstatsdTimestampedMetricSample)
 	aggregatorExpvars.Set("ChecksMetricSample", &aggregatorChecksMetricSample)
 	aggregatorExpvars.Set("ChecksHistogramBucketMetricSample", &aggregatorCheckHistogramBucketMetricSample)
 	aggregatorExpvars.Set("ServiceCheck", &aggregatorServiceCheck)
@@ -168,6 +174,8 @@ func init() {
 	aggregatorExpvars.Set("OrchestratorMetadata", &aggregatorOrchestratorMetadata)
 	aggregatorExpvars.Set("OrchestratorMetadataErrors", &aggregatorOrchestratorMetadataErrors)
 	aggregatorExpvars.Set("DogstatsdContexts", &aggregatorDogstatsdContexts)
+	aggregatorExpvars.Set("DogstatsdContextsDropped", &aggregatorDogstatsdContextsDropped)
+	aggregatorExpvars.Set("DogstatsdContextsTagsStripped", &aggregatorDogstatsdContextsTagsStripped)
 	aggregatorExpvars.Set("EventPlatformEvents", &aggregatorEventPlatformEvents)
 	aggregatorExpvars.Set("EventPlatformEventsErrors", &aggregatorEventPlatformEventsErrors)
 	aggregatorExpvars.Set("ContainerLifecycleEvents", &aggregatorContainerLifecycleEvents)
@@ -195,6 +203,10 @@ type BufferedAggregator struct {
 	bufferedServiceCheckIn chan []*metrics.ServiceCheck
 	bufferedEventIn        chan []*metrics.Event
 
+	// bufferedTimestampedMetricIn receives the DogStatsD samples carrying their
+	// own timestamp, which are not aggregated by the time sampler
+	bufferedTimestampedMetricIn chan []metrics.MetricSample
+
 	metricIn       chan *metrics.MetricSample
 	eventIn        chan metrics.Event
 	serviceCheckIn chan metrics.ServiceCheck
@@ -218,6 +230,7 @@ type BufferedAggregator struct {
 	checkSamplers          map[check.ID]*CheckSampler
 	serviceChecks          metrics.ServiceChecks
 	events                 metrics.Events
+	timestampedSeries      metrics.Series // series of the DogStatsD samples having a timestamp, protected by mu
 	flushInterval          time.Duration
 	mu                     sync.Mutex // to protect the checkSamplers field
 	flushMutex             sync.Mutex // to start multiple flushes in parallel
@@ -237,6 +250,17 @@ type BufferedAggregator struct {
 	agentTags               func(collectors.TagCardinality) ([]string, error) // This function gets the agent tags from the tagger (defined as a struct field to ease testing)
 
 	flushAndSerializeInParallel flushAndSerializeInParallel
+
+	// flushSink receives the series and sketches flushed to the serializer, it is optional
+	flushSink flushSink
+}
+
+// flushSink receives a copy of the series and sketches flushed by the aggregator
+type flushSink interface {
+	AddSerie(serie *metrics.Serie)
+	AddSketches(sketches metrics.SketchSeriesList)
+	// RemoveStale is called at the end of each flush
+	RemoveStale()
 }
 
 type flushAndSerializeInParallel struct {
@@ -269,6 +293,8 @@ func NewBufferedAggregator(s serializer.MetricSerializer, eventPlatformForwarder
 		bufferedServiceCheckIn: make(chan []*metrics.ServiceCheck, bufferSize),
 		bufferedEventIn:        make(chan []*metrics.Event, bufferSize),
 
+		bufferedTimestampedMetricIn: make(chan []metrics.MetricSample, bufferSize),
+
 		metricIn:       make(chan *metrics.MetricSample, bufferSize),
 		serviceCheckIn: make(chan metrics.ServiceCheck, bufferSize),
 		eventIn:        make(chan metrics.Event, bufferSize),
@@ -342,6 +368,24 @@ func (agg *BufferedAggregator) GetBufferedMetricsWithTsChannel() chan []metrics.
 	return agg.bufferedMetricInWithTs
 }
 
+// GetBufferedTimestampedMetricsChannel returns the channel to send the DogStatsD
+// MetricSamples having an explicit timestamp. These samples are not aggregated:
+// each of them is flushed as a point of its own serie on the next flush.
+func (agg *BufferedAggregator) GetBufferedTimestampedMetricsChannel() chan []metrics.MetricSample {
+	return agg.bufferedTimestampedMetricIn
+}
+
+// addTimeSamples copies the samples into batches of the metric sample pool and
+// sends them to the time sampler, the caller keeps the ownership of samples.
+func (agg *BufferedAggregator) addTimeSamples(samples []metrics.MetricSample) {
+	for len(samples) > 0 {
+		batch := agg.MetricSamplePool.GetBatch()
+		n := copy(batch, samples)
+		agg.bufferedMetricIn <- batch[:n]
+		samples = samples[n:]
+	}
+}
+
 // SetHostname sets the hostname that the aggregator uses by default on all the data it sends
 // Blocks until the main aggregator goroutine has finished handling the update
 func (agg *BufferedAggregator) SetHostname(hostname string) {
@@ -464,6 +508,33 @@ func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, tim
 	agg.statsdSampler.addSample(metricSample, timestamp)
 }
 
+// addTimestampedSample converts a sample having an explicit timestamp (in nanoseconds) to
+// a serie of a single point, flushed as-is on the next flush.
+// These samples are not tracked as contexts, which would never expire, so the cardinality
+// limits of the DogStatsD contexts do not apply to them.
+func (agg *BufferedAggregator) addTimestampedSample(metricSample *metrics.MetricSample) {
+	serie := &metrics.Serie{
+		Name:     metricSample.Name,
+		Points:   []metrics.Point{{Ts: metricSample.Timestamp / float64(time.Second), Value: metricSample.Value}},
+		Host:     metricSample.Host,
+		MType:    metrics.APIGaugeType,
+		Interval: agg.statsdSampler.interval,
+	}
+	if metricSample.Mtype == metrics.CounterType {
+		serie.MType = metrics.APICountType
+		if metricSample.SampleRate > 0 {
+			serie.Points[0].Value = metricSample.Value * (1 / metricSample.SampleRate)
+		}
+	}
+	tb := tagset.NewHashingTagsAccumulator()
+	metricSample.GetTags(tb)
+	serie.Tags = tb.Get()
+
+	agg.mu.Lock()
+	agg.timestampedSeries = append(agg.timestampedSeries, serie)
+	agg.mu.Unlock()
+}
+
 // GetSeriesAndSketches grabs all the series & sketches from the queue and clears the queue
 // The parameter `before` is used as an end interval while retrieving series and sketches
 // from the time sampler. Metrics and sketches before this timestamp should be returned.
@@ -481,6 +552,11 @@ func (agg *BufferedAggregator) getSeriesAndSketches(before time.Time, series met
 	defer agg.mu.Unlock()
 	sketches := agg.statsdSampler.flush(float64(before.UnixNano())/float64(time.Second), series)
 
+	for _, s := range agg.timestampedSeries {
+		series.Append(s)
+	}
+	agg.timestampedSeries = nil
+
 	for _, checkSampler := range agg.checkSamplers {
 		checkSeries, sk := checkSampler.flush()
 		for _, s := range checkSeries {
@@ -587,6 +663,12 @@ func (agg *BufferedAggregator) sendSeries(start time.Time, series metrics.Series
 	agg.appendDefaultSeries(start, &series)
 	addFlushCount("Series", int64(len(series)))
 
+	if agg.flushSink != nil {
+		for _, serie := range series {
+			agg.flushSink.AddSerie(serie)
+		}
+	}
+
 	// For debug purposes print out all metrics/tag combinations
 	if config.Datadog.GetBool("log_payloads") {
 		log.Debug("Flushing the following metrics:")
@@ -621,6 +703,9 @@ func (agg *BufferedAggregator) sendIterableSeries(
 func (agg *BufferedAggregator) sendSketches(start time.Time, sketches metrics.SketchSeriesList, waitForSerializer bool) {
 	// Serialize and forward sketches in a separate goroutine
 	addFlushCount("Sketches", int64(len(sketches)))
+	if agg.flushSink != nil {
+		agg.flushSink.AddSketches(sketches)
+	}
 	if len(sketches) != 0 {
 		if waitForSerializer {
 			agg.pushSketches(start, sketches)
@@ -643,6 +728,9 @@ func (agg *BufferedAggregator) flushSeriesAndSketches(start time.Time, waitForSe
 				log.Debugf("Flushing the following metrics: %s", s)
 			}
 			tagsetTlm.updateHugeSerieTelemetry(s)
+			if agg.flushSink != nil {
+				agg.flushSink.AddSerie(s)
+			}
 		}, agg.flushAndSerializeInParallel.channelSize, agg.flushAndSerializeInParallel.bufferSize)
 		done := make(chan struct{})
 		agg.sendIterableSeries(start, series, done)
@@ -654,6 +742,10 @@ func (agg *BufferedAggregator) flushSeriesAndSketches(start time.Time, waitForSe
 		}
 		agg.sendSketches(start, sketches, waitForSerializer)
 	}
+
+	if agg.flushSink != nil {
+		agg.flushSink.RemoveStale()
+	}
 }
 
 // GetServiceChecks grabs all the service checks from the queue and clears the queue
@@ -859,6 +951,13 @@ func (agg *BufferedAggregator) run() {
 				agg.addSample(&ms[i], ms[i].Timestamp/float64(time.Second))
 			}
 			agg.MetricSamplePool.PutBatch(ms)
+		case ms := <-agg.bufferedTimestampedMetricIn:
+			aggregatorDogstatsdTimestampedMetricSample.Add(int64(len(ms)))
+			tlmProcessed.Add(float64(len(ms)), "dogstatsd_timestamped_metrics")
+			for i := 0; i < len(ms); i++ {
+				agg.addTimestampedSample(&ms[i])
+			}
+			agg.MetricSamplePool.PutBatch(ms)
 		case ms := <-agg.bufferedMetricIn:
 			aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
 			tlmProcessed.Add(float64(len(ms)), "dogstatsd_metrics")
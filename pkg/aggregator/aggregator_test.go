@@ -9,6 +9,7 @@ package aggregator
 
 import (
 	// stdlib
+	"bytes"
 	"errors"
 	"expvar"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/openmetrics"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/epforwarder"
@@ -530,6 +532,31 @@ func TestAggregatorFlush(t *testing.T) {
 	}
 }
 
+func TestAggregatorFlushSink(t *testing.T) {
+	defer config.Datadog.Set("aggregator_flush_metrics_and_serialize_in_parallel", nil)
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("aggregator_flush_metrics_and_serialize_in_parallel %v", enabled), func(t *testing.T) {
+			config.Datadog.Set("aggregator_flush_metrics_and_serialize_in_parallel", enabled)
+			s := &MockSerializerIterableSerie{}
+			s.On("SendServiceChecks", mock.Anything).Return(nil)
+			s.On("IsIterableSeriesSupported", mock.Anything).Return(true).Maybe()
+			agg := NewBufferedAggregator(s, nil, "hostname", DefaultFlushInterval)
+			store := openmetrics.NewStore(time.Minute)
+			agg.flushSink = store
+
+			flushSomeSamples(agg)
+
+			// the sink receives the series sent to the serializer, including the default ones
+			assert.Equal(t, len(s.series), store.Len())
+			var buf bytes.Buffer
+			_, err := store.WriteTo(&buf)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "# TYPE serie42 gauge\nserie42 2 10\n")
+		})
+	}
+}
+
 // The implementation of MockSerializer.SendIterableSeries uses `s.Called(series).Error(0)`.
 // It calls internaly `Printf` on each field of the real type of `IterableStreamJSONMarshaler` which is `IterableSeries`.
 // It can lead to a race condition, if another goruntine call `IterableSeries.Append` which modifies `series.count`.
@@ -0,0 +1,383 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Actions of the cardinality limiter when a limit is reached.
+const (
+	// CardinalityLimitDrop drops the new contexts.
+	CardinalityLimitDrop = "drop"
+	// CardinalityLimitStripTag removes the tags having too many values from the new contexts.
+	CardinalityLimitStripTag = "strip_tag"
+)
+
+const (
+	// maxCardinalityOffenders bounds the number of offenders kept for the report.
+	maxCardinalityOffenders = 1000
+	// cardinalityReportSize is the number of entries of each list of the report.
+	cardinalityReportSize = 20
+)
+
+var (
+	// cardinalityLimiterInstance is the limiter of the dogstatsd contexts, used to build the report.
+	cardinalityLimiterInstance   *cardinalityLimiter
+	cardinalityLimiterInstanceMu sync.Mutex
+)
+
+// cardinalityOffender identifies the limit that prevented a context from being tracked.
+type cardinalityOffender struct {
+	metric string
+	// tagKey is empty for the limit of contexts per metric.
+	tagKey string
+	action string
+}
+
+// cardinalityLimiter limits the number of contexts of each metric name and the number of values
+// of the tag keys of each metric name. The contexts are counted until they expire.
+//
+// The limit of contexts per metric always drops the new contexts, while the limits of tag values
+// either drop the new contexts or remove the offending tags, which can aggregate the samples of
+// several contexts into one.
+type cardinalityLimiter struct {
+	maxContextsPerMetric int
+	maxValuesPerTagKey   map[string]int
+	action               string
+
+	// the limiter is used by the sampler, the mutex protects the state from the reports.
+	mu sync.Mutex
+	// contextsByMetric is the number of contexts of each metric name.
+	contextsByMetric map[string]int
+	// tagValuesByMetric is the number of contexts of each value of the limited tag keys of each metric name.
+	tagValuesByMetric map[string]map[string]map[string]int
+	offenders         map[cardinalityOffender]uint64
+}
+
+// newCardinalityLimiter returns a new limiter, or nil when no limit is set.
+func newCardinalityLimiter(maxContextsPerMetric int, maxValuesPerTagKey map[string]int, action string) *cardinalityLimiter {
+	if maxContextsPerMetric <= 0 && len(maxValuesPerTagKey) == 0 {
+		return nil
+	}
+	if action != CardinalityLimitDrop && action != CardinalityLimitStripTag {
+		log.Warnf("Unknown cardinality limit action %q, new contexts will be dropped", action)
+		action = CardinalityLimitDrop
+	}
+	return &cardinalityLimiter{
+		maxContextsPerMetric: maxContextsPerMetric,
+		maxValuesPerTagKey:   maxValuesPerTagKey,
+		action:               action,
+		contextsByMetric:     make(map[string]int),
+		tagValuesByMetric:    make(map[string]map[string]map[string]int),
+		offenders:            make(map[cardinalityOffender]uint64),
+	}
+}
+
+// newCardinalityLimiterFromConfig returns the limiter of the dogstatsd contexts, or nil when no limit is set.
+func newCardinalityLimiterFromConfig() *cardinalityLimiter {
+	maxValuesPerTagKey := make(map[string]int)
+	for key, value := range config.Datadog.GetStringMapString("dogstatsd_max_tag_values_per_metric") {
+		max, err := strconv.Atoi(value)
+		if err != nil || max <= 0 {
+			log.Warnf("Invalid limit %v for the values of the tag %s in dogstatsd_max_tag_values_per_metric, it must be a positive integer", value, key)
+			continue
+		}
+		maxValuesPerTagKey[key] = max
+	}
+	limiter := newCardinalityLimiter(
+		config.Datadog.GetInt("dogstatsd_max_contexts_per_metric"),
+		maxValuesPerTagKey,
+		config.Datadog.GetString("dogstatsd_cardinality_limit_action"),
+	)
+
+	cardinalityLimiterInstanceMu.Lock()
+	cardinalityLimiterInstance = limiter
+	cardinalityLimiterInstanceMu.Unlock()
+	return limiter
+}
+
+// checkTags enforces the limits of tag values on the tags of a new context. When the action is
+// CardinalityLimitStripTag, the offending tags are removed from the tags and stripped is true.
+func (l *cardinalityLimiter) checkTags(metric string, tags *tagset.HashingTagsAccumulator) (stripped bool, allowed bool) {
+	if len(l.maxValuesPerTagKey) == 0 {
+		return false, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var offendingKeys []string
+	for _, tag := range tags.Get() {
+		key, value := splitTag(tag)
+		max, limited := l.maxValuesPerTagKey[key]
+		if !limited {
+			continue
+		}
+		values := l.tagValuesByMetric[metric][key]
+		if _, exists := values[value]; exists || len(values) < max {
+			continue
+		}
+		if l.action == CardinalityLimitDrop {
+			l.onLimited(metric, key)
+			return false, false
+		}
+		offendingKeys = append(offendingKeys, key)
+	}
+	if len(offendingKeys) == 0 {
+		return false, true
+	}
+
+	for _, key := range offendingKeys {
+		l.onLimited(metric, key)
+	}
+	tags.Retain(func(tag string) bool {
+		key, _ := splitTag(tag)
+		for _, offendingKey := range offendingKeys {
+			if key == offendingKey {
+				return false
+			}
+		}
+		return true
+	})
+	return true, true
+}
+
+// checkContexts returns false when the metric already has the maximum number of contexts.
+func (l *cardinalityLimiter) checkContexts(metric string) bool {
+	if l.maxContextsPerMetric <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.contextsByMetric[metric] < l.maxContextsPerMetric {
+		return true
+	}
+	// the offending tags are unknown, the context can only be dropped
+	l.recordOffender(cardinalityOffender{metric: metric, action: CardinalityLimitDrop})
+	tlmDogstatsdContextsLimited.Inc(CardinalityLimitDrop)
+	aggregatorDogstatsdContextsDropped.Add(1)
+	return false
+}
+
+// onLimited records a context that reached the limit of values of a tag key.
+func (l *cardinalityLimiter) onLimited(metric string, tagKey string) {
+	l.recordOffender(cardinalityOffender{metric: metric, tagKey: tagKey, action: l.action})
+	tlmDogstatsdContextsLimited.Inc(l.action)
+	if l.action == CardinalityLimitDrop {
+		aggregatorDogstatsdContextsDropped.Add(1)
+	} else {
+		aggregatorDogstatsdContextsTagsStripped.Add(1)
+	}
+}
+
+// recordOffender counts a limited context of the offender, at most maxCardinalityOffenders
+// offenders are tracked.
+func (l *cardinalityLimiter) recordOffender(offender cardinalityOffender) {
+	if _, found := l.offenders[offender]; found || len(l.offenders) < maxCardinalityOffenders {
+		l.offenders[offender]++
+	}
+}
+
+// add counts a new context.
+func (l *cardinalityLimiter) add(metric string, tags []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.contextsByMetric[metric]++
+	for _, tag := range tags {
+		key, value := splitTag(tag)
+		if _, limited := l.maxValuesPerTagKey[key]; !limited {
+			continue
+		}
+		valuesByKey, found := l.tagValuesByMetric[metric]
+		if !found {
+			valuesByKey = make(map[string]map[string]int)
+			l.tagValuesByMetric[metric] = valuesByKey
+		}
+		values, found := valuesByKey[key]
+		if !found {
+			values = make(map[string]int)
+			valuesByKey[key] = values
+		}
+		values[value]++
+	}
+}
+
+// remove stops counting an expired context.
+func (l *cardinalityLimiter) remove(metric string, tags []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.contextsByMetric[metric]--; l.contextsByMetric[metric] <= 0 {
+		delete(l.contextsByMetric, metric)
+	}
+	valuesByKey := l.tagValuesByMetric[metric]
+	for _, tag := range tags {
+		key, value := splitTag(tag)
+		values, found := valuesByKey[key]
+		if !found {
+			continue
+		}
+		if values[value]--; values[value] <= 0 {
+			delete(values, value)
+		}
+		if len(values) == 0 {
+			delete(valuesByKey, key)
+		}
+	}
+	if len(valuesByKey) == 0 {
+		delete(l.tagValuesByMetric, metric)
+	}
+}
+
+// splitTag returns the key and the value of a tag, the value is empty when the tag has no value.
+func splitTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// CardinalityReport describes the limits of the dogstatsd contexts and the metrics having the most contexts.
+type CardinalityReport struct {
+	MaxContextsPerMetric int                       `json:"max_contexts_per_metric"`
+	MaxValuesPerTagKey   map[string]int            `json:"max_tag_values_per_metric"`
+	Action               string                    `json:"action"`
+	TopMetrics           []CardinalityMetric       `json:"top_metrics"`
+	TopOffenders         []CardinalityOffenderStat `json:"top_offenders"`
+}
+
+// CardinalityMetric is the number of contexts of a metric.
+type CardinalityMetric struct {
+	Metric   string `json:"metric"`
+	Contexts int    `json:"contexts"`
+}
+
+// CardinalityOffenderStat is the number of contexts of a metric that reached a limit.
+type CardinalityOffenderStat struct {
+	Metric string `json:"metric"`
+	// TagKey is empty for the limit of contexts per metric.
+	TagKey string `json:"tag_key,omitempty"`
+	Action string `json:"action"`
+	Count  uint64 `json:"count"`
+}
+
+func (l *cardinalityLimiter) report(size int) CardinalityReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	report := CardinalityReport{
+		MaxContextsPerMetric: l.maxContextsPerMetric,
+		MaxValuesPerTagKey:   l.maxValuesPerTagKey,
+		Action:               l.action,
+		TopMetrics:           []CardinalityMetric{},
+		TopOffenders:         []CardinalityOffenderStat{},
+	}
+	for metric, contexts := range l.contextsByMetric {
+		report.TopMetrics = append(report.TopMetrics, CardinalityMetric{Metric: metric, Contexts: contexts})
+	}
+	sort.Slice(report.TopMetrics, func(i, j int) bool {
+		if report.TopMetrics[i].Contexts != report.TopMetrics[j].Contexts {
+			return report.TopMetrics[i].Contexts > report.TopMetrics[j].Contexts
+		}
+		return report.TopMetrics[i].Metric < report.TopMetrics[j].Metric
+	})
+	if len(report.TopMetrics) > size {
+		report.TopMetrics = report.TopMetrics[:size]
+	}
+
+	for offender, count := range l.offenders {
+		report.TopOffenders = append(report.TopOffenders, CardinalityOffenderStat{
+			Metric: offender.metric,
+			TagKey: offender.tagKey,
+			Action: offender.action,
+			Count:  count,
+		})
+	}
+	sort.Slice(report.TopOffenders, func(i, j int) bool {
+		a, b := report.TopOffenders[i], report.TopOffenders[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		return a.TagKey < b.TagKey
+	})
+	if len(report.TopOffenders) > size {
+		report.TopOffenders = report.TopOffenders[:size]
+	}
+	return report
+}
+
+// GetJSONCardinalityReport returns the report of the limits of the dogstatsd contexts
+// marshaled in JSON, it returns an error when no limit is configured.
+func GetJSONCardinalityReport() ([]byte, error) {
+	cardinalityLimiterInstanceMu.Lock()
+	limiter := cardinalityLimiterInstance
+	cardinalityLimiterInstanceMu.Unlock()
+	if limiter == nil {
+		return nil, fmt.Errorf("no cardinality limit is configured")
+	}
+	return json.Marshal(limiter.report(cardinalityReportSize))
+}
+
+// FormatCardinalityReport formats a report returned by GetJSONCardinalityReport to be displayed.
+func FormatCardinalityReport(data []byte) (string, error) {
+	var report CardinalityReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "Limits:\n")
+	if report.MaxContextsPerMetric > 0 {
+		fmt.Fprintf(buf, "  Contexts per metric: %d\n", report.MaxContextsPerMetric)
+	}
+	keys := make([]string, 0, len(report.MaxValuesPerTagKey))
+	for key := range report.MaxValuesPerTagKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "  Values of tag %s per metric: %d\n", key, report.MaxValuesPerTagKey[key])
+	}
+	fmt.Fprintf(buf, "  Action: %s\n\n", report.Action)
+
+	header := fmt.Sprintf("%-60s | %-10s\n", "Metric", "Contexts")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, metric := range report.TopMetrics {
+		fmt.Fprintf(buf, "%-60s | %-10d\n", metric.Metric, metric.Contexts)
+	}
+	if len(report.TopMetrics) == 0 {
+		buf.WriteString("No contexts tracked yet.\n")
+	}
+	buf.WriteString("\n")
+
+	header = fmt.Sprintf("%-60s | %-20s | %-10s | %-10s\n", "Limited metric", "Tag key", "Action", "Count")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, offender := range report.TopOffenders {
+		tagKey := offender.TagKey
+		if tagKey == "" {
+			tagKey = "(contexts)"
+		}
+		fmt.Fprintf(buf, "%-60s | %-20s | %-10s | %-10d\n", offender.Metric, tagKey, offender.Action, offender.Count)
+	}
+	if len(report.TopOffenders) == 0 {
+		buf.WriteString("No context reached a limit yet.\n")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build test
+
+package aggregator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewCardinalityLimiterDisabled(t *testing.T) {
+	assert.Nil(t, newCardinalityLimiter(0, nil, CardinalityLimitDrop))
+	assert.Nil(t, newCardinalityLimiter(0, map[string]int{}, CardinalityLimitStripTag))
+
+	limiter := newCardinalityLimiter(1, nil, "unknown")
+	require.NotNil(t, limiter)
+	assert.Equal(t, CardinalityLimitDrop, limiter.action)
+}
+
+func TestNewCardinalityLimiterFromConfig(t *testing.T) {
+	config.Datadog.Set("dogstatsd_max_contexts_per_metric", 10)
+	config.Datadog.Set("dogstatsd_max_tag_values_per_metric", map[string]interface{}{"user_id": 5, "invalid": "foo"})
+	config.Datadog.Set("dogstatsd_cardinality_limit_action", CardinalityLimitStripTag)
+	defer func() {
+		config.Datadog.Set("dogstatsd_max_contexts_per_metric", 0)
+		config.Datadog.Set("dogstatsd_max_tag_values_per_metric", map[string]int{})
+		config.Datadog.Set("dogstatsd_cardinality_limit_action", CardinalityLimitDrop)
+		newCardinalityLimiterFromConfig()
+	}()
+
+	limiter := newCardinalityLimiterFromConfig()
+	require.NotNil(t, limiter)
+	assert.Equal(t, 10, limiter.maxContextsPerMetric)
+	assert.Equal(t, map[string]int{"user_id": 5}, limiter.maxValuesPerTagKey)
+	assert.Equal(t, CardinalityLimitStripTag, limiter.action)
+	_, err := GetJSONCardinalityReport()
+	assert.NoError(t, err)
+}
+
+func testCardinalityLimitPerMetric(t *testing.T, store *tags.Store) {
+	resolver := newContextResolver(store, newCardinalityLimiter(2, nil, CardinalityLimitDrop))
+
+	_, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"a"}})
+	assert.True(t, ok)
+	key, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"b"}})
+	assert.True(t, ok)
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"c"}})
+	assert.False(t, ok)
+	// the existing contexts and the other metrics are not limited
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"b"}})
+	assert.True(t, ok)
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "bar", Tags: []string{"c"}})
+	assert.True(t, ok)
+	assert.Equal(t, 3, resolver.length())
+
+	// the expired contexts are not counted anymore
+	resolver.removeKeys([]ckey.ContextKey{key})
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"c"}})
+	assert.True(t, ok)
+
+	report := resolver.limiter.report(10)
+	assert.Equal(t, []CardinalityMetric{{Metric: "foo", Contexts: 2}, {Metric: "bar", Contexts: 1}}, report.TopMetrics)
+	assert.Equal(t, []CardinalityOffenderStat{{Metric: "foo", Action: CardinalityLimitDrop, Count: 1}}, report.TopOffenders)
+}
+
+func TestCardinalityLimitPerMetric(t *testing.T) {
+	testWithTagsStore(t, testCardinalityLimitPerMetric)
+}
+
+func TestCardinalityLimitOffendersBound(t *testing.T) {
+	limiter := newCardinalityLimiter(1, map[string]int{"user_id": 1}, CardinalityLimitDrop)
+	for i := 0; i < maxCardinalityOffenders+10; i++ {
+		metric := fmt.Sprintf("metric.%d", i)
+		limiter.add(metric, nil)
+		assert.False(t, limiter.checkContexts(metric))
+	}
+	limiter.onLimited("other", "user_id")
+	assert.Len(t, limiter.offenders, maxCardinalityOffenders)
+}
+
+func testCardinalityLimitPerTagDrop(t *testing.T, store *tags.Store) {
+	resolver := newContextResolver(store, newCardinalityLimiter(0, map[string]int{"user_id": 2}, CardinalityLimitDrop))
+
+	for _, tags := range [][]string{{"user_id:1", "env:prod"}, {"user_id:2", "env:prod"}, {"user_id:1", "env:dev"}, {"env:dev"}} {
+		_, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: tags})
+		assert.True(t, ok, "%v should be tracked", tags)
+	}
+	_, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"user_id:3", "env:prod"}})
+	assert.False(t, ok)
+	// the values are counted per metric
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "bar", Tags: []string{"user_id:3"}})
+	assert.True(t, ok)
+
+	report := resolver.limiter.report(10)
+	assert.Equal(t, []CardinalityOffenderStat{{Metric: "foo", TagKey: "user_id", Action: CardinalityLimitDrop, Count: 1}}, report.TopOffenders)
+}
+
+func TestCardinalityLimitPerTagDrop(t *testing.T) {
+	testWithTagsStore(t, testCardinalityLimitPerTagDrop)
+}
+
+func testCardinalityLimitPerTagStrip(t *testing.T, store *tags.Store) {
+	resolver := newContextResolver(store, newCardinalityLimiter(0, map[string]int{"user_id": 1}, CardinalityLimitStripTag))
+
+	key1, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"user_id:1", "env:prod"}})
+	assert.True(t, ok)
+	key2, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"user_id:2", "env:prod"}})
+	assert.True(t, ok)
+	assert.NotEqual(t, key1, key2)
+	context, found := resolver.get(key2)
+	require.True(t, found)
+	assert.Equal(t, []string{"env:prod"}, context.Tags())
+
+	// the stripped contexts are aggregated together
+	key3, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"user_id:3", "env:prod"}})
+	assert.True(t, ok)
+	assert.Equal(t, key2, key3)
+	assert.Equal(t, 2, resolver.length())
+
+	report := resolver.limiter.report(10)
+	assert.Equal(t, []CardinalityOffenderStat{{Metric: "foo", TagKey: "user_id", Action: CardinalityLimitStripTag, Count: 2}}, report.TopOffenders)
+}
+
+func TestCardinalityLimitPerTagStrip(t *testing.T) {
+	testWithTagsStore(t, testCardinalityLimitPerTagStrip)
+}
+
+func TestFormatCardinalityReport(t *testing.T) {
+	limiter := newCardinalityLimiter(1, map[string]int{"user_id": 10}, CardinalityLimitDrop)
+	limiter.add("foo", []string{"user_id:1"})
+	assert.False(t, limiter.checkContexts("foo"))
+
+	cardinalityLimiterInstanceMu.Lock()
+	previous := cardinalityLimiterInstance
+	cardinalityLimiterInstance = limiter
+	cardinalityLimiterInstanceMu.Unlock()
+	defer func() {
+		cardinalityLimiterInstanceMu.Lock()
+		cardinalityLimiterInstance = previous
+		cardinalityLimiterInstanceMu.Unlock()
+	}()
+
+	data, err := GetJSONCardinalityReport()
+	require.NoError(t, err)
+	formatted, err := FormatCardinalityReport(data)
+	require.NoError(t, err)
+	assert.Contains(t, formatted, "Contexts per metric: 1")
+	assert.Contains(t, formatted, "Values of tag user_id per metric: 10")
+	assert.Regexp(t, `foo +\| 1 `, formatted)
+	assert.Regexp(t, `foo +\| \(contexts\) +\| drop +\| 1 `, formatted)
+}
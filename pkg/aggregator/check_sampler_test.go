@@ -210,6 +210,42 @@ func TestHistogramCountSampling(t *testing.T) {
 	testWithTagsStore(t, testHistogramCountSampling)
 }
 
+func testCheckDistributionSampling(t *testing.T, store *tags.Store) {
+	checkSampler := newCheckSampler(1, true, 1*time.Second, store)
+
+	for _, value := range []float64{1, 2, 3} {
+		checkSampler.addSample(&metrics.MetricSample{
+			Name:       "my.distribution",
+			Value:      value,
+			Mtype:      metrics.DistributionType,
+			Tags:       []string{"foo", "bar"},
+			Host:       "my-host",
+			SampleRate: 1,
+			Timestamp:  12345.0,
+		})
+	}
+
+	checkSampler.commit(12349.0)
+	series, sketches := checkSampler.flush()
+	assert.Empty(t, series)
+	require.Len(t, sketches, 1)
+
+	expSketch := &quantile.Sketch{}
+	expSketch.Insert(quantile.Default(), 1, 2, 3)
+
+	metrics.AssertSketchSeriesEqual(t, metrics.SketchSeries{
+		Name:       "my.distribution",
+		Tags:       []string{"bar", "foo"},
+		Host:       "my-host",
+		Points:     []metrics.SketchPoint{{Ts: 12345, Sketch: expSketch}},
+		ContextKey: generateContextKey(&metrics.MetricSample{Name: "my.distribution", Tags: []string{"foo", "bar"}, Host: "my-host"}),
+	}, sketches[0])
+}
+
+func TestCheckDistributionSampling(t *testing.T) {
+	testWithTagsStore(t, testCheckDistributionSampling)
+}
+
 func testCheckHistogramBucketSampling(t *testing.T, store *tags.Store) {
 	checkSampler := newCheckSampler(1, true, 1*time.Second, store)
 
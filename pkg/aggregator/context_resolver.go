@@ -34,6 +34,8 @@ type contextResolver struct {
 	// buffer slice allocated once per contextResolver to combine and sort
 	// tags, origin detection tags and k8s tags.
 	tagsBuffer *tagset.HashingTagsAccumulator
+	// limiter limits the cardinality of the new contexts, nil when there is no limit.
+	limiter *cardinalityLimiter
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -41,30 +43,59 @@ func (cr *contextResolver) generateContextKey(metricSampleContext metrics.Metric
 	return cr.keyGenerator.GenerateWithTags(metricSampleContext.GetName(), metricSampleContext.GetHost(), cr.tagsBuffer)
 }
 
-func newContextResolver(cache *tags.Store) *contextResolver {
+func newContextResolver(cache *tags.Store, limiter *cardinalityLimiter) *contextResolver {
 	return &contextResolver{
 		contextsByKey: make(map[ckey.ContextKey]*Context),
 		tagsCache:     cache,
 		keyGenerator:  ckey.NewKeyGenerator(),
 		tagsBuffer:    tagset.NewHashingTagsAccumulator(),
+		limiter:       limiter,
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false when the context is new and exceeds the cardinality limits, the sample must then be dropped.
+func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) (ckey.ContextKey, bool) {
 	metricSampleContext.GetTags(cr.tagsBuffer)                        // tags here are not sorted and can contain duplicates
 	contextKey, tagsKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates from cr.tagsBuffer (and doesn't mind the order)
 
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		cr.contextsByKey[contextKey] = &Context{
-			Name: metricSampleContext.GetName(),
-			tags: cr.tagsCache.Insert(tagsKey, cr.tagsBuffer),
-			Host: metricSampleContext.GetHost(),
+		if cr.limiter != nil && !cr.checkLimits(metricSampleContext, &contextKey, &tagsKey) {
+			cr.tagsBuffer.Reset()
+			return contextKey, false
+		}
+		if _, ok := cr.contextsByKey[contextKey]; !ok {
+			context := &Context{
+				Name: metricSampleContext.GetName(),
+				tags: cr.tagsCache.Insert(tagsKey, cr.tagsBuffer),
+				Host: metricSampleContext.GetHost(),
+			}
+			cr.contextsByKey[contextKey] = context
+			if cr.limiter != nil {
+				cr.limiter.add(context.Name, context.Tags())
+			}
 		}
 	}
 
 	cr.tagsBuffer.Reset()
-	return contextKey
+	return contextKey, true
+}
+
+// checkLimits enforces the cardinality limits on a new context. When the offending tags are stripped,
+// the keys are updated and can be the ones of an existing context.
+func (cr *contextResolver) checkLimits(metricSampleContext metrics.MetricSampleContext, contextKey *ckey.ContextKey, tagsKey *ckey.TagsKey) bool {
+	name := metricSampleContext.GetName()
+	stripped, allowed := cr.limiter.checkTags(name, cr.tagsBuffer)
+	if !allowed {
+		return false
+	}
+	if stripped {
+		*contextKey, *tagsKey = cr.generateContextKey(metricSampleContext)
+		if _, ok := cr.contextsByKey[*contextKey]; ok {
+			return true
+		}
+	}
+	return cr.limiter.checkContexts(name)
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -82,6 +113,9 @@ func (cr *contextResolver) removeKeys(expiredContextKeys []ckey.ContextKey) {
 		delete(cr.contextsByKey, expiredContextKey)
 
 		if context != nil {
+			if cr.limiter != nil {
+				cr.limiter.remove(context.Name, context.Tags())
+			}
 			context.tags.Release()
 		}
 	}
@@ -93,9 +127,9 @@ type timestampContextResolver struct {
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, limiter *cardinalityLimiter) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache),
+		resolver:      newContextResolver(cache, limiter),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -111,11 +145,15 @@ func (cr *timestampContextResolver) updateTrackedContext(contextKey ckey.Context
 	return nil
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context,
+// it returns false when the context exceeds the cardinality limits.
+func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) (ckey.ContextKey, bool) {
+	contextKey, ok := cr.resolver.trackContext(metricSampleContext)
+	if !ok {
+		return contextKey, false
+	}
 	cr.lastSeenByKey[contextKey] = currentTimestamp
-	return contextKey
+	return contextKey, true
 }
 
 func (cr *timestampContextResolver) length() int {
@@ -159,7 +197,7 @@ type countBasedContextResolver struct {
 
 func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache),
+		resolver:            newContextResolver(cache, nil),
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -168,7 +206,7 @@ func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *c
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
+	contextKey, _ := cr.resolver.trackContext(metricSampleContext) // the contexts of the checks are not limited
 	cr.expireCountByKey[contextKey] = cr.expireCount
 	return contextKey
 }
@@ -89,6 +89,7 @@ type DemultiplexerOptions struct {
 	UseEventPlatformForwarder      bool
 	UseOrchestratorForwarder       bool
 	UseContainerLifecycleForwarder bool
+	UseOpenMetricsEndpoint         bool
 	FlushInterval                  time.Duration
 
 	DontStartForwarders bool // unit tests don't need the forwarders to be instanciated
@@ -102,8 +103,9 @@ type forwarders struct {
 }
 
 type dataOutputs struct {
-	forwarders       forwarders
-	sharedSerializer serializer.MetricSerializer
+	forwarders          forwarders
+	sharedSerializer    serializer.MetricSerializer
+	openMetricsEndpoint *openMetricsEndpoint
 }
 
 // DefaultDemultiplexerOptions returns the default options to initialize a Demultiplexer.
@@ -177,6 +179,15 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 
 	agg := InitAggregatorWithFlushInterval(sharedSerializer, eventPlatformForwarder, hostname, options.FlushInterval)
 
+	// prepare the OpenMetrics endpoint, receiving the same series and sketches as the serializer
+	// --
+
+	var openMetricsEndpoint *openMetricsEndpoint
+	if options.UseOpenMetricsEndpoint {
+		openMetricsEndpoint = newOpenMetricsEndpoint()
+		agg.flushSink = openMetricsEndpoint.store
+	}
+
 	// --
 
 	demux := &AgentDemultiplexer{
@@ -195,7 +206,8 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 				containerLifecycle: containerLifecycleForwarder,
 			},
 
-			sharedSerializer: sharedSerializer,
+			sharedSerializer:    sharedSerializer,
+			openMetricsEndpoint: openMetricsEndpoint,
 		},
 
 		senders: newSenders(agg),
@@ -245,6 +257,10 @@ func (d *AgentDemultiplexer) Run() {
 		d.aggregator.contLcycleDequeueOnce.Do(func() { go d.aggregator.dequeueContainerLifecycleEvents() })
 	}
 
+	if d.dataOutputs.openMetricsEndpoint != nil {
+		d.dataOutputs.openMetricsEndpoint.start()
+	}
+
 	d.aggregator.run() // this is the blocking call
 }
 
@@ -285,6 +301,11 @@ func (d *AgentDemultiplexer) Stop(flush bool) {
 		}
 	}
 
+	if d.dataOutputs.openMetricsEndpoint != nil {
+		d.dataOutputs.openMetricsEndpoint.stop()
+		d.dataOutputs.openMetricsEndpoint = nil
+	}
+
 	d.dataOutputs.sharedSerializer = nil
 	d.senders = nil
 	demultiplexerInstance = nil
@@ -304,7 +325,7 @@ func (d *AgentDemultiplexer) FlushAggregatedData(start time.Time, waitForSeriali
 // AddTimeSamples adds time samples processed by the DogStatsD server into a time sampler pipeline.
 // The MetricSamples should have their hash computed.
 func (d *AgentDemultiplexer) AddTimeSamples(samples []metrics.MetricSample) {
-	panic("not implemented yet.")
+	d.aggregator.addTimeSamples(samples)
 }
 
 // AddCheckSample adds check sample sent by a check from one of the collectors into a check sampler pipeline.
@@ -390,7 +411,7 @@ func (d *ServerlessDemultiplexer) FlushAggregatedData(start time.Time, waitForSe
 // AddTimeSamples adds time samples processed by the DogStatsD server into a time sampler pipeline.
 // The MetricSamples should have their hash computed.
 func (d *ServerlessDemultiplexer) AddTimeSamples(samples []metrics.MetricSample) {
-	panic("not implemented yet.")
+	d.aggregator.addTimeSamples(samples)
 }
 
 // AddCheckSample doesn't do anything in the Serverless Agent implementation.
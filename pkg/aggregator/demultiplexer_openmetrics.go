@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/openmetrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// the staleness window can't be shorter than a flush, nor keep the series
+	// not flushed anymore for too long
+	minOpenMetricsStaleness = DefaultFlushInterval
+	maxOpenMetricsStaleness = time.Hour
+)
+
+// openMetricsEndpoint serves the series and sketches flushed by the aggregator
+// on a local `/metrics` endpoint in the OpenMetrics text format.
+type openMetricsEndpoint struct {
+	store  *openmetrics.Store
+	server *http.Server
+}
+
+func newOpenMetricsEndpoint() *openMetricsEndpoint {
+	staleness := time.Duration(config.Datadog.GetInt("openmetrics_endpoint.staleness_seconds")) * time.Second
+	if staleness < minOpenMetricsStaleness {
+		log.Warnf("openmetrics_endpoint.staleness_seconds is too low, using %s", minOpenMetricsStaleness)
+		staleness = minOpenMetricsStaleness
+	} else if staleness > maxOpenMetricsStaleness {
+		log.Warnf("openmetrics_endpoint.staleness_seconds is too high, using %s", maxOpenMetricsStaleness)
+		staleness = maxOpenMetricsStaleness
+	}
+
+	store := openmetrics.NewStore(staleness)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", store)
+
+	addr := net.JoinHostPort(config.Datadog.GetString("openmetrics_endpoint.bind_host"), strconv.Itoa(config.Datadog.GetInt("openmetrics_endpoint.port")))
+	return &openMetricsEndpoint{
+		store: store,
+		server: &http.Server{
+			Addr:    addr,
+			Handler: mux,
+		},
+	}
+}
+
+func (e *openMetricsEndpoint) start() {
+	listener, err := net.Listen("tcp", e.server.Addr)
+	if err != nil {
+		log.Errorf("Could not start the OpenMetrics endpoint on %s: %v", e.server.Addr, err)
+		return
+	}
+	log.Infof("Exposing the aggregated metrics in the OpenMetrics format on http://%s/metrics", listener.Addr())
+	go func() {
+		if err := e.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Error serving the OpenMetrics endpoint: %v", err)
+		}
+	}()
+}
+
+func (e *openMetricsEndpoint) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := e.server.Shutdown(ctx); err != nil {
+		log.Debugf("Error stopping the OpenMetrics endpoint: %v", err)
+	}
+}
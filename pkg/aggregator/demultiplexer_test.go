@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
 	providerMocks "github.com/DataDog/datadog-agent/pkg/util/containers/providers/mock"
 
@@ -199,3 +200,25 @@ func TestDemuxFlushAggregatorToSerializer(t *testing.T) {
 	require.Len(series, 3)
 	require.Len(sketches, 0)
 }
+
+func TestDemuxAddTimeSamples(t *testing.T) {
+	require := require.New(t)
+
+	opts := demuxTestOptions()
+	demux := initAgentDemultiplexer(opts, "")
+	require.NotNil(demux)
+
+	samples := make([]metrics.MetricSample, MetricSamplePoolBatchSize+1)
+	for i := range samples {
+		samples[i] = metrics.MetricSample{Name: "my.log.metric", Value: float64(i), Mtype: metrics.CountType}
+	}
+	demux.AddTimeSamples(samples)
+
+	// the samples are split in batches of the metric sample pool
+	batch := <-demux.aggregator.bufferedMetricIn
+	require.Len(batch, MetricSamplePoolBatchSize)
+	require.Equal(samples[:MetricSamplePoolBatchSize], batch)
+	batch = <-demux.aggregator.bufferedMetricIn
+	require.Len(batch, 1)
+	require.Equal(samples[MetricSamplePoolBatchSize], batch[0])
+}
@@ -96,6 +96,11 @@ func (m *MockSender) FinalizeCheckServiceTag() {
 	m.Called()
 }
 
+//SetHistogramCopyToDistribution enables the setting of the histogram copy to distribution mock call.
+func (m *MockSender) SetHistogramCopyToDistribution(enabled bool, prefix string) {
+	m.Called(enabled, prefix)
+}
+
 //GetSenderStats enables the get metric stats mock call.
 func (m *MockSender) GetSenderStats() check.SenderStats {
 	m.Called()
@@ -81,6 +81,7 @@ func (m *MockSender) SetupAcceptAll() {
 	m.On("SetCheckCustomTags", mock.AnythingOfType("[]string")).Return()
 	m.On("SetCheckService", mock.AnythingOfType("string")).Return()
 	m.On("FinalizeCheckServiceTag").Return()
+	m.On("SetHistogramCopyToDistribution", mock.AnythingOfType("bool"), mock.AnythingOfType("string")).Return()
 	m.On("Commit").Return()
 }
 
@@ -0,0 +1,356 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package openmetrics exposes the series and sketches flushed by the aggregator
+// in the OpenMetrics text format.
+package openmetrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ContentType is the content type of the OpenMetrics text format
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// summaryQuantiles are the quantiles exposed for the sketches
+var summaryQuantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+// entry is the last flushed value of a serie, or of a sketch when sketch is set
+type entry struct {
+	name     string
+	labels   string
+	value    float64
+	sketch   *quantile.Sketch
+	ts       float64
+	lastSeen time.Time
+	family   *family
+}
+
+// family is a metric family exposed by the store, the gauge of a serie or the summary
+// of a sketch. Distinct metrics can't share the names of a family once sanitized.
+type family struct {
+	name       string
+	metricName string
+	summary    bool
+	// entries is the number of entries of the family, it is forgotten with its last entry.
+	entries int
+}
+
+// names returns the names of the samples of the family.
+func (f *family) names() []string {
+	if f.summary {
+		return []string{f.name, f.name + "_sum", f.name + "_count"}
+	}
+	return []string{f.name}
+}
+
+// Store keeps the last value of the series and sketches flushed by the aggregator
+// until they are not flushed anymore for the staleness window.
+type Store struct {
+	mu        sync.Mutex
+	staleness time.Duration
+	entries   map[string]*entry
+	// families holds the families by the names of their samples.
+	families map[string]*family
+	now      func() time.Time
+}
+
+// NewStore returns a new Store forgetting the series and sketches not flushed
+// for the staleness duration.
+func NewStore(staleness time.Duration) *Store {
+	return &Store{
+		staleness: staleness,
+		entries:   make(map[string]*entry),
+		families:  make(map[string]*family),
+		now:       time.Now,
+	}
+}
+
+// AddSerie stores the last point of a serie.
+func (s *Store) AddSerie(serie *metrics.Serie) {
+	if len(serie.Points) == 0 {
+		return
+	}
+	point := serie.Points[0]
+	for _, p := range serie.Points[1:] {
+		if p.Ts >= point.Ts {
+			point = p
+		}
+	}
+
+	name := sanitizeName(serie.Name)
+	labels := formatLabels(serie.Host, serie.Device, serie.Tags)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(&entry{
+		name:     name,
+		labels:   labels,
+		value:    point.Value,
+		ts:       point.Ts,
+		lastSeen: s.now(),
+	}, serie.Name)
+}
+
+// AddSketches stores the last point of each sketch series.
+func (s *Store) AddSketches(sketches metrics.SketchSeriesList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, sketch := range sketches {
+		if len(sketch.Points) == 0 {
+			continue
+		}
+		point := sketch.Points[0]
+		for _, p := range sketch.Points[1:] {
+			if p.Ts >= point.Ts {
+				point = p
+			}
+		}
+		if point.Sketch == nil {
+			continue
+		}
+
+		name := sanitizeName(sketch.Name)
+		labels := formatLabels(sketch.Host, "", sketch.Tags)
+		s.set(&entry{
+			name:     name,
+			labels:   labels,
+			sketch:   point.Sketch.Copy(),
+			ts:       float64(point.Ts),
+			lastSeen: now,
+		}, sketch.Name)
+	}
+}
+
+// set stores the entry of the metric, unless its family conflicts with the family of
+// another metric. The first family is kept until all its entries are stale.
+// It must be called with the lock held.
+func (s *Store) set(e *entry, metricName string) {
+	f := s.family(e.name, metricName, e.sketch != nil)
+	if f == nil {
+		return
+	}
+	e.family = f
+	key := e.name + e.labels
+	if _, ok := s.entries[key]; !ok {
+		f.entries++
+	}
+	s.entries[key] = e
+}
+
+// family returns the family of the metric, adding it when it is new, or nil when
+// the names of its samples are already used by another family.
+// It must be called with the lock held.
+func (s *Store) family(name, metricName string, summary bool) *family {
+	if f, ok := s.families[name]; ok && f.name == name && f.metricName == metricName && f.summary == summary {
+		return f
+	}
+	f := &family{name: name, metricName: metricName, summary: summary}
+	for _, n := range f.names() {
+		if other, ok := s.families[n]; ok {
+			log.Debugf("Not exposing %s in the OpenMetrics endpoint, its name %s is already used by %s", metricName, n, other.metricName)
+			return nil
+		}
+	}
+	for _, n := range f.names() {
+		s.families[n] = f
+	}
+	return f
+}
+
+// Len returns the number of series and sketches stored.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// RemoveStale removes the series and sketches not flushed for the staleness duration.
+func (s *Store) RemoveStale() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeStale()
+}
+
+func (s *Store) removeStale() {
+	deadline := s.now().Add(-s.staleness)
+	for key, e := range s.entries {
+		if e.lastSeen.Before(deadline) {
+			delete(s.entries, key)
+			e.family.entries--
+			if e.family.entries == 0 {
+				for _, n := range e.family.names() {
+					delete(s.families, n)
+				}
+			}
+		}
+	}
+}
+
+// sortedEntries removes the stale entries and returns the other ones sorted by name.
+func (s *Store) sortedEntries() []*entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeStale()
+	entries := make([]*entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].name != entries[j].name {
+			return entries[i].name < entries[j].name
+		}
+		return entries[i].labels < entries[j].labels
+	})
+	return entries
+}
+
+// WriteTo writes the stored series and sketches in the OpenMetrics text format.
+// The series are exposed as gauges, and the sketches as summaries.
+func (s *Store) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.NewBuffer(nil)
+	entries := s.sortedEntries()
+
+	for i, e := range entries {
+		// the samples of a metric family must be grouped after a single TYPE line
+		if i == 0 || entries[i-1].name != e.name {
+			if e.sketch == nil {
+				fmt.Fprintf(buf, "# TYPE %s gauge\n", e.name)
+			} else {
+				fmt.Fprintf(buf, "# TYPE %s summary\n", e.name)
+			}
+		}
+		ts := strconv.FormatFloat(e.ts, 'f', -1, 64)
+		if e.sketch == nil {
+			fmt.Fprintf(buf, "%s%s %s %s\n", e.name, wrapLabels(e.labels, ""), formatValue(e.value), ts)
+			continue
+		}
+		config := quantile.Default()
+		for _, q := range summaryQuantiles {
+			quantileLabel := `quantile="` + strconv.FormatFloat(q, 'f', -1, 64) + `"`
+			fmt.Fprintf(buf, "%s%s %s %s\n", e.name, wrapLabels(e.labels, quantileLabel), formatValue(e.sketch.Quantile(config, q)), ts)
+		}
+		fmt.Fprintf(buf, "%s_sum%s %s %s\n", e.name, wrapLabels(e.labels, ""), formatValue(e.sketch.Basic.Sum), ts)
+		fmt.Fprintf(buf, "%s_count%s %d %s\n", e.name, wrapLabels(e.labels, ""), e.sketch.Basic.Cnt, ts)
+	}
+	buf.WriteString("# EOF\n")
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the stored series and sketches in the OpenMetrics text format.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	s.WriteTo(w) //nolint:errcheck
+}
+
+// sanitizeName converts a Datadog metric name to a valid OpenMetrics metric name.
+func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+func sanitize(name string, allowColon bool) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(i > 0 && r >= '0' && r <= '9') || (allowColon && r == ':')
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// formatLabels converts the host, device and tags of a serie to labels. The
+// values of the tags sharing the same key are joined with commas.
+func formatLabels(host, device string, tags []string) string {
+	values := make(map[string][]string, len(tags)+2)
+	if host != "" {
+		values["host"] = []string{host}
+	}
+	if device != "" {
+		values["device"] = []string{device}
+	}
+	for _, tag := range tags {
+		key, value := tag, ""
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key, value = tag[:i], tag[i+1:]
+		}
+		key = sanitize(key, false)
+		if key == "" {
+			continue
+		}
+		values[key] = append(values[key], value)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		sort.Strings(values[key])
+		b.WriteString(key)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(strings.Join(values[key], ",")))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+func wrapLabels(labels, extra string) string {
+	if extra != "" {
+		if labels != "" {
+			labels += ","
+		}
+		labels += extra
+	}
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
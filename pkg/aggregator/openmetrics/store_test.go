@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bytes"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+)
+
+func newTestStore(staleness time.Duration) (*Store, *time.Time) {
+	now := time.Unix(1000, 0)
+	store := NewStore(staleness)
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestStoreSeries(t *testing.T) {
+	store, _ := newTestStore(time.Minute)
+
+	store.AddSerie(&metrics.Serie{
+		Name:   "my.metric",
+		Points: []metrics.Point{{Ts: 20, Value: 2}, {Ts: 10, Value: 1}},
+		Tags:   []string{"env:prod", "role:b", "role:a", "standalone", "bad-key:\"quoted\"\n"},
+		Host:   "my-host",
+	})
+	store.AddSerie(&metrics.Serie{Name: "my.metric", Points: []metrics.Point{{Ts: 10, Value: math.Inf(1)}}})
+	store.AddSerie(&metrics.Serie{Name: "0other-metric", Points: []metrics.Point{{Ts: 10.5, Value: 0.25}}})
+	store.AddSerie(&metrics.Serie{Name: "no.points"})
+
+	var buf bytes.Buffer
+	_, err := store.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, `# TYPE _other_metric gauge
+_other_metric 0.25 10.5
+# TYPE my_metric gauge
+my_metric +Inf 10
+my_metric{bad_key="\"quoted\"\n",env="prod",host="my-host",role="a,b",standalone=""} 2 20
+# EOF
+`, buf.String())
+}
+
+func TestStoreSketches(t *testing.T) {
+	store, _ := newTestStore(time.Minute)
+
+	sketch := &quantile.Sketch{}
+	for i := 1; i <= 100; i++ {
+		sketch.Insert(quantile.Default(), float64(i))
+	}
+	store.AddSketches(metrics.SketchSeriesList{
+		{
+			Name:   "my.distribution",
+			Tags:   []string{"env:prod"},
+			Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch}},
+		},
+		{Name: "no.points"},
+	})
+	// the store keeps a copy of the sketch
+	sketch.Reset()
+
+	var buf bytes.Buffer
+	_, err := store.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Regexp(t, `^# TYPE my_distribution summary
+my_distribution\{env="prod",quantile="0.5"\} 5\d(\.\d+)? 10
+my_distribution\{env="prod",quantile="0.75"\} 7\d(\.\d+)? 10
+my_distribution\{env="prod",quantile="0.95"\} 9\d(\.\d+)? 10
+my_distribution\{env="prod",quantile="0.99"\} 9\d(\.\d+)? 10
+my_distribution_sum\{env="prod"\} 5050 10
+my_distribution_count\{env="prod"\} 100 10
+# EOF
+$`, buf.String())
+}
+
+func TestStoreStaleness(t *testing.T) {
+	store, now := newTestStore(time.Minute)
+
+	store.AddSerie(&metrics.Serie{Name: "old", Points: []metrics.Point{{Ts: 10, Value: 1}}})
+	*now = now.Add(30 * time.Second)
+	store.AddSerie(&metrics.Serie{Name: "recent", Points: []metrics.Point{{Ts: 40, Value: 1}}})
+	assert.Equal(t, 2, store.Len())
+
+	*now = now.Add(45 * time.Second)
+	store.RemoveStale()
+	assert.Equal(t, 1, store.Len())
+
+	// the series flushed again are kept
+	store.AddSerie(&metrics.Serie{Name: "recent", Points: []metrics.Point{{Ts: 85, Value: 2}}})
+	*now = now.Add(45 * time.Second)
+
+	rec := httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE recent gauge\nrecent 2 85\n# EOF\n", rec.Body.String())
+
+	*now = now.Add(time.Minute)
+	rec = httptest.NewRecorder()
+	store.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "# EOF\n", rec.Body.String())
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStoreNameConflicts(t *testing.T) {
+	store, now := newTestStore(time.Minute)
+
+	sketch := &quantile.Sketch{}
+	sketch.Insert(quantile.Default(), 1)
+	store.AddSerie(&metrics.Serie{Name: "a.b", Points: []metrics.Point{{Ts: 10, Value: 1}}})
+	store.AddSerie(&metrics.Serie{Name: "a.b", Points: []metrics.Point{{Ts: 10, Value: 2}}, Tags: []string{"env:prod"}})
+	// the same sanitized name as a.b
+	store.AddSerie(&metrics.Serie{Name: "a_b", Points: []metrics.Point{{Ts: 10, Value: 3}}})
+	// the same name with another type
+	store.AddSketches(metrics.SketchSeriesList{{Name: "a.b", Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch}}}})
+	// the name of the count of a summary
+	store.AddSketches(metrics.SketchSeriesList{{Name: "x", Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch}}}})
+	store.AddSerie(&metrics.Serie{Name: "x.count", Points: []metrics.Point{{Ts: 10, Value: 4}}})
+	// a summary whose count would be an existing gauge
+	store.AddSerie(&metrics.Serie{Name: "y.count", Points: []metrics.Point{{Ts: 10, Value: 5}}})
+	store.AddSketches(metrics.SketchSeriesList{{Name: "y", Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch}}}})
+	assert.Equal(t, 4, store.Len())
+
+	var buf bytes.Buffer
+	_, err := store.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Regexp(t, `^# TYPE a_b gauge
+a_b 1 10
+a_b\{env="prod"\} 2 10
+# TYPE x summary
+(x\{quantile="[0-9.]+"\} [0-9.]+ 10
+){4}x_sum 1 10
+x_count 1 10
+# TYPE y_count gauge
+y_count 5 10
+# EOF
+$`, buf.String())
+
+	// the names are released once the family is stale
+	*now = now.Add(2 * time.Minute)
+	store.RemoveStale()
+	store.AddSerie(&metrics.Serie{Name: "a_b", Points: []metrics.Point{{Ts: 130, Value: 3}}})
+	store.AddSerie(&metrics.Serie{Name: "x.count", Points: []metrics.Point{{Ts: 130, Value: 4}}})
+	buf.Reset()
+	_, err = store.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "# TYPE a_b gauge\na_b 3 130\n# TYPE x_count gauge\nx_count 4 130\n# EOF\n", buf.String())
+}
@@ -37,6 +37,7 @@ type Sender interface {
 	SetCheckCustomTags(tags []string)
 	SetCheckService(service string)
 	FinalizeCheckServiceTag()
+	SetHistogramCopyToDistribution(enabled bool, prefix string)
 	OrchestratorMetadata(msgs []serializer.ProcessMessageBody, clusterID string, nodeType int)
 	ContainerLifecycleEvent(msgs []serializer.ContainerLifecycleMessage)
 }
@@ -65,6 +66,8 @@ type checkSender struct {
 	eventPlatformOut        chan<- senderEventPlatformEvent
 	checkTags               []string
 	service                 string
+	histToDist              bool
+	histToDistPrefix        string
 }
 
 type senderMetricSample struct {
@@ -198,6 +201,13 @@ func (s *checkSender) FinalizeCheckServiceTag() {
 	}
 }
 
+// SetHistogramCopyToDistribution enables copying the histograms of the check to
+// distributions, named with the prefix followed by the name of the histogram.
+func (s *checkSender) SetHistogramCopyToDistribution(enabled bool, prefix string) {
+	s.histToDist = enabled
+	s.histToDistPrefix = prefix
+}
+
 // Commit commits the metric samples & histogram buckets that were added during a check run
 // Should be called at the end of every check run
 func (s *checkSender) Commit() {
@@ -288,7 +298,15 @@ func (s *checkSender) Counter(metric string, value float64, hostname string, tag
 // Histogram should be used to track the statistical distribution of a set of values during a check run
 // Should be called multiple times on the same (metric, hostname, tags) so that a distribution can be computed
 func (s *checkSender) Histogram(metric string, value float64, hostname string, tags []string) {
+	if !s.histToDist {
+		s.sendMetricSample(metric, value, hostname, tags, metrics.HistogramType, false)
+		return
+	}
+	// the aggregator sorts the tags of the samples in place, each sample needs its own
+	distTags := make([]string, len(tags), len(tags)+len(s.checkTags))
+	copy(distTags, tags)
 	s.sendMetricSample(metric, value, hostname, tags, metrics.HistogramType, false)
+	s.sendMetricSample(s.histToDistPrefix+metric, value, hostname, distTags, metrics.DistributionType, false)
 }
 
 // HistogramBucket should be called to directly send raw buckets to be submitted as distribution metrics
@@ -287,6 +287,42 @@ func TestGetSenderAddCheckCustomTagsMetrics(t *testing.T) {
 	assert.Equal(t, append(checkTags, customTags...), sms.metricSample.Tags)
 }
 
+func TestCheckSenderHistogramCopyToDistribution(t *testing.T) {
+	// this test not using anything global
+	// -
+
+	s := initSender(checkID1, "")
+	s.sender.SetCheckCustomTags([]string{"custom:tag"})
+	s.sender.SetHistogramCopyToDistribution(true, "dist.")
+
+	s.sender.Histogram("my.histo_metric", 3.0, "my-hostname", []string{"foo"})
+
+	histoSenderSample := <-s.senderMetricSampleChan
+	assert.Equal(t, "my.histo_metric", histoSenderSample.metricSample.Name)
+	assert.Equal(t, metrics.HistogramType, histoSenderSample.metricSample.Mtype)
+	assert.Equal(t, []string{"foo", "custom:tag"}, histoSenderSample.metricSample.Tags)
+
+	distSenderSample := <-s.senderMetricSampleChan
+	assert.EqualValues(t, checkID1, distSenderSample.id)
+	assert.Equal(t, "dist.my.histo_metric", distSenderSample.metricSample.Name)
+	assert.Equal(t, metrics.DistributionType, distSenderSample.metricSample.Mtype)
+	assert.Equal(t, 3.0, distSenderSample.metricSample.Value)
+	assert.Equal(t, "my-hostname", distSenderSample.metricSample.Host)
+	assert.Equal(t, []string{"foo", "custom:tag"}, distSenderSample.metricSample.Tags)
+	assert.Equal(t, false, distSenderSample.commit)
+
+	// the tags of the samples don't share their storage
+	histoSenderSample.metricSample.Tags[0] = "changed"
+	assert.Equal(t, "foo", distSenderSample.metricSample.Tags[0])
+
+	// the other types are not copied
+	s.sender.SetHistogramCopyToDistribution(false, "")
+	s.sender.Histogram("my.histo_metric", 3.0, "my-hostname", nil)
+	s.sender.Gauge("my.metric", 1.0, "my-hostname", nil)
+	assert.Equal(t, metrics.HistogramType, (<-s.senderMetricSampleChan).metricSample.Mtype)
+	assert.Equal(t, metrics.GaugeType, (<-s.senderMetricSampleChan).metricSample.Mtype)
+}
+
 func TestGetSenderAddCheckCustomTagsService(t *testing.T) {
 	// this test not using anything global
 	// -
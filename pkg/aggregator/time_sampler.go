@@ -37,7 +37,7 @@ func NewTimeSampler(interval int64, cache *tags.Store) *TimeSampler {
 	}
 	return &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache),
+		contextResolver:             newTimestampContextResolver(cache, newCardinalityLimiterFromConfig()),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
@@ -55,7 +55,11 @@ func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) b
 // Add the metricSample to the correct bucket
 func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := s.contextResolver.trackContext(metricSample, timestamp)
+	if !ok {
+		// the context exceeds the cardinality limits
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
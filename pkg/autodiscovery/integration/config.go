@@ -62,17 +62,21 @@ type Config struct {
 
 // CommonInstanceConfig holds the reserved fields for the yaml instance data
 type CommonInstanceConfig struct {
-	MinCollectionInterval int      `yaml:"min_collection_interval"`
-	EmptyDefaultHostname  bool     `yaml:"empty_default_hostname"`
-	Tags                  []string `yaml:"tags"`
-	Service               string   `yaml:"service"`
-	Name                  string   `yaml:"name"`
-	Namespace             string   `yaml:"namespace"`
+	MinCollectionInterval             int      `yaml:"min_collection_interval"`
+	EmptyDefaultHostname              bool     `yaml:"empty_default_hostname"`
+	Tags                              []string `yaml:"tags"`
+	Service                           string   `yaml:"service"`
+	Name                              string   `yaml:"name"`
+	Namespace                         string   `yaml:"namespace"`
+	HistogramCopyToDistribution       bool     `yaml:"histogram_copy_to_distribution"`
+	HistogramCopyToDistributionPrefix string   `yaml:"histogram_copy_to_distribution_prefix"`
 }
 
 // CommonGlobalConfig holds the reserved fields for the yaml init_config data
 type CommonGlobalConfig struct {
-	Service string `yaml:"service"`
+	Service                           string `yaml:"service"`
+	HistogramCopyToDistribution       bool   `yaml:"histogram_copy_to_distribution"`
+	HistogramCopyToDistributionPrefix string `yaml:"histogram_copy_to_distribution_prefix"`
 }
 
 // AdvancedADIdentifier contains user-defined autodiscovery information
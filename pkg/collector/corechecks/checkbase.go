@@ -87,6 +87,16 @@ func (c *CheckBase) Configure(data integration.Data, initConfig integration.Data
 		s.SetCheckService(commonGlobalOptions.Service)
 	}
 
+	// Copy the histograms of all the instances of this check to distributions
+	if commonGlobalOptions.HistogramCopyToDistribution {
+		s, err := aggregator.GetSender(c.checkID)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
+			return err
+		}
+		s.SetHistogramCopyToDistribution(true, commonGlobalOptions.HistogramCopyToDistributionPrefix)
+	}
+
 	err = c.CommonConfigure(data, source)
 	if err != nil {
 		return err
@@ -148,6 +158,16 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 		s.SetCheckService(commonOptions.Service)
 	}
 
+	// Copy the histograms of this instance to distributions, overriding the prefix possibly defined globally
+	if commonOptions.HistogramCopyToDistribution {
+		s, err := aggregator.GetSender(c.checkID)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
+			return err
+		}
+		s.SetHistogramCopyToDistribution(true, commonOptions.HistogramCopyToDistributionPrefix)
+	}
+
 	c.source = source
 	return nil
 }
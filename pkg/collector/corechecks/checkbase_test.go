@@ -51,6 +51,27 @@ func TestCommonConfigure(t *testing.T) {
 	mockSender.AssertExpectations(t)
 }
 
+func TestConfigureHistogramCopyToDistribution(t *testing.T) {
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase("test"),
+	}
+	mockSender := mocksender.NewMockSender(mycheck.ID())
+	mockSender.On("FinalizeCheckServiceTag").Return()
+
+	// enabled for all the instances of the check
+	mockSender.On("SetHistogramCopyToDistribution", true, "dist.").Return().Once()
+	err := mycheck.Configure([]byte(defaultsInstance), []byte("histogram_copy_to_distribution: true\nhistogram_copy_to_distribution_prefix: dist.\n"), "test")
+	assert.NoError(t, err)
+	mockSender.AssertExpectations(t)
+
+	// enabled for a single instance
+	mockSender.On("SetHistogramCopyToDistribution", true, "").Return().Once()
+	err = mycheck.Configure([]byte("histogram_copy_to_distribution: true\n"), []byte(initConfig), "test")
+	assert.NoError(t, err)
+	mockSender.AssertExpectations(t)
+	mockSender.AssertNumberOfCalls(t, "SetHistogramCopyToDistribution", 2)
+}
+
 func TestCommonConfigureCustomID(t *testing.T) {
 	checkName := "test"
 	mycheck := &dummyCheck{
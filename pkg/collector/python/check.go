@@ -215,6 +215,16 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 		}
 	}
 
+	// Copy the histograms of all the instances of this check to distributions
+	if commonGlobalOptions.HistogramCopyToDistribution {
+		s, err := aggregator.GetSender(c.id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
+		} else {
+			s.SetHistogramCopyToDistribution(true, commonGlobalOptions.HistogramCopyToDistributionPrefix)
+		}
+	}
+
 	commonOptions := integration.CommonInstanceConfig{}
 	if err := yaml.Unmarshal(data, &commonOptions); err != nil {
 		log.Errorf("invalid instance section for check %s: %s", string(c.id), err)
@@ -246,6 +256,16 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 		}
 	}
 
+	// Copy the histograms of this instance to distributions, overriding the prefix possibly defined globally
+	if commonOptions.HistogramCopyToDistribution {
+		s, err := aggregator.GetSender(c.id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
+		} else {
+			s.SetHistogramCopyToDistribution(true, commonOptions.HistogramCopyToDistributionPrefix)
+		}
+	}
+
 	cInitConfig := TrackedCString(string(initConfig))
 	cInstance := TrackedCString(string(data))
 	cCheckID := TrackedCString(string(c.id))
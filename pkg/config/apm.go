@@ -34,6 +34,11 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.dynamodb.enabled")
+	config.SetKnown("apm_config.obfuscation.dynamodb.keep_values")
+	config.SetKnown("apm_config.obfuscation.dynamodb.obfuscate_sql_values")
+	config.SetKnown("apm_config.obfuscation.kafka.enabled")
+	config.SetKnown("apm_config.obfuscation.key_value.enabled")
 	config.SetKnown("apm_config.filter_tags.require")
 	config.SetKnown("apm_config.filter_tags.reject")
 	config.SetKnown("apm_config.extra_sample_rate")
@@ -72,6 +77,12 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.errors_per_second", "DD_APM_ERROR_TPS")
 	config.BindEnv("apm_config.disable_rare_sampler", "DD_APM_DISABLE_RARE_SAMPLER")
 	config.BindEnv("apm_config.max_remote_traces_per_second", "DD_APM_MAX_REMOTE_TPS")
+	config.BindEnv("apm_config.stats_span_tags", "DD_APM_STATS_SPAN_TAGS")
+	config.BindEnv("apm_config.stats_span_tags_max_cardinality", "DD_APM_STATS_SPAN_TAGS_MAX_CARDINALITY")
+	config.BindEnv("apm_config.tail_sampling.enabled", "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.decision_wait_seconds", "DD_APM_TAIL_SAMPLING_DECISION_WAIT_SECONDS")
+	config.BindEnv("apm_config.tail_sampling.max_buffered_bytes", "DD_APM_TAIL_SAMPLING_MAX_BUFFERED_BYTES")
+	config.BindEnv("apm_config.tail_sampling.policies", "DD_APM_TAIL_SAMPLING_POLICIES")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -91,6 +102,7 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.sync_flushing", "DD_APM_SYNC_FLUSHING")
 	config.BindEnv("apm_config.filter_tags.require", "DD_APM_FILTER_TAGS_REQUIRE")
 	config.BindEnv("apm_config.filter_tags.reject", "DD_APM_FILTER_TAGS_REJECT")
+	config.BindEnv("apm_config.filter_rules", "DD_APM_FILTER_RULES")
 	config.BindEnv("apm_config.internal_profiling.enabled", "DD_APM_INTERNAL_PROFILING_ENABLED")
 	config.BindEnv("apm_config.debugger_dd_url", "DD_APM_DEBUGGER_DD_URL")
 	config.BindEnv("apm_config.debugger_api_key", "DD_APM_DEBUGGER_API_KEY")
@@ -99,6 +111,7 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.telemetry.additional_endpoints", "DD_APM_TELEMETRY_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.obfuscation.credit_cards.enabled", "DD_APM_OBFUSCATION_CREDIT_CARDS_ENABLED")
 	config.BindEnv("apm_config.obfuscation.credit_cards.luhn", "DD_APM_OBFUSCATION_CREDIT_CARDS_LUHN")
+	config.BindEnv("apm_config.obfuscation.span_types", "DD_APM_OBFUSCATION_SPAN_TYPES")
 
 	config.SetEnvKeyTransformer("apm_config.ignore_resources", func(in string) interface{} {
 		r, err := splitCSVString(in, ',')
@@ -117,6 +130,26 @@ func setupAPM(config Config) {
 		return strings.Split(in, " ")
 	})
 
+	config.SetEnvKeyTransformer("apm_config.stats_span_tags", func(in string) interface{} {
+		return strings.Fields(in)
+	})
+
+	config.SetEnvKeyTransformer("apm_config.tail_sampling.policies", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.tail_sampling.policies" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.SetEnvKeyTransformer("apm_config.obfuscation.span_types", func(in string) interface{} {
+		var out map[string]string
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.obfuscation.span_types" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.replace_tags", func(in string) interface{} {
 		var out []map[string]string
 		if err := json.Unmarshal([]byte(in), &out); err != nil {
@@ -125,6 +158,14 @@ func setupAPM(config Config) {
 		return out
 	})
 
+	config.SetEnvKeyTransformer("apm_config.filter_rules", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.filter_rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.analyzed_spans", func(in string) interface{} {
 		out, err := parseAnalyzedSpans(in)
 		if err != nil {
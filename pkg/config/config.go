@@ -164,9 +164,10 @@ type Proxy struct {
 
 // MappingProfile represent a group of mappings
 type MappingProfile struct {
-	Name     string          `mapstructure:"name" json:"name"`
-	Prefix   string          `mapstructure:"prefix" json:"prefix"`
-	Mappings []MetricMapping `mapstructure:"mappings" json:"mappings"`
+	Name        string          `mapstructure:"name" json:"name"`
+	Prefix      string          `mapstructure:"prefix" json:"prefix"`
+	Mappings    []MetricMapping `mapstructure:"mappings" json:"mappings"`
+	TagMappings []TagMapping    `mapstructure:"tag_mappings" json:"tag_mappings"`
 }
 
 // MetricMapping represent one mapping rule
@@ -175,6 +176,41 @@ type MetricMapping struct {
 	MatchType string            `mapstructure:"match_type" json:"match_type"`
 	Name      string            `mapstructure:"name" json:"name"`
 	Tags      map[string]string `mapstructure:"tags" json:"tags"`
+	Action    string            `mapstructure:"action" json:"action"`
+}
+
+// TagMapping represent one rule rewriting the tags of the metrics
+type TagMapping struct {
+	Match     string            `mapstructure:"match" json:"match"`
+	MatchType string            `mapstructure:"match_type" json:"match_type"`
+	Tags      map[string]string `mapstructure:"tags" json:"tags"`
+	Action    string            `mapstructure:"action" json:"action"`
+}
+
+// HistogramOverride represent the aggregates and percentiles of the histograms
+// of the metrics named Name, or starting with Prefix
+type HistogramOverride struct {
+	Name        string   `mapstructure:"name" json:"name"`
+	Prefix      string   `mapstructure:"prefix" json:"prefix"`
+	Aggregates  []string `mapstructure:"aggregates" json:"aggregates"`
+	Percentiles []string `mapstructure:"percentiles" json:"percentiles"`
+}
+
+// TagRule represent a DogStatsD rule dropping metric samples or removing their tags
+type TagRule struct {
+	MetricPrefix string   `mapstructure:"metric_prefix" json:"metric_prefix"`
+	MatchTags    []string `mapstructure:"match_tags" json:"match_tags"`
+	Action       string   `mapstructure:"action" json:"action"`
+	Tags         []string `mapstructure:"tags" json:"tags"`
+}
+
+// MetricRoutingRule represent a rule restricting the series and sketches sent
+// to a domain to the metrics starting with one of MetricPrefixes and having
+// at least one of MatchTags
+type MetricRoutingRule struct {
+	Domain         string   `mapstructure:"domain" json:"domain"`
+	MetricPrefixes []string `mapstructure:"metric_prefixes" json:"metric_prefixes"`
+	MatchTags      []string `mapstructure:"match_tags" json:"match_tags"`
 }
 
 // Endpoint represent a datadog endpoint
@@ -411,9 +447,22 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("proc_root", "/proc")
 	config.BindEnvAndSetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
+	config.BindEnv("histogram_overrides")
+	config.SetEnvKeyTransformer("histogram_overrides", func(in string) interface{} {
+		var overrides []HistogramOverride
+		if err := json.Unmarshal([]byte(in), &overrides); err != nil {
+			log.Errorf(`"histogram_overrides" can not be parsed: %v`, err)
+		}
+		return overrides
+	})
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
 	config.BindEnvAndSetDefault("aggregator_use_tags_store", true)
+	// Local endpoint exposing the aggregated series and sketches in the OpenMetrics format
+	config.BindEnvAndSetDefault("openmetrics_endpoint.enabled", false)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.bind_host", "localhost")
+	config.BindEnvAndSetDefault("openmetrics_endpoint.port", 5020)
+	config.BindEnvAndSetDefault("openmetrics_endpoint.staleness_seconds", 300)
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel", true)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_chan_size", 200)
@@ -440,6 +489,14 @@ func InitConfig(config Config) {
 
 	// Forwarder
 	config.BindEnvAndSetDefault("additional_endpoints", map[string][]string{})
+	config.BindEnv("metric_routing_rules")
+	config.SetEnvKeyTransformer("metric_routing_rules", func(in string) interface{} {
+		var rules []MetricRoutingRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"metric_routing_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
 	config.BindEnv("forwarder_retry_queue_max_size")                                                     // Deprecated in favor of `forwarder_retry_queue_payloads_max_size`
 	config.BindEnv("forwarder_retry_queue_payloads_max_size")                                            // Default value is defined inside `NewOptions` in pkg/forwarder/forwarder.go
@@ -485,7 +542,12 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
-	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_socket", "")  // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0) // Notice: 0 means TCP port closed
+	// Options are: newline, length_prefixed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_framing", "newline")
+	config.BindEnvAndSetDefault("dogstatsd_tcp_max_connections", 1024)       // 0 means no limit
+	config.BindEnvAndSetDefault("dogstatsd_tcp_idle_timeout", 5*time.Minute) // 0 means no timeout
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
 	config.BindEnvAndSetDefault("dogstatsd_stats_enable", false)
 	config.BindEnvAndSetDefault("dogstatsd_stats_buffer", 10)
@@ -496,6 +558,11 @@ func InitConfig(config Config) {
 	// is 10s), otherwise we won't be able to sample unseen counter as
 	// contexts will be deleted (see 'dogstatsd_expiry_seconds').
 	config.BindEnvAndSetDefault("dogstatsd_context_expiry_seconds", 300)
+	// Cardinality limits of the dogstatsd contexts, the contexts are counted until they expire.
+	config.BindEnvAndSetDefault("dogstatsd_max_contexts_per_metric", 0) // 0 means no limit
+	config.BindEnvAndSetDefault("dogstatsd_max_tag_values_per_metric", map[string]int{})
+	// Options are: drop, strip_tag
+	config.BindEnvAndSetDefault("dogstatsd_cardinality_limit_action", "drop")
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
@@ -521,6 +588,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_tag_rules")
+	config.SetEnvKeyTransformer("dogstatsd_tag_rules", func(in string) interface{} {
+		var rules []TagRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"dogstatsd_tag_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -803,6 +879,12 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.aggregation_timeout", 1000)
 	// Time in seconds
 	config.BindEnvAndSetDefault("logs_config.file_scan_period", 10.0)
+	// Maximum disk space used to store payloads while the logs destinations are unreachable,
+	// 0 means disabled and payloads are only buffered in memory.
+	config.BindEnvAndSetDefault("logs_config.spool_max_size_in_bytes", 0)
+	config.BindEnvAndSetDefault("logs_config.spool_path", "") // defaults to <logs_config.run_path>/spool
+	// Either "drop_oldest" to remove the oldest payloads or "block" to stop spooling when the spool is full.
+	config.BindEnvAndSetDefault("logs_config.spool_removal_policy", "drop_oldest")
 
 	// The cardinality of tags to send for checks and dogstatsd respectively.
 	// Choices are: low, orchestrator, high.
@@ -1519,6 +1601,54 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetHistogramOverrides returns the per-metric overrides of the histogram aggregates and percentiles
+func GetHistogramOverrides() ([]HistogramOverride, error) {
+	return getHistogramOverridesConfig(Datadog)
+}
+
+func getHistogramOverridesConfig(config Config) ([]HistogramOverride, error) {
+	var overrides []HistogramOverride
+	if config.IsSet("histogram_overrides") {
+		err := config.UnmarshalKey("histogram_overrides", &overrides)
+		if err != nil {
+			return []HistogramOverride{}, log.Errorf("Could not parse histogram_overrides: %v", err)
+		}
+	}
+	return overrides, nil
+}
+
+// GetDogstatsdTagRules returns the rules filtering the DogStatsD metric samples and their tags
+func GetDogstatsdTagRules() ([]TagRule, error) {
+	return getDogstatsdTagRulesConfig(Datadog)
+}
+
+func getDogstatsdTagRulesConfig(config Config) ([]TagRule, error) {
+	var rules []TagRule
+	if config.IsSet("dogstatsd_tag_rules") {
+		err := config.UnmarshalKey("dogstatsd_tag_rules", &rules)
+		if err != nil {
+			return []TagRule{}, log.Errorf("Could not parse dogstatsd_tag_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
+// GetMetricRoutingRules returns the rules restricting the series and sketches sent to some domains
+func GetMetricRoutingRules() ([]MetricRoutingRule, error) {
+	return getMetricRoutingRulesConfig(Datadog)
+}
+
+func getMetricRoutingRulesConfig(config Config) ([]MetricRoutingRule, error) {
+	var rules []MetricRoutingRule
+	if config.IsSet("metric_routing_rules") {
+		err := config.UnmarshalKey("metric_routing_rules", &rules)
+		if err != nil {
+			return []MetricRoutingRule{}, log.Errorf("Could not parse metric_routing_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
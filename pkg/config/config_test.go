@@ -981,6 +981,82 @@ dogstatsd_mapper_profiles:
 	assert.Empty(t, profiles)
 }
 
+func TestHistogramOverrides(t *testing.T) {
+	datadogYaml := `
+histogram_overrides:
+  - name: "api.request.latency"
+    percentiles: ["0.5", "0.999"]
+  - prefix: "db."
+    aggregates: ["max"]
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	overrides, err := getHistogramOverridesConfig(testConfig)
+
+	expectedOverrides := []HistogramOverride{
+		{Name: "api.request.latency", Percentiles: []string{"0.5", "0.999"}},
+		{Prefix: "db.", Aggregates: []string{"max"}},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedOverrides, overrides)
+}
+
+func TestDogstatsdTagRules(t *testing.T) {
+	datadogYaml := `
+dogstatsd_tag_rules:
+  - metric_prefix: "api."
+    match_tags: ["env:staging"]
+    action: drop
+  - action: strip_tags
+    tags: ["request_id"]
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getDogstatsdTagRulesConfig(testConfig)
+
+	expectedRules := []TagRule{
+		{MetricPrefix: "api.", MatchTags: []string{"env:staging"}, Action: "drop"},
+		{Action: "strip_tags", Tags: []string{"request_id"}},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRules, rules)
+
+	testConfig = setupConfFromYAML("dogstatsd_tag_rules:\n  - abc\n")
+	rules, err = getDogstatsdTagRulesConfig(testConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Could not parse dogstatsd_tag_rules")
+	assert.Empty(t, rules)
+}
+
+func TestMetricRoutingRules(t *testing.T) {
+	datadogYaml := `
+metric_routing_rules:
+  - domain: "https://app.datadoghq.eu"
+    match_tags: ["team:payments"]
+  - domain: "https://app.datadoghq.eu"
+    metric_prefixes: ["billing.", "invoices."]
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getMetricRoutingRulesConfig(testConfig)
+
+	expectedRules := []MetricRoutingRule{
+		{Domain: "https://app.datadoghq.eu", MatchTags: []string{"team:payments"}},
+		{Domain: "https://app.datadoghq.eu", MetricPrefixes: []string{"billing.", "invoices."}},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRules, rules)
+
+	testConfig = setupConfFromYAML("metric_routing_rules:\n  - abc\n")
+	rules, err = getMetricRoutingRulesConfig(testConfig)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Could not parse metric_routing_rules")
+	assert.Empty(t, rules)
+}
+
 func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	env := "DD_DOGSTATSD_MAPPER_PROFILES"
 	err := os.Setenv(env, `[{"name":"another_profile","prefix":"abcd","mappings":[{"match":"airflow\\.dag_processing\\.last_runtime\\.(.*)","match_type":"regex","name":"foo","tags":{"a":"$1","b":"$2"}}]},{"name":"some_other_profile","prefix":"some_other_profile.","mappings":[{"match":"some_other_profile.*","name":"some_other_profile.abc","tags":{"a":"$1"}}]}]`)
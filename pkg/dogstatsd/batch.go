@@ -18,13 +18,19 @@ type batcher struct {
 	samples      []metrics.MetricSample
 	samplesCount int
 
+	// samples with a timestamp, sent to the aggregator without being aggregated.
+	// The batch is only taken from the pool when such a sample is received.
+	timestampedSamples      []metrics.MetricSample
+	timestampedSamplesCount int
+
 	events        []*metrics.Event
 	serviceChecks []*metrics.ServiceCheck
 
 	// output channels
-	choutSamples       chan<- []metrics.MetricSample
-	choutEvents        chan<- []*metrics.Event
-	choutServiceChecks chan<- []*metrics.ServiceCheck
+	choutSamples            chan<- []metrics.MetricSample
+	choutTimestampedSamples chan<- []metrics.MetricSample
+	choutEvents             chan<- []*metrics.Event
+	choutServiceChecks      chan<- []*metrics.ServiceCheck
 
 	metricSamplePool *metrics.MetricSamplePool
 }
@@ -33,11 +39,12 @@ func newBatcher(demux aggregator.Demultiplexer) *batcher {
 	agg := demux.Aggregator()
 	s, e, sc := agg.GetBufferedChannels()
 	return &batcher{
-		samples:            agg.MetricSamplePool.GetBatch(),
-		metricSamplePool:   agg.MetricSamplePool,
-		choutSamples:       s,
-		choutEvents:        e,
-		choutServiceChecks: sc,
+		samples:                 agg.MetricSamplePool.GetBatch(),
+		metricSamplePool:        agg.MetricSamplePool,
+		choutSamples:            s,
+		choutTimestampedSamples: agg.GetBufferedTimestampedMetricsChannel(),
+		choutEvents:             e,
+		choutServiceChecks:      sc,
 	}
 }
 
@@ -49,6 +56,17 @@ func (b *batcher) appendSample(sample metrics.MetricSample) {
 	b.samplesCount++
 }
 
+func (b *batcher) appendTimestampedSample(sample metrics.MetricSample) {
+	if b.timestampedSamples == nil {
+		b.timestampedSamples = b.metricSamplePool.GetBatch()
+	} else if b.timestampedSamplesCount == len(b.timestampedSamples) {
+		b.flushTimestampedSamples()
+		b.timestampedSamples = b.metricSamplePool.GetBatch()
+	}
+	b.timestampedSamples[b.timestampedSamplesCount] = sample
+	b.timestampedSamplesCount++
+}
+
 func (b *batcher) appendEvent(event *metrics.Event) {
 	b.events = append(b.events, event)
 }
@@ -69,9 +87,22 @@ func (b *batcher) flushSamples() {
 	}
 }
 
+func (b *batcher) flushTimestampedSamples() {
+	if b.timestampedSamplesCount > 0 {
+		t1 := time.Now()
+		b.choutTimestampedSamples <- b.timestampedSamples[:b.timestampedSamplesCount]
+		t2 := time.Now()
+		tlmChannel.Observe(float64(t2.Sub(t1).Nanoseconds()), "timestamped_metrics")
+
+		b.timestampedSamplesCount = 0
+		b.timestampedSamples = nil
+	}
+}
+
 // flush pushes all batched metrics to the aggregator.
 func (b *batcher) flush() {
 	b.flushSamples()
+	b.flushTimestampedSamples()
 	if len(b.events) > 0 {
 		t1 := time.Now()
 		b.choutEvents <- b.events
@@ -7,6 +7,7 @@ package dogstatsd
 
 import (
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
@@ -99,7 +100,7 @@ func isMetricBlocklisted(metricName string, metricBlocklist []string) bool {
 }
 
 func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsdMetricSample, namespace string, excludedNamespaces []string,
-	metricBlocklist []string, defaultHostname string, origin string, entityIDPrecedenceEnabled bool, serverlessMode bool) []metrics.MetricSample {
+	metricBlocklist []string, tagRules []tagRule, defaultHostname string, origin string, entityIDPrecedenceEnabled bool, serverlessMode bool) []metrics.MetricSample {
 	metricName := ddSample.name
 	tags, hostnameFromTags, originID, k8sOriginID, cardinality := extractTagsMetadata(ddSample.tags, defaultHostname, origin, entityIDPrecedenceEnabled)
 
@@ -111,15 +112,33 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 		return []metrics.MetricSample{}
 	}
 
+	if len(tagRules) > 0 {
+		var keep bool
+		if tags, keep = applyTagRules(tagRules, metricName, tags); !keep {
+			dogstatsdMetricTagRulesDropped.Add(1)
+			return []metrics.MetricSample{}
+		}
+	}
+
 	if serverlessMode { // we don't want to set the host while running in serverless mode
 		hostnameFromTags = ""
 	}
 
 	mtype := enrichMetricType(ddSample.metricType)
 
+	// The timestamp is only supported by the gauges and counts, which can be
+	// sent without being aggregated. It is ignored for the other types.
+	// Like the other samples sent with their timestamp to the aggregator, it
+	// is stored in nanoseconds.
+	var timestamp float64
+	if ddSample.timestamp != 0 && (mtype == metrics.GaugeType || mtype == metrics.CounterType) {
+		timestamp = float64(ddSample.timestamp) * float64(time.Second)
+	}
+
 	// if 'ddSample.values' contains values we're enriching a multi-value
 	// dogstatsd message and will create a MetricSample per value. If not
-	// we will use 'ddSample.value'and return a single MetricSample
+	// we will use 'ddSample.value'and return a single MetricSample.
+	// The multi-value messages can't be timestamped, they are rejected by the parser.
 	if len(ddSample.values) > 0 {
 		for idx := range ddSample.values {
 			metricSamples = append(metricSamples,
@@ -148,6 +167,7 @@ func enrichMetricSample(metricSamples []metrics.MetricSample, ddSample dogstatsd
 		Value:       ddSample.value,
 		SampleRate:  ddSample.sampleRate,
 		RawValue:    ddSample.setValue,
+		Timestamp:   timestamp,
 		OriginID:    originID,
 		K8sOriginID: k8sOriginID,
 		Cardinality: cardinality,
@@ -8,6 +8,7 @@ package dogstatsd
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,7 +36,7 @@ func parseAndEnrichSingleMetricMessage(message []byte, namespace string, namespa
 	}
 
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, defaultHostname, "", true, false)
+	samples = enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, nil, defaultHostname, "", true, false)
 	if len(samples) != 1 {
 		return metrics.MetricSample{}, fmt.Errorf("wrong number of metrics parsed")
 	}
@@ -50,7 +51,7 @@ func parseAndEnrichMultipleMetricMessage(message []byte, namespace string, names
 	}
 
 	samples := []metrics.MetricSample{}
-	return enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, defaultHostname, "", true, false), nil
+	return enrichMetricSample(samples, parsed, namespace, namespaceBlacklist, metricBlocklist, nil, defaultHostname, "", true, false), nil
 }
 
 func parseAndEnrichServiceCheckMessage(message []byte, defaultHostname string) (*metrics.ServiceCheck, error) {
@@ -200,6 +201,30 @@ func TestConvertParseSingleWithSampleRate(t *testing.T) {
 	}
 }
 
+func TestConvertParseSingleWithTimestamp(t *testing.T) {
+	for metricSymbol, metricType := range symbolToType {
+
+		parsed, err := parseAndEnrichSingleMetricMessage([]byte("daemon:666|"+metricSymbol+"|T1657100430"), "", nil, nil, "default-hostname")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "daemon", parsed.Name)
+		assert.InEpsilon(t, 666.0, parsed.Value, epsilon)
+		assert.Equal(t, metricType, parsed.Mtype)
+
+		// only the gauges and the counts keep their timestamp
+		if metricType == metrics.GaugeType || metricType == metrics.CounterType {
+			assert.Equal(t, 1657100430.0*float64(time.Second), parsed.Timestamp)
+		} else {
+			assert.Zero(t, parsed.Timestamp)
+		}
+	}
+
+	parsed, err := parseAndEnrichSingleMetricMessage([]byte("daemon:abc|s|T1657100430"), "", nil, nil, "default-hostname")
+	assert.NoError(t, err)
+	assert.Equal(t, metrics.SetType, parsed.Mtype)
+	assert.Zero(t, parsed.Timestamp)
+}
+
 func TestConvertParseSet(t *testing.T) {
 	parsed, err := parseAndEnrichSingleMetricMessage([]byte("daemon:abc:def|s"), "", nil, nil, "default-hostname")
 
@@ -819,7 +844,7 @@ func TestMetricBlocklistShouldBlock(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, false)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, false)
 
 	assert.Equal(t, 0, len(samples))
 }
@@ -831,7 +856,7 @@ func TestServerlessModeShouldSetEmptyHostname(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, true)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, true)
 
 	assert.Equal(t, 1, len(samples))
 	assert.Equal(t, "", samples[0].Host)
@@ -847,7 +872,7 @@ func TestMetricBlocklistShouldNotBlock(t *testing.T) {
 	parsed, err := parser.parseMetricSample(message)
 	assert.NoError(t, err)
 	samples := []metrics.MetricSample{}
-	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, "default", "", true, false)
+	samples = enrichMetricSample(samples, parsed, "", nil, metricBlocklist, nil, "default", "", true, false)
 
 	assert.Equal(t, 1, len(samples))
 }
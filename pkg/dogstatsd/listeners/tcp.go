@@ -0,0 +1,335 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Framings of the TCP streams.
+const (
+	// TCPFramingNewline separates the messages with '\n'.
+	TCPFramingNewline = "newline"
+	// TCPFramingLengthPrefixed prefixes each frame with its length as a little-endian uint32,
+	// a frame can hold several messages separated with '\n'.
+	TCPFramingLengthPrefixed = "length_prefixed"
+)
+
+var (
+	tcpExpvars             = expvar.NewMap("dogstatsd-tcp")
+	tcpPacketReadingErrors = expvar.Int{}
+	tcpPackets             = expvar.Int{}
+	tcpBytes               = expvar.Int{}
+	tcpConnections         = expvar.Int{}
+	tcpRejectedConnections = expvar.Int{}
+	tcpActiveConnections   = expvar.Int{}
+)
+
+func init() {
+	tcpExpvars.Set("PacketReadingErrors", &tcpPacketReadingErrors)
+	tcpExpvars.Set("Packets", &tcpPackets)
+	tcpExpvars.Set("Bytes", &tcpBytes)
+	tcpExpvars.Set("Connections", &tcpConnections)
+	tcpExpvars.Set("RejectedConnections", &tcpRejectedConnections)
+	tcpExpvars.Set("ActiveConnections", &tcpActiveConnections)
+}
+
+// TCPListener implements the StatsdListener interface for TCP protocol.
+// It listens to a given TCP address and sends back packets ready to be
+// processed. The connections are read by blocking reads, when the packets
+// can't be processed fast enough the clients are slowed down.
+// Origin detection is not implemented for TCP.
+type TCPListener struct {
+	listener        net.Listener
+	packetsBuffer   *packets.Buffer
+	packetAssembler *packets.Assembler
+	bufferSize      int
+	framing         string
+	maxConnections  int
+	idleTimeout     time.Duration
+	trafficCapture  *replay.TrafficCapture // Currently ignored
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	framing := config.Datadog.GetString("dogstatsd_tcp_framing")
+	if framing != TCPFramingNewline && framing != TCPFramingLengthPrefixed {
+		return nil, fmt.Errorf("invalid dogstatsd_tcp_framing %q, must be %q or %q", framing, TCPFramingNewline, TCPFramingLengthPrefixed)
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	bufferSize := config.Datadog.GetInt("dogstatsd_buffer_size")
+	packetsBufferSize := config.Datadog.GetInt("dogstatsd_packet_buffer_size")
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+
+	packetsBuffer := packets.NewBuffer(uint(packetsBufferSize), flushTimeout, packetOut)
+	packetAssembler := packets.NewAssembler(flushTimeout, packetsBuffer, sharedPacketPoolManager, packets.TCP)
+
+	l := &TCPListener{
+		listener:        listener,
+		packetsBuffer:   packetsBuffer,
+		packetAssembler: packetAssembler,
+		bufferSize:      bufferSize,
+		framing:         framing,
+		maxConnections:  config.Datadog.GetInt("dogstatsd_tcp_max_connections"),
+		idleTimeout:     config.Datadog.GetDuration("dogstatsd_tcp_idle_timeout"),
+		trafficCapture:  capture,
+		conns:           make(map[net.Conn]struct{}),
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			continue
+		}
+		if !l.addConnection(conn) {
+			conn.Close()
+			continue
+		}
+		go l.handleConnection(conn)
+	}
+}
+
+// addConnection registers a new connection, it returns false when the connection must be rejected.
+func (l *TCPListener) addConnection(conn net.Conn) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping {
+		return false
+	}
+	if l.maxConnections > 0 && len(l.conns) >= l.maxConnections {
+		log.Warnf("dogstatsd-tcp: rejecting connection from %s, the maximum of %d connections is reached", conn.RemoteAddr(), l.maxConnections)
+		tcpRejectedConnections.Add(1)
+		tlmTCPConnections.Inc("rejected")
+		return false
+	}
+	l.conns[conn] = struct{}{}
+	l.wg.Add(1)
+	tcpConnections.Add(1)
+	tcpActiveConnections.Add(1)
+	tlmTCPConnections.Inc("accepted")
+	tlmTCPActiveConnections.Inc()
+	return true
+}
+
+func (l *TCPListener) removeConnection(conn net.Conn) {
+	conn.Close()
+	l.mu.Lock()
+	delete(l.conns, conn)
+	l.mu.Unlock()
+	tcpActiveConnections.Add(-1)
+	tlmTCPActiveConnections.Dec()
+	l.wg.Done()
+}
+
+func (l *TCPListener) handleConnection(conn net.Conn) {
+	defer l.removeConnection(conn)
+	log.Debugf("dogstatsd-tcp: new connection from %s", conn.RemoteAddr())
+
+	var err error
+	if l.framing == TCPFramingLengthPrefixed {
+		err = l.readLengthPrefixed(conn)
+	} else {
+		err = l.readNewlines(conn)
+	}
+
+	switch {
+	case l.isStopping():
+	case err == io.EOF:
+		log.Debugf("dogstatsd-tcp: client %s disconnected", conn.RemoteAddr())
+	case isTimeout(err):
+		log.Debugf("dogstatsd-tcp: closing idle connection from %s", conn.RemoteAddr())
+	default:
+		log.Errorf("dogstatsd-tcp: error reading from %s: %v", conn.RemoteAddr(), err)
+		tcpPacketReadingErrors.Add(1)
+		tlmTCPPackets.Inc("error")
+	}
+}
+
+// read reads from the connection, the connection is closed when it is idle for too long.
+func (l *TCPListener) read(conn net.Conn, buffer []byte) (int, error) {
+	if l.idleTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(l.idleTimeout)) //nolint:errcheck
+	}
+	return conn.Read(buffer)
+}
+
+// readNewlines reads messages separated with '\n' until an error occurs. The messages
+// larger than the buffer are dropped.
+func (l *TCPListener) readNewlines(conn net.Conn) error {
+	buffer := make([]byte, l.bufferSize)
+	startWriteIndex := 0
+	// dropping is true while the remaining of a message larger than the buffer is read
+	dropping := false
+	var t1, t2 time.Time
+	for {
+		n, err := l.read(conn, buffer[startWriteIndex:])
+		t1 = time.Now()
+		if n == 0 && err != nil {
+			return err
+		}
+		endIndex := startWriteIndex + n
+
+		lastNewline := bytes.LastIndexByte(buffer[:endIndex], '\n')
+		if lastNewline >= 0 {
+			start := 0
+			if dropping {
+				// skip the end of the message that was too large
+				start = bytes.IndexByte(buffer[:endIndex], '\n') + 1
+				dropping = false
+			}
+			var messages []byte
+			if start < lastNewline {
+				messages = buffer[start:lastNewline]
+			}
+			if len(messages) > 0 {
+				l.onMessages(messages)
+			}
+			startWriteIndex = copy(buffer, buffer[lastNewline+1:endIndex])
+		} else {
+			startWriteIndex = endIndex
+		}
+
+		if startWriteIndex >= len(buffer) {
+			// the message is larger than the buffer
+			if !dropping {
+				log.Debugf("dogstatsd-tcp: dropping a message larger than %d bytes from %s", len(buffer), conn.RemoteAddr())
+				tcpPacketReadingErrors.Add(1)
+				tlmTCPPackets.Inc("error")
+			}
+			dropping = true
+			startWriteIndex = 0
+		}
+
+		t2 = time.Now()
+		tlmListener.Observe(float64(t2.Sub(t1).Nanoseconds()), "tcp")
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLengthPrefixed reads length-prefixed frames until an error occurs. The frames larger
+// than the buffer are dropped.
+func (l *TCPListener) readLengthPrefixed(conn net.Conn) error {
+	buffer := make([]byte, l.bufferSize)
+	reader := readerFunc(func(p []byte) (int, error) { return l.read(conn, p) })
+	var header [4]byte
+	var t1, t2 time.Time
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			return err
+		}
+		t1 = time.Now()
+		size := int64(binary.LittleEndian.Uint32(header[:]))
+		if size > int64(len(buffer)) {
+			log.Debugf("dogstatsd-tcp: dropping a frame of %d bytes larger than %d bytes from %s", size, len(buffer), conn.RemoteAddr())
+			tcpPacketReadingErrors.Add(1)
+			tlmTCPPackets.Inc("error")
+			if _, err := io.CopyN(ioutil.Discard, reader, size); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.ReadFull(reader, buffer[:size]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		// the frame can end with a '\n' like the datagrams
+		if frame := bytes.TrimSuffix(buffer[:size], []byte{'\n'}); len(frame) > 0 {
+			l.onMessages(frame)
+		}
+		t2 = time.Now()
+		tlmListener.Observe(float64(t2.Sub(t1).Nanoseconds()), "tcp")
+	}
+}
+
+// onMessages forwards complete messages, separated with '\n', to the packet assembler.
+func (l *TCPListener) onMessages(messages []byte) {
+	tcpPackets.Add(1)
+	tcpBytes.Add(int64(len(messages)))
+	tlmTCPPackets.Inc("ok")
+	tlmTCPPacketsBytes.Add(float64(len(messages)))
+	// packetAssembler merges multiple packets together and sends them when its buffer is full
+	l.packetAssembler.AddMessage(messages)
+}
+
+func (l *TCPListener) isStopping() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopping
+}
+
+// Stop closes the TCP listener and the connections and stops listening
+func (l *TCPListener) Stop() {
+	l.listener.Close()
+	l.mu.Lock()
+	l.stopping = true
+	for conn := range l.conns {
+		// unblock the reads of the connection
+		conn.SetReadDeadline(time.Now()) //nolint:errcheck
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+	l.packetAssembler.Close()
+	l.packetsBuffer.Close()
+}
+
+// readerFunc is an io.Reader calling a function.
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
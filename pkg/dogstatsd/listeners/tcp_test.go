@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+var packetPoolManagerTCP = packets.NewPoolManager(packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size")))
+
+func newTestTCPListener(t *testing.T, framing string, maxConnections int, idleTimeout time.Duration) (*TCPListener, chan packets.Packets, string) {
+	config.Datadog.SetDefault("dogstatsd_tcp_port", 0)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", framing)
+	config.Datadog.SetDefault("dogstatsd_tcp_max_connections", maxConnections)
+	config.Datadog.SetDefault("dogstatsd_tcp_idle_timeout", idleTimeout)
+	defer func() {
+		config.Datadog.SetDefault("dogstatsd_tcp_framing", TCPFramingNewline)
+		config.Datadog.SetDefault("dogstatsd_tcp_max_connections", 1024)
+		config.Datadog.SetDefault("dogstatsd_tcp_idle_timeout", 5*time.Minute)
+	}()
+
+	packetChannel := make(chan packets.Packets, 10)
+	s, err := NewTCPListener(packetChannel, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+	go s.Listen()
+	return s, packetChannel, s.listener.Addr().String()
+}
+
+func receiveContents(t *testing.T, packetChannel chan packets.Packets) string {
+	select {
+	case pkts := <-packetChannel:
+		require.Len(t, pkts, 1)
+		assert.Equal(t, packets.TCP, pkts[0].Source)
+		assert.Equal(t, packets.NoOrigin, pkts[0].Origin)
+		return string(pkts[0].Contents)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timeout on receive channel")
+	}
+	return ""
+}
+
+func TestTCPListenerInvalidFraming(t *testing.T) {
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", "bogus")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_framing", TCPFramingNewline)
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestTCPListenerNewlineFraming(t *testing.T) {
+	s, packetChannel, addr := newTestTCPListener(t, TCPFramingNewline, 0, 0)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the last message is only forwarded once it is complete
+	_, err = conn.Write([]byte("daemon:666|g\ncustom_counter:1|c\npartial"))
+	require.NoError(t, err)
+	assert.Equal(t, "daemon:666|g\ncustom_counter:1|c", receiveContents(t, packetChannel))
+
+	_, err = conn.Write([]byte(":1|c\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "partial:1|c", receiveContents(t, packetChannel))
+}
+
+func TestTCPListenerNewlineFramingDropsLargeMessages(t *testing.T) {
+	s, packetChannel, addr := newTestTCPListener(t, TCPFramingNewline, 0, 0)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	large := strings.Repeat("a", 2*config.Datadog.GetInt("dogstatsd_buffer_size"))
+	_, err = conn.Write([]byte(large + ":1|c\nsmall:1|c\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "small:1|c", receiveContents(t, packetChannel))
+}
+
+func TestTCPListenerLengthPrefixedFraming(t *testing.T) {
+	s, packetChannel, addr := newTestTCPListener(t, TCPFramingLengthPrefixed, 0, 0)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writeFrame := func(frame string) {
+		var header [4]byte
+		binary.LittleEndian.PutUint32(header[:], uint32(len(frame)))
+		_, err := conn.Write(append(header[:], frame...))
+		require.NoError(t, err)
+	}
+	writeFrame(strings.Repeat("a", 2*config.Datadog.GetInt("dogstatsd_buffer_size")))
+	writeFrame("daemon:666|g\ncustom_counter:1|c\n")
+	assert.Equal(t, "daemon:666|g\ncustom_counter:1|c", receiveContents(t, packetChannel))
+}
+
+func TestTCPListenerMaxConnections(t *testing.T) {
+	s, packetChannel, addr := newTestTCPListener(t, TCPFramingNewline, 1, 0)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("first:1|c\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "first:1|c", receiveContents(t, packetChannel))
+
+	// the second connection is closed by the listener
+	rejected, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer rejected.Close()
+	rejected.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = rejected.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.False(t, isTimeout(err), "the connection should have been closed")
+}
+
+func TestTCPListenerIdleTimeout(t *testing.T) {
+	s, _, addr := newTestTCPListener(t, TCPFramingNewline, 0, 50*time.Millisecond)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.False(t, isTimeout(err), "the connection should have been closed")
+}
+
+func TestTCPListenerStopClosesConnections(t *testing.T) {
+	s, _, addr := newTestTCPListener(t, TCPFramingNewline, 0, 0)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	// wait for the connection to be registered
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.conns) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err, fmt.Sprintf("%s should not accept connections", addr))
+}
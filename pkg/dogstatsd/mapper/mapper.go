@@ -23,6 +23,11 @@ const (
 	matchTypeRegex    = "regex"
 )
 
+const (
+	actionMap  = "map"
+	actionDrop = "drop"
+)
+
 // MetricMapper contains mappings and cache instance
 type MetricMapper struct {
 	Profiles []MappingProfile
@@ -31,9 +36,10 @@ type MetricMapper struct {
 
 // MappingProfile represent a group of mappings
 type MappingProfile struct {
-	Name     string
-	Prefix   string
-	Mappings []*MetricMapping
+	Name        string
+	Prefix      string
+	Mappings    []*MetricMapping
+	TagMappings []*TagMapping
 }
 
 // MetricMapping represent one mapping rule
@@ -41,12 +47,28 @@ type MetricMapping struct {
 	name  string
 	tags  map[string]string
 	regex *regexp.Regexp
+	drop  bool
+}
+
+// TagMapping represent one rule rewriting a tag
+type TagMapping struct {
+	tags  map[string]string
+	regex *regexp.Regexp
+	drop  bool
 }
 
 // MapResult represent the outcome of the mapping
 type MapResult struct {
 	Name    string
 	Tags    []string
+	Drop    bool
+	matched bool
+}
+
+// tagMapResult represent the outcome of the mapping of a tag
+type tagMapResult struct {
+	tags    []string
+	drop    bool
 	matched bool
 }
 
@@ -69,7 +91,11 @@ func NewMetricMapper(configProfiles []config.MappingProfile, cacheSize int) (*Me
 			if matchType != matchTypeWildcard && matchType != matchTypeRegex {
 				return nil, fmt.Errorf("profile: %s, mapping num %d: invalid match type, must be `wildcard` or `regex`", profile.Name, i)
 			}
-			if currentMapping.Name == "" {
+			drop, err := parseAction(currentMapping.Action)
+			if err != nil {
+				return nil, fmt.Errorf("profile: %s, mapping num %d: %v", profile.Name, i, err)
+			}
+			if currentMapping.Name == "" && !drop {
 				return nil, fmt.Errorf("profile: %s, mapping num %d: name is required", profile.Name, i)
 			}
 			if currentMapping.Match == "" {
@@ -79,7 +105,28 @@ func NewMetricMapper(configProfiles []config.MappingProfile, cacheSize int) (*Me
 			if err != nil {
 				return nil, err
 			}
-			profile.Mappings = append(profile.Mappings, &MetricMapping{name: currentMapping.Name, tags: currentMapping.Tags, regex: regex})
+			profile.Mappings = append(profile.Mappings, &MetricMapping{name: currentMapping.Name, tags: currentMapping.Tags, regex: regex, drop: drop})
+		}
+		for i, currentMapping := range configProfile.TagMappings {
+			matchType := currentMapping.MatchType
+			if matchType == "" {
+				matchType = matchTypeWildcard
+			}
+			if matchType != matchTypeWildcard && matchType != matchTypeRegex {
+				return nil, fmt.Errorf("profile: %s, tag mapping num %d: invalid match type, must be `wildcard` or `regex`", profile.Name, i)
+			}
+			drop, err := parseAction(currentMapping.Action)
+			if err != nil {
+				return nil, fmt.Errorf("profile: %s, tag mapping num %d: %v", profile.Name, i, err)
+			}
+			if currentMapping.Match == "" {
+				return nil, fmt.Errorf("profile: %s, tag mapping num %d: match is required", profile.Name, i)
+			}
+			regex, err := buildTagRegex(currentMapping.Match, matchType)
+			if err != nil {
+				return nil, err
+			}
+			profile.TagMappings = append(profile.TagMappings, &TagMapping{tags: currentMapping.Tags, regex: regex, drop: drop})
 		}
 		profiles = append(profiles, profile)
 	}
@@ -108,7 +155,38 @@ func buildRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
 	return regex, nil
 }
 
-// Map returns a MapResult
+func parseAction(action string) (bool, error) {
+	switch action {
+	case "", actionMap:
+		return false, nil
+	case actionDrop:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid action `%s`, must be `%s` or `%s`", action, actionMap, actionDrop)
+}
+
+// buildTagRegex builds the regex matching a whole tag. Unlike the metric names,
+// the tags are not split in dot-separated parts so a wildcard matches any characters.
+func buildTagRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
+	if matchType == matchTypeWildcard {
+		if strings.Contains(matchRe, "**") {
+			return nil, fmt.Errorf("invalid wildcard match pattern `%s`, it should not contain consecutive `*`", matchRe)
+		}
+		parts := strings.Split(matchRe, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		matchRe = strings.Join(parts, "(.*?)")
+	}
+	regex, err := regexp.Compile("^" + matchRe + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid match `%s`. cannot compile regex: %v", matchRe, err)
+	}
+	return regex, nil
+}
+
+// Map returns a MapResult, or nil if no mapping matches the metric name.
+// The Drop field of the result is set when the metric must be dropped.
 func (m *MetricMapper) Map(metricName string) *MapResult {
 	for _, profile := range m.Profiles {
 		if !strings.HasPrefix(metricName, profile.Prefix) && profile.Prefix != "*" {
@@ -127,6 +205,12 @@ func (m *MetricMapper) Map(metricName string) *MapResult {
 				continue
 			}
 
+			if mapping.drop {
+				mapResult := &MapResult{Drop: true, matched: true}
+				m.cache.add(metricName, mapResult)
+				return mapResult
+			}
+
 			name := string(mapping.regex.ExpandString(
 				[]byte{},
 				mapping.name,
@@ -150,3 +234,65 @@ func (m *MetricMapper) Map(metricName string) *MapResult {
 	}
 	return nil
 }
+
+// MapTags rewrites the tags of a metric with the tag mappings of the first profile
+// matching the metric name which has tag mappings, the profiles without any are skipped.
+// The returned slice may share the tags backing array.
+// It returns false if the metric must be dropped.
+func (m *MetricMapper) MapTags(metricName string, tags []string) ([]string, bool) {
+	for profileIndex, profile := range m.Profiles {
+		if len(profile.TagMappings) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(metricName, profile.Prefix) && profile.Prefix != "*" {
+			continue
+		}
+
+		var mappedTags []string
+		for i, tag := range tags {
+			result, cached := m.cache.getTag(profileIndex, tag)
+			if !cached {
+				result = profile.mapTag(tag)
+				m.cache.addTag(profileIndex, tag, result)
+			}
+			if result.drop {
+				return nil, false
+			}
+			if !result.matched {
+				if mappedTags != nil {
+					mappedTags = append(mappedTags, tag)
+				}
+				continue
+			}
+			if mappedTags == nil {
+				mappedTags = make([]string, i, len(tags)+len(result.tags))
+				copy(mappedTags, tags[:i])
+			}
+			mappedTags = append(mappedTags, result.tags...)
+		}
+		if mappedTags == nil {
+			return tags, true
+		}
+		return mappedTags, true
+	}
+	return tags, true
+}
+
+func (p *MappingProfile) mapTag(tag string) *tagMapResult {
+	for _, mapping := range p.TagMappings {
+		matches := mapping.regex.FindStringSubmatchIndex(tag)
+		if len(matches) == 0 {
+			continue
+		}
+		if mapping.drop {
+			return &tagMapResult{drop: true, matched: true}
+		}
+		tags := make([]string, 0, len(mapping.tags))
+		for tagKey, tagValueExpr := range mapping.tags {
+			tagValue := string(mapping.regex.ExpandString([]byte{}, tagValueExpr, tag, matches))
+			tags = append(tags, tagKey+":"+tagValue)
+		}
+		return &tagMapResult{tags: tags, matched: true}
+	}
+	return &tagMapResult{matched: false}
+}
@@ -10,7 +10,15 @@ import (
 )
 
 type mapperCache struct {
-	cache *lru.Cache
+	cache    *lru.Cache
+	tagCache *lru.Cache
+}
+
+// tagCacheKey is the key of the tag mapping results, the same tag
+// can be mapped differently by each profile.
+type tagCacheKey struct {
+	profile int
+	tag     string
 }
 
 // newMapperCache creates a new mapperCache
@@ -19,7 +27,11 @@ func newMapperCache(size int) (*mapperCache, error) {
 	if err != nil {
 		return &mapperCache{}, err
 	}
-	return &mapperCache{cache: cache}, nil
+	tagCache, err := lru.New(size)
+	if err != nil {
+		return &mapperCache{}, err
+	}
+	return &mapperCache{cache: cache, tagCache: tagCache}, nil
 }
 
 // get returns:
@@ -36,3 +48,18 @@ func (m *mapperCache) get(metricName string) (*MapResult, bool) {
 func (m *mapperCache) add(metricName string, mapResult *MapResult) {
 	m.cache.Add(metricName, mapResult)
 }
+
+// getTag returns:
+// - the result of the mapping of a tag by a profile if found, otherwise nil
+// - a boolean indicating if the result has been found
+func (m *mapperCache) getTag(profile int, tag string) (*tagMapResult, bool) {
+	if result, ok := m.tagCache.Get(tagCacheKey{profile: profile, tag: tag}); ok {
+		return result.(*tagMapResult), true
+	}
+	return nil, false
+}
+
+// addTag adds the result of the mapping of a tag by a profile to cache
+func (m *mapperCache) addTag(profile int, tag string, result *tagMapResult) {
+	m.tagCache.Add(tagCacheKey{profile: profile, tag: tag}, result)
+}
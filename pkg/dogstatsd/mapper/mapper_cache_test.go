@@ -35,3 +35,25 @@ func TestMapperCache(t *testing.T) {
 	assert.Equal(t, true, found)
 	assert.Equal(t, &MapResult{matched: false}, result)
 }
+
+func TestMapperCacheTags(t *testing.T) {
+	c, err := newMapperCache(10)
+	assert.NoError(t, err)
+
+	c.addTag(0, "env:prod-eu", &tagMapResult{tags: []string{"env:prod", "region:eu"}, matched: true})
+	c.addTag(1, "env:prod-eu", &tagMapResult{matched: false})
+	assert.Equal(t, 2, c.tagCache.Len())
+	assert.Equal(t, 0, c.cache.Len())
+
+	result, found := c.getTag(0, "env:prod-eu")
+	assert.Equal(t, true, found)
+	assert.Equal(t, &tagMapResult{tags: []string{"env:prod", "region:eu"}, matched: true}, result)
+
+	result, found = c.getTag(1, "env:prod-eu")
+	assert.Equal(t, true, found)
+	assert.Equal(t, &tagMapResult{matched: false}, result)
+
+	result, found = c.getTag(2, "env:prod-eu")
+	assert.Equal(t, false, found)
+	assert.Equal(t, (*tagMapResult)(nil), result)
+}
@@ -324,6 +324,31 @@ dogstatsd_mapper_profiles:
 				{Name: "foo.bar1.duration", Tags: []string{"bar:bar", "foo:foo_name"}, matched: true},
 			},
 		},
+		{
+			name: "Drop action",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.graphite.*.*"
+        action: drop
+      - match: "test.job.*"
+        name: "test.job"
+        tags:
+          job_name: "$1"
+`,
+			packets: []string{
+				"test.graphite.foo.bar",
+				"test.graphite.foo.bar",
+				"test.job.my_job",
+			},
+			expectedResults: []MapResult{
+				{Drop: true, matched: true},
+				{Drop: true, matched: true},
+				{Name: "test.job", Tags: []string{"job_name:my_job"}, matched: true},
+			},
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -499,6 +524,43 @@ dogstatsd_mapper_profiles:
 			},
 			expectedError: "missing prefix for profile",
 		},
+		{
+			name: "Invalid action",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.duration"
+        name: "test.job.duration"
+        action: invalid
+`,
+			expectedError: "invalid action `invalid`",
+		},
+		{
+			name: "Missing tag mapping match",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    tag_mappings:
+      - tags:
+          env: "$1"
+`,
+			expectedError: "tag mapping num 0: match is required",
+		},
+		{
+			name: "Invalid tag mapping regex",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    tag_mappings:
+      - match: "env:(["
+        match_type: regex
+`,
+			expectedError: "cannot compile regex",
+		},
 	}
 
 	for _, scenario := range scenarios {
@@ -510,6 +572,88 @@ dogstatsd_mapper_profiles:
 	}
 }
 
+func TestTagMappings(t *testing.T) {
+	mapper, err := getMapper(`
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    tag_mappings:
+      - match: 'env:(\w+)-(\w+)'
+        match_type: regex
+        tags:
+          env: "$1"
+          region: "$2"
+      - match: "team:*.*"
+        tags:
+          team: "$1"
+      - match: "request_id:*"
+      - match: "debug:*"
+        action: drop
+  - name: other
+    prefix: 'other.'
+    mappings:
+      - match: "other.*"
+        name: "other"
+`)
+	require.NoError(t, err)
+
+	scenarios := []struct {
+		name         string
+		tags         []string
+		expectedTags []string
+		expectedKeep bool
+	}{
+		{"test.foo", []string{"env:prod-eu", "service:web"}, []string{"env:prod", "region:eu", "service:web"}, true},
+		{"test.foo", []string{"service:web", "team:core.agent", "request_id:123"}, []string{"service:web", "team:core"}, true},
+		{"test.foo", []string{"service:web"}, []string{"service:web"}, true},
+		{"test.foo", []string{"service:web", "debug:true"}, nil, false},
+		{"other.foo", []string{"env:prod-eu", "debug:true"}, []string{"env:prod-eu", "debug:true"}, true},
+		{"unknown.foo", []string{"env:prod-eu"}, []string{"env:prod-eu"}, true},
+	}
+
+	// run twice to use the cached results
+	for i := 0; i < 2; i++ {
+		for _, scenario := range scenarios {
+			tags, keep := mapper.MapTags(scenario.name, append([]string{}, scenario.tags...))
+			assert.Equal(t, scenario.expectedKeep, keep, "%s %v", scenario.name, scenario.tags)
+			sort.Strings(tags)
+			sort.Strings(scenario.expectedTags)
+			assert.Equal(t, scenario.expectedTags, tags, "%s %v", scenario.name, scenario.tags)
+		}
+	}
+	assert.Equal(t, 5, mapper.cache.tagCache.Len())
+}
+
+func TestTagMappingsOverlappingProfiles(t *testing.T) {
+	mapper, err := getMapper(`
+dogstatsd_mapper_profiles:
+  - name: all
+    prefix: '*'
+    mappings:
+      - match: "all.*"
+        name: "all"
+  - name: test
+    prefix: 'test.'
+    tag_mappings:
+      - match: "request_id:*"
+  - name: test_foo
+    prefix: 'test.foo.'
+    tag_mappings:
+      - match: "debug:*"
+        action: drop
+`)
+	require.NoError(t, err)
+
+	// the profiles without tag mappings don't hide the following ones
+	tags, keep := mapper.MapTags("test.foo.bar", []string{"service:web", "request_id:123", "debug:true"})
+	assert.True(t, keep)
+	assert.Equal(t, []string{"service:web", "debug:true"}, tags)
+
+	tags, keep = mapper.MapTags("other.foo", []string{"request_id:123"})
+	assert.True(t, keep)
+	assert.Equal(t, []string{"request_id:123"}, tags)
+}
+
 func getMapper(configString string) (*MetricMapper, error) {
 	var profiles []config.MappingProfile
 	config.Datadog.SetConfigType("yaml")
@@ -131,6 +131,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 	}
 
 	sampleRate := 1.0
+	var timestamp int64
 	var tags []string
 	var optionalField []byte
 	for message != nil {
@@ -142,9 +143,20 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 			if err != nil {
 				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd sample rate %q", optionalField)
 			}
+		} else if bytes.HasPrefix(optionalField, timestampFieldPrefix) {
+			timestamp, err = parseMetricSampleTimestamp(optionalField[1:])
+			if err != nil {
+				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd timestamp %q: %v", optionalField, err)
+			}
 		}
 	}
 
+	// a timestamped sample is a point of a pre-aggregated serie, it can't have several values
+	if timestamp != 0 && len(values) > 0 {
+		p.float64List.put(values)
+		return dogstatsdMetricSample{}, fmt.Errorf("dogstatsd timestamps are not supported with multiple values")
+	}
+
 	return dogstatsdMetricSample{
 		name:       p.interner.LoadOrStore(name),
 		value:      value,
@@ -153,6 +165,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 		metricType: metricType,
 		sampleRate: sampleRate,
 		tags:       tags,
+		timestamp:  timestamp,
 	}, nil
 }
 
@@ -31,6 +31,7 @@ var (
 
 	tagsFieldPrefix       = []byte("#")
 	sampleRateFieldPrefix = []byte("@")
+	timestampFieldPrefix  = []byte("T")
 )
 
 type dogstatsdMetricSample struct {
@@ -44,6 +45,8 @@ type dogstatsdMetricSample struct {
 	metricType metricType
 	sampleRate float64
 	tags       []string
+	// timestamp is the unix timestamp sent by the client, 0 if none was sent
+	timestamp int64
 }
 
 // sanity checks a given message against the metric sample format
@@ -52,7 +55,7 @@ func hasMetricSampleFormat(message []byte) bool {
 		return false
 	}
 	separatorCount := bytes.Count(message, fieldSeparator)
-	if separatorCount < 1 || separatorCount > 3 {
+	if separatorCount < 1 || separatorCount > 4 {
 		return false
 	}
 	return true
@@ -92,3 +95,14 @@ func parseMetricSampleMetricType(rawMetricType []byte) (metricType, error) {
 func parseMetricSampleSampleRate(rawSampleRate []byte) (float64, error) {
 	return parseFloat64(rawSampleRate)
 }
+
+func parseMetricSampleTimestamp(rawTimestamp []byte) (int64, error) {
+	timestamp, err := parseInt64(rawTimestamp)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp < 1 {
+		return 0, fmt.Errorf("the timestamp must be positive")
+	}
+	return timestamp, nil
+}
@@ -273,6 +273,25 @@ func TestParseGaugeWithUnicode(t *testing.T) {
 	assert.InEpsilon(t, 1.0, sample.sampleRate, epsilon)
 }
 
+func TestParseGaugeWithTimestamp(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:666|g|@0.5|#sometag|T1657100430"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.InEpsilon(t, 666.0, sample.value, epsilon)
+	assert.Equal(t, gaugeType, sample.metricType)
+	assert.Equal(t, []string{"sometag"}, sample.tags)
+	assert.InEpsilon(t, 0.5, sample.sampleRate, epsilon)
+	assert.Equal(t, int64(1657100430), sample.timestamp)
+
+	// the fields are not ordered
+	sample, err = parseMetricSample([]byte("daemon:666|c|T1657100430|#sometag"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1657100430), sample.timestamp)
+	assert.Equal(t, []string{"sometag"}, sample.tags)
+}
+
 func TestParseMetricError(t *testing.T) {
 	// not enough information
 	_, err := parseMetricSample([]byte("daemon:666"))
@@ -305,4 +324,18 @@ func TestParseMetricError(t *testing.T) {
 	// invalid sample rate
 	_, err = parseMetricSample([]byte("daemon:666|g|@abc"))
 	assert.Error(t, err)
+
+	// invalid timestamps
+	_, err = parseMetricSample([]byte("daemon:666|g|Tabc"))
+	assert.Error(t, err)
+
+	_, err = parseMetricSample([]byte("daemon:666|g|T-1657100430"))
+	assert.Error(t, err)
+
+	_, err = parseMetricSample([]byte("daemon:666|g|T0"))
+	assert.Error(t, err)
+
+	// a timestamp can't be used with multiple values
+	_, err = parseMetricSample([]byte("daemon:666:777|g|T1657100430"))
+	assert.Error(t, err)
 }
@@ -21,6 +21,17 @@ import (
 	"github.com/h2non/filetype"
 )
 
+// Pacing controls the cadence at which Read writes the packets of a capture
+// to the Traffic channel.
+type Pacing struct {
+	// Speed multiplies the original cadence of the capture, 2 replays it twice
+	// as fast. The original cadence is used when Speed is 0.
+	Speed float64
+	// PacketsPerSecond replaces the original cadence with a constant rate of
+	// packets when set.
+	PacketsPerSecond float64
+}
+
 // TrafficCaptureReader allows reading back a traffic capture and its contents
 type TrafficCaptureReader struct {
 	Contents    []byte
@@ -31,6 +42,7 @@ type TrafficCaptureReader struct {
 	fuse        chan struct{}
 	offset      uint32
 	mmap        bool
+	pacing      Pacing
 
 	sync.Mutex
 }
@@ -79,6 +91,21 @@ func NewTrafficCaptureReader(path string, depth int, mmap bool) (*TrafficCapture
 	}, nil
 }
 
+// SetPacing sets the cadence of the next calls to Read.
+func (tc *TrafficCaptureReader) SetPacing(pacing Pacing) error {
+	if pacing.Speed < 0 {
+		return fmt.Errorf("the replay speed can't be negative: %v", pacing.Speed)
+	}
+	if pacing.PacketsPerSecond < 0 {
+		return fmt.Errorf("the replay rate can't be negative: %v", pacing.PacketsPerSecond)
+	}
+
+	tc.Lock()
+	defer tc.Unlock()
+	tc.pacing = pacing
+	return nil
+}
+
 // Read reads the contents of the traffic capture and writes each packet to a channel
 func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	tc.Lock()
@@ -97,9 +124,17 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	} else {
 		tsResolution = time.Nanosecond
 	}
+	pacing := tc.pacing
+	if pacing.Speed == 0 {
+		pacing.Speed = 1
+	}
 	tc.Unlock()
 
-	last := int64(0)
+	// the packets are scheduled relatively to the start of the replay, so that
+	// the time spent writing them doesn't slow the replay down
+	var start time.Time
+	first := int64(0)
+	sent := 0
 
 	// we are all ready to go - let the caller know
 	ready <- struct{}{}
@@ -117,13 +152,22 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 			break
 		}
 
-		if last != 0 {
-			if msg.Timestamp > last {
-				util.Wait(tsResolution * time.Duration(msg.Timestamp-last))
-			}
+		if sent == 0 {
+			start = time.Now()
+			first = msg.Timestamp
+		}
+
+		var offset time.Duration
+		if pacing.PacketsPerSecond > 0 {
+			offset = time.Duration(float64(sent) / pacing.PacketsPerSecond * float64(time.Second))
+		} else if msg.Timestamp > first {
+			offset = time.Duration(float64(tsResolution*time.Duration(msg.Timestamp-first)) / pacing.Speed)
+		}
+		if wait := time.Until(start.Add(offset)); wait > 0 {
+			util.Wait(wait)
 		}
 
-		last = msg.Timestamp
+		sent++
 		tc.Traffic <- msg
 
 		select {
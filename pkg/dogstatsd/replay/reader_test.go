@@ -8,8 +8,10 @@ package replay
 import (
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func readerTest(t *testing.T, path string, mmap bool) {
@@ -91,3 +93,53 @@ func TestSeek(t *testing.T) {
 	assert.Equal(t, cnt*i, total)
 
 }
+
+func pacingTest(t *testing.T, pacing Pacing) (int, time.Duration) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+	defer tc.Close()
+	require.NoError(t, tc.SetPacing(pacing))
+
+	ready := make(chan struct{})
+	go tc.Read(ready)
+	<-ready
+
+	start := time.Now()
+	cnt := 0
+	for {
+		select {
+		case <-tc.Traffic:
+			cnt++
+		case <-tc.Done:
+			// the last packets may still be buffered
+			for len(tc.Traffic) > 0 {
+				<-tc.Traffic
+				cnt++
+			}
+			return cnt, time.Since(start)
+		}
+	}
+}
+
+func TestReadSpeed(t *testing.T) {
+	// the capture spans 13 seconds
+	cnt, elapsed := pacingTest(t, Pacing{Speed: 100})
+	assert.Equal(t, 21, cnt)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(130*time.Millisecond))
+	assert.Less(t, int64(elapsed), int64(5*time.Second))
+}
+
+func TestReadPacketsPerSecond(t *testing.T) {
+	// the first packet is sent right away, the 20 others every 10ms
+	cnt, elapsed := pacingTest(t, Pacing{PacketsPerSecond: 100})
+	assert.Equal(t, 21, cnt)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(200*time.Millisecond))
+	assert.Less(t, int64(elapsed), int64(5*time.Second))
+}
+
+func TestSetPacingErrors(t *testing.T) {
+	tc := &TrafficCaptureReader{}
+	assert.Error(t, tc.SetPacing(Pacing{Speed: -1}))
+	assert.Error(t, tc.SetPacing(Pacing{PacketsPerSecond: -1}))
+	assert.NoError(t, tc.SetPacing(Pacing{Speed: 0.5, PacketsPerSecond: 10}))
+}
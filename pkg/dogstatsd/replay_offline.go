@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+// maxOfflineReplayParseErrors is the number of parse errors kept in the report
+const maxOfflineReplayParseErrors = 20
+
+// OfflineReplayReport is the result of the processing of a traffic capture by
+// the parsing, mapping and filtering of DogStatsD, without sending anything.
+type OfflineReplayReport struct {
+	Packets       int
+	Messages      int
+	MetricSamples int
+	Events        int
+	ServiceChecks int
+	// DroppedMessages counts the metric messages dropped by the mapper, the
+	// blocklist or the tag rules.
+	DroppedMessages int
+	ParseErrors     int
+	// ParseErrorMessages are the first parse errors met.
+	ParseErrorMessages []string
+	// Metrics are the metrics resulting from the processing, by name.
+	Metrics map[string]*OfflineReplayMetric
+}
+
+// OfflineReplayMetric holds the contexts of a metric resulting from an offline replay.
+type OfflineReplayMetric struct {
+	Name    string
+	Type    metrics.MetricType
+	Samples int
+	// Contexts describe the tags, and host when it isn't the default hostname,
+	// of each context of the metric, by context key.
+	Contexts map[ckey.ContextKey]string
+}
+
+// offlineReplay processes the packets of a capture like the DogStatsD server
+// would, and gathers the resulting contexts.
+type offlineReplay struct {
+	server  *Server
+	parser  *parser
+	samples []metrics.MetricSample
+	keyGen  *ckey.KeyGenerator
+	tagsAcc *tagset.HashingTagsAccumulator
+	report  *OfflineReplayReport
+}
+
+func newOfflineReplay() *offlineReplay {
+	s := &Server{
+		sharedFloat64List:  newFloat64ListPool(),
+		cachedTlmOriginIds: make(map[string]cachedTagsOriginMap),
+		disableVerboseLogs: true,
+	}
+	s.loadProcessingConfig(nil)
+
+	return &offlineReplay{
+		server:  s,
+		parser:  newParser(s.sharedFloat64List),
+		keyGen:  ckey.NewKeyGenerator(),
+		tagsAcc: tagset.NewHashingTagsAccumulator(),
+		report:  &OfflineReplayReport{Metrics: make(map[string]*OfflineReplayMetric)},
+	}
+}
+
+// ReplayOffline feeds a traffic capture to the parsing, mapping and filtering
+// of DogStatsD using the current configuration, and reports the resulting
+// contexts. The tags of the origin of the packets are not added, and the
+// samples are not aggregated: the contexts dropped by the cardinality limits
+// of the aggregator are reported, and the histogram aggregates are not.
+func ReplayOffline(reader *replay.TrafficCaptureReader) (*OfflineReplayReport, error) {
+	r := newOfflineReplay()
+
+	reader.Seek(0)
+	for {
+		msg, err := reader.ReadNext()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return r.report, err
+		}
+		r.processPacket(msg.Payload[:msg.PayloadSize])
+	}
+	return r.report, nil
+}
+
+func (r *offlineReplay) processPacket(packet []byte) {
+	r.report.Packets++
+	eol := r.server.eolEnabled(packets.UDS)
+	for {
+		message := nextMessage(&packet, eol)
+		if message == nil {
+			break
+		}
+		if len(message) == 0 {
+			continue
+		}
+		r.report.Messages++
+
+		var err error
+		switch findMessageType(message) {
+		case serviceCheckType:
+			if _, err = r.server.parseServiceCheckMessage(r.parser, message, ""); err == nil {
+				r.report.ServiceChecks++
+			}
+		case eventType:
+			if _, err = r.server.parseEventMessage(r.parser, message, ""); err == nil {
+				r.report.Events++
+			}
+		case metricSampleType:
+			r.samples, err = r.server.parseMetricMessage(r.samples[0:0], r.parser, message, "", false)
+			if err == nil && len(r.samples) == 0 {
+				r.report.DroppedMessages++
+			}
+			for _, sample := range r.samples {
+				r.addSample(sample)
+				if r.server.histToDist && sample.Mtype == metrics.HistogramType {
+					distSample := sample.Copy()
+					distSample.Name = r.server.histToDistPrefix + distSample.Name
+					distSample.Mtype = metrics.DistributionType
+					r.addSample(*distSample)
+				}
+			}
+		}
+
+		if err != nil {
+			r.report.ParseErrors++
+			if len(r.report.ParseErrorMessages) < maxOfflineReplayParseErrors {
+				r.report.ParseErrorMessages = append(r.report.ParseErrorMessages, fmt.Sprintf("%q: %s", message, err))
+			}
+		}
+	}
+}
+
+func (r *offlineReplay) addSample(sample metrics.MetricSample) {
+	r.report.MetricSamples++
+
+	metric, found := r.report.Metrics[sample.Name]
+	if !found {
+		metric = &OfflineReplayMetric{
+			Name:     sample.Name,
+			Type:     sample.Mtype,
+			Contexts: make(map[ckey.ContextKey]string),
+		}
+		r.report.Metrics[sample.Name] = metric
+	}
+	metric.Samples++
+
+	defer r.tagsAcc.Reset()
+	r.tagsAcc.Append(sample.Tags...)
+	key := r.keyGen.Generate(sample.Name, sample.Host, r.tagsAcc)
+	if _, found := metric.Contexts[key]; !found {
+		tags := append([]string(nil), r.tagsAcc.Get()...)
+		sort.Strings(tags)
+		context := strings.Join(tags, ",")
+		if sample.Host != r.server.defaultHostname {
+			context += " (host: " + sample.Host + ")"
+		}
+		metric.Contexts[key] = context
+	}
+}
+
+// Contexts returns the total number of contexts of the report.
+func (r *OfflineReplayReport) Contexts() int {
+	contexts := 0
+	for _, metric := range r.Metrics {
+		contexts += len(metric.Contexts)
+	}
+	return contexts
+}
+
+// Format returns a human readable version of the report, listing the metrics
+// by decreasing cardinality. The contexts of each metric are listed when
+// verbose is set.
+func (r *OfflineReplayReport) Format(verbose bool) string {
+	buf := bytes.NewBuffer(nil)
+
+	fmt.Fprintf(buf, "Packets: %d\nMessages: %d\n", r.Packets, r.Messages)
+	fmt.Fprintf(buf, "Metric samples: %d\nEvents: %d\nService checks: %d\n", r.MetricSamples, r.Events, r.ServiceChecks)
+	fmt.Fprintf(buf, "Dropped metric messages: %d\nParse errors: %d\n", r.DroppedMessages, r.ParseErrors)
+	fmt.Fprintf(buf, "Metrics: %d\nContexts: %d\n", len(r.Metrics), r.Contexts())
+
+	metricsList := make([]*OfflineReplayMetric, 0, len(r.Metrics))
+	for _, metric := range r.Metrics {
+		metricsList = append(metricsList, metric)
+	}
+	sort.Slice(metricsList, func(i, j int) bool {
+		if len(metricsList[i].Contexts) != len(metricsList[j].Contexts) {
+			return len(metricsList[i].Contexts) > len(metricsList[j].Contexts)
+		}
+		return metricsList[i].Name < metricsList[j].Name
+	})
+
+	if len(metricsList) > 0 {
+		header := fmt.Sprintf("%-40s | %-12s | %-10s | %-10s\n", "Metric", "Type", "Samples", "Contexts")
+		buf.WriteString("\n" + header)
+		buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+		for _, metric := range metricsList {
+			fmt.Fprintf(buf, "%-40s | %-12s | %-10d | %-10d\n", metric.Name, metric.Type, metric.Samples, len(metric.Contexts))
+			if !verbose {
+				continue
+			}
+			contexts := make([]string, 0, len(metric.Contexts))
+			for _, tags := range metric.Contexts {
+				contexts = append(contexts, tags)
+			}
+			sort.Strings(contexts)
+			for _, tags := range contexts {
+				fmt.Fprintf(buf, "    %s\n", tags)
+			}
+		}
+	}
+
+	if len(r.ParseErrorMessages) > 0 {
+		buf.WriteString("\nParse errors")
+		if r.ParseErrors > len(r.ParseErrorMessages) {
+			fmt.Fprintf(buf, " (first %d)", len(r.ParseErrorMessages))
+		}
+		buf.WriteString(":\n")
+		for _, msg := range r.ParseErrorMessages {
+			fmt.Fprintf(buf, "  %s\n", msg)
+		}
+	}
+
+	return buf.String()
+}
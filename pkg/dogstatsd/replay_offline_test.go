@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestReplayOfflineCapture(t *testing.T) {
+	reader, err := replay.NewTrafficCaptureReader("replay/resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	report, err := ReplayOffline(reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, 21, report.Packets)
+	assert.Equal(t, 21, report.MetricSamples)
+	assert.Equal(t, 0, report.ParseErrors)
+	require.Len(t, report.Metrics, 1)
+	metric := report.Metrics["jaime.uds.test"]
+	require.NotNil(t, metric)
+	assert.Equal(t, metrics.GaugeType, metric.Type)
+	assert.Equal(t, 21, metric.Samples)
+	assert.Len(t, metric.Contexts, 1)
+}
+
+func TestReplayOfflineProcessing(t *testing.T) {
+	config.Datadog.SetConfigType("yaml")
+	err := config.Datadog.ReadConfig(strings.NewReader(`
+statsd_metric_blocklist:
+  - blocked.metric
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: "test."
+    mappings:
+      - match: "test.job.*.duration"
+        name: "test.job.duration"
+        tags:
+          job: "$1"
+`))
+	require.NoError(t, err)
+	defer config.Datadog.ReadConfig(strings.NewReader(``)) //nolint:errcheck
+
+	r := newOfflineReplay()
+	r.processPacket([]byte("test.job.a.duration:1|h\ntest.job.b.duration:2|h\ntest.job.a.duration:3|h"))
+	r.processPacket([]byte("blocked.metric:1|c\nhost.metric:1|g|#host:other,env:prod\nhost.metric:1|g|#env:prod"))
+	r.processPacket([]byte("_e{5,4}:title|text\n_sc|check|0\ninvalid:abc|g\n_sc|agen.down"))
+
+	report := r.report
+	assert.Equal(t, 3, report.Packets)
+	assert.Equal(t, 10, report.Messages)
+	assert.Equal(t, 5, report.MetricSamples)
+	assert.Equal(t, 1, report.Events)
+	assert.Equal(t, 1, report.ServiceChecks)
+	assert.Equal(t, 1, report.DroppedMessages)
+	assert.Equal(t, 2, report.ParseErrors)
+	assert.Len(t, report.ParseErrorMessages, 2)
+	assert.Equal(t, 4, report.Contexts())
+
+	require.Len(t, report.Metrics, 2)
+	job := report.Metrics["test.job.duration"]
+	require.NotNil(t, job)
+	assert.Equal(t, 3, job.Samples)
+	assert.Len(t, job.Contexts, 2)
+
+	hostMetric := report.Metrics["host.metric"]
+	require.NotNil(t, hostMetric)
+	assert.Len(t, hostMetric.Contexts, 2)
+
+	formatted := report.Format(true)
+	assert.Contains(t, formatted, "Contexts: 4\n")
+	assert.Regexp(t, `test.job.duration +\| Histogram +\| 3 +\| 2 `, formatted)
+	assert.Contains(t, formatted, "    job:a\n    job:b\n")
+	assert.Contains(t, formatted, "    env:prod (host: other)\n")
+	assert.Contains(t, formatted, `"invalid:abc|g"`)
+
+	assert.NotContains(t, report.Format(false), "job:a")
+}
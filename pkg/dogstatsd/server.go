@@ -44,6 +44,8 @@ var (
 	dogstatsdMetricPackets            = expvar.Int{}
 	dogstatsdPacketsLastSec           = expvar.Int{}
 	dogstatsdUnterminatedMetricErrors = expvar.Int{}
+	dogstatsdMetricTagRulesDropped    = expvar.Int{}
+	dogstatsdMetricMapperDropped      = expvar.Int{}
 
 	tlmProcessed = telemetry.NewCounter("dogstatsd", "processed",
 		[]string{"message_type", "state", "origin"}, "Count of service checks/events/metrics processed by dogstatsd")
@@ -69,6 +71,8 @@ func init() {
 	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
 	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
 	dogstatsdExpvars.Set("UnterminatedMetricErrors", &dogstatsdUnterminatedMetricErrors)
+	dogstatsdExpvars.Set("MetricTagRulesDropped", &dogstatsdMetricTagRulesDropped)
+	dogstatsdExpvars.Set("MetricMapperDropped", &dogstatsdMetricMapperDropped)
 }
 
 // used in debug mode to add the origin on the processed metric as a tag
@@ -139,6 +143,7 @@ type Server struct {
 	metricPrefix              string
 	metricPrefixBlacklist     []string
 	metricBlocklist           []string
+	tagRules                  []tagRule
 	defaultHostname           string
 	histToDist                bool
 	histToDistPrefix          string
@@ -174,6 +179,9 @@ type metricStat struct {
 	Count    uint64    `json:"count"`
 	LastSeen time.Time `json:"last_seen"`
 	Tags     string    `json:"tags"`
+	// HistogramOverride describes the aggregates and percentiles of a histogram
+	// when they are overridden for this metric.
+	HistogramOverride string `json:"histogram_override,omitempty"`
 }
 
 type dsdServerDebug struct {
@@ -221,7 +229,7 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 	}
 
 	packetsChannel := make(chan packets.Packets, config.Datadog.GetInt("dogstatsd_queue_size"))
-	tmpListeners := make([]listeners.StatsdListener, 0, 2)
+	tmpListeners := make([]listeners.StatsdListener, 0, 3)
 	capture, err := replay.NewTrafficCapture()
 	if err != nil {
 		return nil, err
@@ -253,6 +261,15 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 		}
 	}
 
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPoolManager, capture)
@@ -264,32 +281,9 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
-	}
-
-	// check configuration for custom namespace
-	metricPrefix := config.Datadog.GetString("statsd_metric_namespace")
-	if metricPrefix != "" && !strings.HasSuffix(metricPrefix, ".") {
-		metricPrefix = metricPrefix + "."
-	}
-
-	metricPrefixBlacklist := config.Datadog.GetStringSlice("statsd_metric_namespace_blacklist")
-	metricBlocklist := config.Datadog.GetStringSlice("statsd_metric_blocklist")
-
-	defaultHostname, err := util.GetHostname(context.TODO())
-	if err != nil {
-		log.Errorf("Dogstatsd: unable to determine default hostname: %s", err.Error())
-	}
-
-	histToDist := config.Datadog.GetBool("histogram_copy_to_distribution")
-	histToDistPrefix := config.Datadog.GetString("histogram_copy_to_distribution_prefix")
-
-	if extraTags == nil {
-		extraTags = config.Datadog.GetStringSlice("dogstatsd_tags")
+		return nil, fmt.Errorf("listening on neither udp, tcp nor socket, please check your configuration")
 	}
 
-	entityIDPrecedenceEnabled := config.Datadog.GetBool("dogstatsd_entity_id_precedence")
-
 	eolTerminationUDP := false
 	eolTerminationUDS := false
 	eolTerminationNamedPipe := false
@@ -308,28 +302,20 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 	}
 
 	s := &Server{
-		Started:                   true,
-		Statistics:                stats,
-		packetsIn:                 packetsChannel,
-		sharedPacketPool:          sharedPacketPool,
-		sharedPacketPoolManager:   sharedPacketPoolManager,
-		sharedFloat64List:         newFloat64ListPool(),
-		demultiplexer:             demultiplexer,
-		listeners:                 tmpListeners,
-		stopChan:                  make(chan bool),
-		health:                    health.RegisterLiveness("dogstatsd-main"),
-		metricPrefix:              metricPrefix,
-		metricPrefixBlacklist:     metricPrefixBlacklist,
-		metricBlocklist:           metricBlocklist,
-		defaultHostname:           defaultHostname,
-		histToDist:                histToDist,
-		histToDistPrefix:          histToDistPrefix,
-		extraTags:                 extraTags,
-		eolTerminationUDP:         eolTerminationUDP,
-		eolTerminationUDS:         eolTerminationUDS,
-		eolTerminationNamedPipe:   eolTerminationNamedPipe,
-		entityIDPrecedenceEnabled: entityIDPrecedenceEnabled,
-		disableVerboseLogs:        config.Datadog.GetBool("dogstatsd_disable_verbose_logs"),
+		Started:                 true,
+		Statistics:              stats,
+		packetsIn:               packetsChannel,
+		sharedPacketPool:        sharedPacketPool,
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		sharedFloat64List:       newFloat64ListPool(),
+		demultiplexer:           demultiplexer,
+		listeners:               tmpListeners,
+		stopChan:                make(chan bool),
+		health:                  health.RegisterLiveness("dogstatsd-main"),
+		eolTerminationUDP:       eolTerminationUDP,
+		eolTerminationUDS:       eolTerminationUDS,
+		eolTerminationNamedPipe: eolTerminationNamedPipe,
+		disableVerboseLogs:      config.Datadog.GetBool("dogstatsd_disable_verbose_logs"),
 		Debug: &dsdServerDebug{
 			Stats: make(map[ckey.ContextKey]metricStat),
 			metricsCounts: metricsCountBuckets{
@@ -344,6 +330,11 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 		cachedTlmOriginIds: make(map[string]cachedTagsOriginMap),
 	}
 
+	// read the configuration of the processing of the metrics
+	// ----------------------
+
+	s.loadProcessingConfig(extraTags)
+
 	// packets forwarding
 	// ----------------------
 
@@ -372,6 +363,38 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 		s.EnableMetricsStats()
 	}
 
+	return s, nil
+}
+
+// loadProcessingConfig reads the configuration of the parsing, mapping and
+// filtering of the metric samples, shared by the server and the offline replay.
+// If extraTags is nil, they will be read from DD_DOGSTATSD_TAGS if set.
+func (s *Server) loadProcessingConfig(extraTags []string) {
+	// check configuration for custom namespace
+	s.metricPrefix = config.Datadog.GetString("statsd_metric_namespace")
+	if s.metricPrefix != "" && !strings.HasSuffix(s.metricPrefix, ".") {
+		s.metricPrefix = s.metricPrefix + "."
+	}
+
+	s.metricPrefixBlacklist = config.Datadog.GetStringSlice("statsd_metric_namespace_blacklist")
+	s.metricBlocklist = config.Datadog.GetStringSlice("statsd_metric_blocklist")
+
+	defaultHostname, err := util.GetHostname(context.TODO())
+	if err != nil {
+		log.Errorf("Dogstatsd: unable to determine default hostname: %s", err.Error())
+	}
+	s.defaultHostname = defaultHostname
+
+	s.histToDist = config.Datadog.GetBool("histogram_copy_to_distribution")
+	s.histToDistPrefix = config.Datadog.GetString("histogram_copy_to_distribution_prefix")
+
+	if extraTags == nil {
+		extraTags = config.Datadog.GetStringSlice("dogstatsd_tags")
+	}
+	s.extraTags = extraTags
+
+	s.entityIDPrecedenceEnabled = config.Datadog.GetBool("dogstatsd_entity_id_precedence")
+
 	// map some metric name
 	// ----------------------
 
@@ -388,7 +411,21 @@ func NewServer(demultiplexer aggregator.Demultiplexer, extraTags []string) (*Ser
 			s.mapper = mapperInstance
 		}
 	}
-	return s, nil
+
+	// filter the samples and their tags
+	// ----------------------
+
+	configTagRules, err := config.GetDogstatsdTagRules()
+	if err != nil {
+		log.Warnf("Could not parse tag rules: %v", err)
+	} else if len(configTagRules) != 0 {
+		tagRules, err := newTagRules(configTagRules)
+		if err != nil {
+			log.Warnf("Could not create tag rules: %v", err)
+		} else {
+			s.tagRules = tagRules
+		}
+	}
 }
 
 func (s *Server) handleMessages() {
@@ -556,6 +593,10 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*packe
 					if debugEnabled {
 						s.storeMetricStats(samples[idx])
 					}
+					if samples[idx].Timestamp > 0 {
+						batcher.appendTimestampedSample(samples[idx])
+						continue
+					}
 					batcher.appendSample(samples[idx])
 					if s.histToDist && samples[idx].Mtype == metrics.HistogramType {
 						distSample := samples[idx].Copy()
@@ -633,13 +674,24 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 
 	if s.mapper != nil {
 		mapResult := s.mapper.Map(sample.name)
+		if mapResult != nil && mapResult.Drop {
+			log.Tracef("Dogstatsd mapper: metric %q dropped", sample.name)
+			dogstatsdMetricMapperDropped.Add(1)
+			return metricSamples, nil
+		}
+		var keep bool
+		if sample.tags, keep = s.mapper.MapTags(sample.name, sample.tags); !keep {
+			log.Tracef("Dogstatsd mapper: metric %q dropped because of its tags", sample.name)
+			dogstatsdMetricMapperDropped.Add(1)
+			return metricSamples, nil
+		}
 		if mapResult != nil {
 			log.Tracef("Dogstatsd mapper: metric mapped from %q to %q with tags %v", sample.name, mapResult.Name, mapResult.Tags)
 			sample.name = mapResult.Name
 			sample.tags = append(sample.tags, mapResult.Tags...)
 		}
 	}
-	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
+	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.tagRules, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
 
 	if len(sample.values) > 0 {
 		s.sharedFloat64List.put(sample.values)
@@ -722,6 +774,9 @@ func (s *Server) storeMetricStats(sample metrics.MetricSample) {
 
 	// store
 	ms := s.Debug.Stats[key]
+	if ms.Count == 0 && sample.Mtype == metrics.HistogramType {
+		ms.HistogramOverride = metrics.DescribeHistogramOverride(sample.Name)
+	}
 	ms.Count++
 	ms.LastSeen = now
 	ms.Name = sample.Name
@@ -852,6 +907,26 @@ func FormatDebugStats(stats []byte) (string, error) {
 		buf.Write([]byte("No metrics processed yet."))
 	}
 
+	// list the histogram overrides once per metric name
+	overrides := make(map[string]string)
+	for _, stats := range dogStats {
+		if stats.HistogramOverride != "" {
+			overrides[stats.Name] = stats.HistogramOverride
+		}
+	}
+	if len(overrides) > 0 {
+		names := make([]string, 0, len(overrides))
+		for name := range overrides {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		buf.Write([]byte("\nHistogram overrides:\n"))
+		for _, name := range names {
+			buf.Write([]byte(fmt.Sprintf("  %-40s | %s\n", name, overrides[name])))
+		}
+	}
+
 	return buf.String(), nil
 }
 
@@ -415,6 +415,48 @@ func TestHistToDist(t *testing.T) {
 	}
 }
 
+func TestTimestampedSamples(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	defaultPort := config.Datadog.GetInt("dogstatsd_port")
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	defer config.Datadog.SetDefault("dogstatsd_port", defaultPort)
+
+	// the aggregator must not flush the series on its own
+	demux := mockDemultiplexerWithFlushInterval(time.Hour)
+	defer demux.Stop(false)
+	s, err := NewServer(demux, nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	url := fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_port"))
+	conn, err := net.Dial("udp", url)
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1|T1657100430\ndaemon:21|c|@0.5|T1657100440\ndaemon:1|h|T1657100450"))
+
+	// the timestamped samples are flushed as-is, without waiting for the end of a time bucket
+	var series metrics.Series
+	require.Eventually(t, func() bool {
+		flushed, _ := demux.Aggregator().GetSeriesAndSketches(time.Now())
+		series = append(series, flushed...)
+		return len(series) >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Len(t, series, 2)
+
+	assert.Equal(t, "daemon", series[0].Name)
+	assert.Equal(t, metrics.APIGaugeType, series[0].MType)
+	assert.Equal(t, []metrics.Point{{Ts: 1657100430, Value: 666}}, series[0].Points)
+	assert.ElementsMatch(t, []string{"sometag1:somevalue1"}, series[0].Tags)
+
+	// the counts are corrected with the sample rate
+	assert.Equal(t, "daemon", series[1].Name)
+	assert.Equal(t, metrics.APICountType, series[1].MType)
+	assert.Equal(t, []metrics.Point{{Ts: 1657100440, Value: 42}}, series[1].Points)
+	assert.EqualValues(t, 10, series[1].Interval)
+}
+
 func TestScanLines(t *testing.T) {
 
 	messages := []string{"foo", "bar", "baz", "quz", "hax", ""}
@@ -666,6 +708,45 @@ func TestDebugStats(t *testing.T) {
 	require.Equal(t, hash4, hash5)
 }
 
+func TestDebugStatsHistogramOverride(t *testing.T) {
+	demux := mockDemultiplexer()
+	s, err := NewServer(demux, nil)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	s.EnableMetricsStats()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("histogram_overrides", []config.HistogramOverride{{Prefix: "api.", Percentiles: []string{"0.999"}}})
+	// the overrides may have been loaded already by the histograms of other tests
+	metrics.ResetHistogramOverrides()
+	defer func() {
+		mockConfig.Set("histogram_overrides", nil)
+		metrics.ResetHistogramOverrides()
+	}()
+
+	s.storeMetricStats(metrics.MetricSample{Name: "api.latency", Mtype: metrics.HistogramType})
+	s.storeMetricStats(metrics.MetricSample{Name: "api.latency", Mtype: metrics.HistogramType, Tags: []string{"a"}})
+	s.storeMetricStats(metrics.MetricSample{Name: "api.count", Mtype: metrics.CounterType})
+	s.storeMetricStats(metrics.MetricSample{Name: "other.latency", Mtype: metrics.HistogramType})
+
+	data, err := s.GetJSONDebugStats()
+	require.NoError(t, err)
+	var stats map[ckey.ContextKey]metricStat
+	require.NoError(t, json.Unmarshal(data, &stats))
+	for _, stat := range stats {
+		if stat.Name == "api.latency" {
+			assert.Contains(t, stat.HistogramOverride, "percentiles: p99.9")
+		} else {
+			assert.Empty(t, stat.HistogramOverride, stat.Name)
+		}
+	}
+
+	formatted, err := FormatDebugStats(data)
+	require.NoError(t, err)
+	assert.Regexp(t, `Histogram overrides:\n  api.latency +\| aggregates: .*; percentiles: p99.9\n$`, formatted)
+}
+
 func TestNoMappingsConfig(t *testing.T) {
 	datadogYaml := ``
 	samples := []metrics.MetricSample{}
@@ -759,6 +840,40 @@ dogstatsd_mapper_profiles:
 			},
 			expectedCacheSize: 1000,
 		},
+		{
+			name: "Tag mappings and drop",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.legacy.*"
+        action: drop
+      - match: "test.job.duration.*"
+        name: "test.job.duration"
+        tags:
+          job_name: "$1"
+    tag_mappings:
+      - match: 'env:(\w+)-(\w+)'
+        match_type: regex
+        tags:
+          env: "$1"
+          region: "$2"
+      - match: "debug:*"
+        action: drop
+`,
+			packets: []string{
+				"test.legacy.foo:666|g|#env:prod-eu",
+				"test.job.duration.my_job:666|g|#env:prod-eu,some:tag",
+				"test.job.duration.my_job:666|g|#debug:true",
+				"test.other:666|g|#env:staging-us",
+			},
+			expectedSamples: []MetricSample{
+				{Name: "test.job.duration", Tags: []string{"job_name:my_job", "env:prod", "region:eu", "some:tag"}, Mtype: metrics.GaugeType, Value: 666.0},
+				{Name: "test.other", Tags: []string{"env:staging", "region:us"}, Mtype: metrics.GaugeType, Value: 666.0},
+			},
+			expectedCacheSize: 1000,
+		},
 		{
 			name: "Cache size",
 			config: `
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Actions of the tag rules
+const (
+	tagRuleDrop      = "drop"
+	tagRuleStripTags = "strip_tags"
+	tagRuleKeepTags  = "keep_tags"
+)
+
+// tagRule is applied to the samples of the metrics starting with metricPrefix
+// and having at least one of the matchTags, or to all of them when there is
+// no matchTags.
+type tagRule struct {
+	metricPrefix string
+	// matchTags are either complete tags (`key:value`), or tag keys matching
+	// any value of the tag.
+	matchTags []string
+	action    string
+	tagKeys   map[string]struct{}
+}
+
+// newTagRules validates the rules from the configuration.
+func newTagRules(configRules []config.TagRule) ([]tagRule, error) {
+	rules := make([]tagRule, 0, len(configRules))
+	for i, configRule := range configRules {
+		rule := tagRule{
+			metricPrefix: configRule.MetricPrefix,
+			matchTags:    configRule.MatchTags,
+			action:       configRule.Action,
+		}
+		switch configRule.Action {
+		case tagRuleDrop:
+			if len(configRule.Tags) > 0 {
+				return nil, fmt.Errorf("rule %d: tags can't be set with the action %s", i, configRule.Action)
+			}
+		case tagRuleStripTags:
+			if len(configRule.Tags) == 0 {
+				return nil, fmt.Errorf("rule %d: the action %s requires tags", i, configRule.Action)
+			}
+			fallthrough
+		case tagRuleKeepTags:
+			rule.tagKeys = make(map[string]struct{}, len(configRule.Tags))
+			for _, key := range configRule.Tags {
+				rule.tagKeys[key] = struct{}{}
+			}
+		default:
+			return nil, fmt.Errorf("rule %d: unknown action %q, valid actions are %s, %s and %s", i, configRule.Action, tagRuleDrop, tagRuleStripTags, tagRuleKeepTags)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// tagKey returns the key of a tag, which is the tag itself when it has no value.
+func tagKey(tag string) string {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+func (r *tagRule) matches(metricName string, tags []string) bool {
+	if !strings.HasPrefix(metricName, r.metricPrefix) {
+		return false
+	}
+	if len(r.matchTags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, matchTag := range r.matchTags {
+			if tag == matchTag || tagKey(tag) == matchTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyTagRules applies the rules in order to the tags of a sample. The tags
+// are filtered in place. It returns false if the sample must be dropped.
+func applyTagRules(rules []tagRule, metricName string, tags []string) ([]string, bool) {
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.matches(metricName, tags) {
+			continue
+		}
+		if rule.action == tagRuleDrop {
+			return nil, false
+		}
+		keep := rule.action == tagRuleKeepTags
+		n := 0
+		for _, tag := range tags {
+			if _, found := rule.tagKeys[tagKey(tag)]; found == keep {
+				tags[n] = tag
+				n++
+			}
+		}
+		tags = tags[:n]
+	}
+	return tags, true
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewTagRulesErrors(t *testing.T) {
+	for _, rule := range []config.TagRule{
+		{Action: "unknown"},
+		{Action: tagRuleDrop, Tags: []string{"env"}},
+		{Action: tagRuleStripTags},
+	} {
+		_, err := newTagRules([]config.TagRule{rule})
+		assert.Error(t, err, "%+v should be invalid", rule)
+	}
+
+	rules, err := newTagRules([]config.TagRule{{Action: tagRuleKeepTags}})
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+}
+
+func TestApplyTagRules(t *testing.T) {
+	rules, err := newTagRules([]config.TagRule{
+		{MetricPrefix: "api.", MatchTags: []string{"env:staging", "canary"}, Action: tagRuleDrop},
+		{Action: tagRuleStripTags, Tags: []string{"request_id"}},
+		{MetricPrefix: "web.", Action: tagRuleKeepTags, Tags: []string{"env", "service"}},
+	})
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name         string
+		tags         []string
+		expectedTags []string
+		expectedKeep bool
+	}{
+		{"api.requests", []string{"env:staging", "service:api"}, nil, false},
+		{"api.requests", []string{"canary:true"}, nil, false},
+		{"api.requests", []string{"canary"}, nil, false},
+		{"api.requests", []string{"env:prod", "request_id:123"}, []string{"env:prod"}, true},
+		{"other.requests", []string{"env:staging", "request_id:123"}, []string{"env:staging"}, true},
+		{"web.requests", []string{"env:prod", "service:web", "request_id:123", "path:/home", "service"}, []string{"env:prod", "service:web", "service"}, true},
+		{"web.requests", []string{}, []string{}, true},
+	} {
+		tags, keep := applyTagRules(rules, test.name, append([]string{}, test.tags...))
+		assert.Equal(t, test.expectedKeep, keep, "%s %v", test.name, test.tags)
+		assert.Equal(t, test.expectedTags, tags, "%s %v", test.name, test.tags)
+	}
+}
+
+func TestEnrichMetricSampleTagRules(t *testing.T) {
+	rules, err := newTagRules([]config.TagRule{
+		{MatchTags: []string{"env:staging"}, Action: tagRuleDrop},
+		{MetricPrefix: "custom.", Action: tagRuleStripTags, Tags: []string{"request_id"}},
+	})
+	require.NoError(t, err)
+
+	parser := newParser(newFloat64ListPool())
+	parsed, err := parser.parseMetricSample([]byte("custom.metric:1:2|h|#request_id:123,host:foo,env:prod"))
+	require.NoError(t, err)
+	samples := enrichMetricSample([]metrics.MetricSample{}, parsed, "", nil, nil, rules, "default", "", true, false)
+	require.Len(t, samples, 2)
+	for _, sample := range samples {
+		assert.Equal(t, "foo", sample.Host)
+		assert.Equal(t, []string{"env:prod"}, sample.Tags)
+	}
+
+	dropped := dogstatsdMetricTagRulesDropped.Value()
+	parsed, err = parser.parseMetricSample([]byte("custom.metric:1|g|#env:staging"))
+	require.NoError(t, err)
+	samples = enrichMetricSample([]metrics.MetricSample{}, parsed, "", nil, nil, rules, "default", "", true, false)
+	assert.Len(t, samples, 0)
+	assert.Equal(t, dropped+1, dogstatsdMetricTagRulesDropped.Value())
+}
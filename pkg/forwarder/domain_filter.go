@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+// DomainFilter returns whether a payload must be sent to a domain. The domain is
+// given as set in `dd_url` or `additional_endpoints`.
+type DomainFilter func(domain string) bool
+
+// DomainFilteringForwarder is implemented by the forwarders able to send the
+// series and sketches payloads to a subset of their domains.
+type DomainFilteringForwarder interface {
+	SubmitV1SeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error
+	SubmitSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error
+	SubmitSketchSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error
+}
+
+// Compile-time checks to ensure that the forwarders implement the DomainFilteringForwarder interface
+var _ DomainFilteringForwarder = &DefaultForwarder{}
+var _ DomainFilteringForwarder = &SyncForwarder{}
+
+func (f *DefaultForwarder) createFilteredTransactions(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, filter DomainFilter) []*transaction.HTTPTransaction {
+	return f.createFilteredHTTPTransactions(endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityNormal, true, filter)
+}
+
+// SubmitV1SeriesToDomains sends timeseries to the v1 endpoint of the domains selected by filter.
+func (f *DefaultForwarder) SubmitV1SeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.createFilteredTransactions(endpoints.V1SeriesEndpoint, payload, true, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSeriesToDomains sends timeseries to the v2 endpoint of the domains selected by filter.
+func (f *DefaultForwarder) SubmitSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.createFilteredTransactions(endpoints.SeriesEndpoint, payload, false, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSketchSeriesToDomains sends sketches to the domains selected by filter.
+func (f *DefaultForwarder) SubmitSketchSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.createFilteredTransactions(endpoints.SketchSeriesEndpoint, payload, false, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitV1SeriesToDomains sends timeseries to the v1 endpoint of the domains selected by filter.
+func (f *SyncForwarder) SubmitV1SeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.defaultForwarder.createFilteredTransactions(endpoints.V1SeriesEndpoint, payload, true, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSeriesToDomains sends timeseries to the v2 endpoint of the domains selected by filter.
+func (f *SyncForwarder) SubmitSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.defaultForwarder.createFilteredTransactions(endpoints.SeriesEndpoint, payload, true, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSketchSeriesToDomains sends sketches to the domains selected by filter.
+func (f *SyncForwarder) SubmitSketchSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	transactions := f.defaultForwarder.createFilteredTransactions(endpoints.SketchSeriesEndpoint, payload, true, extra, filter)
+	return f.sendHTTPTransactions(transactions)
+}
@@ -200,6 +200,10 @@ type DefaultForwarder struct {
 	internalState    uint32     // atomic
 	m                sync.Mutex // To control Start/Stop races
 
+	// configuredDomains are the domains of domainResolvers as set in the configuration,
+	// before the agent version is added to them.
+	configuredDomains map[string]string
+
 	completionHandler transaction.HTTPCompletionHandler
 }
 
@@ -215,6 +219,7 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 			disableAPIKeyChecking: options.DisableAPIKeyChecking,
 			validationInterval:    options.APIKeyValidationInterval,
 		},
+		configuredDomains: map[string]string{},
 		completionHandler: options.CompletionHandler,
 	}
 	var optionalRemovalPolicy *retry.FileRemovalPolicy
@@ -250,8 +255,8 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	domainForwarderSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: true}
 	transactionContainerSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: false}
 
-	for domain, resolver := range options.DomainResolvers {
-		domain, _ := config.AddAgentVersionToDomain(domain, "app")
+	for configuredDomain, resolver := range options.DomainResolvers {
+		domain, _ := config.AddAgentVersionToDomain(configuredDomain, "app")
 		resolver.SetBaseDomain(domain)
 		if resolver.GetAPIKeys() == nil || len(resolver.GetAPIKeys()) == 0 {
 			log.Errorf("No API keys for domain '%s', dropping domain ", domain)
@@ -273,6 +278,7 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 				transactionContainerSort,
 				resolver)
 			f.domainResolvers[domain] = resolver
+			f.configuredDomains[domain] = configuredDomain
 			fwd := newDomainForwarder(
 				domain,
 				transactionContainer,
@@ -396,11 +402,20 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint transaction.Endpoint,
 }
 
 func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority transaction.Priority, storableOnDisk bool) []*transaction.HTTPTransaction {
+	return f.createFilteredHTTPTransactions(endpoint, payloads, apiKeyInQueryString, extra, priority, storableOnDisk, nil)
+}
+
+// createFilteredHTTPTransactions creates the transactions of the domains selected by
+// filter, or of all the domains when filter is nil.
+func (f *DefaultForwarder) createFilteredHTTPTransactions(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority transaction.Priority, storableOnDisk bool, filter DomainFilter) []*transaction.HTTPTransaction {
 	transactions := make([]*transaction.HTTPTransaction, 0, len(payloads)*len(f.domainForwarders))
 	allowArbitraryTags := config.Datadog.GetBool("allow_arbitrary_tags")
 
 	for _, payload := range payloads {
 		for domain, dr := range f.domainResolvers {
+			if filter != nil && !filter(f.configuredDomains[domain]) {
+				continue
+			}
 			for _, apiKey := range dr.GetAPIKeys() {
 				t := transaction.NewHTTPTransaction()
 				t.Domain, _ = dr.Resolve(endpoint)
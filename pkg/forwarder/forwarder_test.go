@@ -204,6 +204,36 @@ func TestCreateHTTPTransactionsWithMultipleDomains(t *testing.T) {
 	assert.Equal(t, txBar[0].Endpoint.Route, "/api/foo?api_key=api-key-3")
 }
 
+func TestCreateFilteredHTTPTransactions(t *testing.T) {
+	forwarder := NewDefaultForwarder(NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
+	p1 := []byte("A payload")
+	payloads := Payloads{&p1}
+	headers := make(http.Header)
+
+	// the filter gets the domains as configured, without the agent version
+	var filtered []string
+	transactions := forwarder.createFilteredTransactions(endpoint, payloads, false, headers, func(domain string) bool {
+		filtered = append(filtered, domain)
+		return domain == "datadog.bar"
+	})
+	assert.ElementsMatch(t, []string{testDomain, "datadog.bar"}, filtered)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, "datadog.bar", transactions[0].Domain)
+	assert.Equal(t, "api-key-3", transactions[0].Headers.Get("DD-Api-Key"))
+
+	transactions = forwarder.createFilteredTransactions(endpoint, payloads, false, headers, func(domain string) bool {
+		return domain != "datadog.bar"
+	})
+	require.Len(t, transactions, 2)
+	for _, tr := range transactions {
+		assert.Equal(t, testVersionDomain, tr.Domain)
+	}
+
+	transactions = forwarder.createFilteredTransactions(endpoint, payloads, false, headers, func(string) bool { return false })
+	assert.Empty(t, transactions)
+}
+
 func TestCreateHTTPTransactionsWithDifferentResolvers(t *testing.T) {
 	resolvers := resolver.NewSingleDomainResolvers(keysWithMultipleDomains)
 	additionalResolver := resolver.NewMultiDomainResolver("datadog.vector", []string{"api-key-4"})
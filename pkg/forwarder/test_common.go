@@ -167,3 +167,18 @@ func (tf *MockedForwarder) SubmitOrchestratorChecks(payload Payloads, extra http
 func (tf *MockedForwarder) SubmitContainerLifecycleEvents(payload Payloads, extra http.Header) error {
 	return tf.Called(payload, extra).Error(0)
 }
+
+// SubmitV1SeriesToDomains updates the internal mock struct
+func (tf *MockedForwarder) SubmitV1SeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	return tf.Called(payload, extra, filter).Error(0)
+}
+
+// SubmitSeriesToDomains updates the internal mock struct
+func (tf *MockedForwarder) SubmitSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	return tf.Called(payload, extra, filter).Error(0)
+}
+
+// SubmitSketchSeriesToDomains updates the internal mock struct
+func (tf *MockedForwarder) SubmitSketchSeriesToDomains(payload Payloads, extra http.Header, filter DomainFilter) error {
+	return tf.Called(payload, extra, filter).Error(0)
+}
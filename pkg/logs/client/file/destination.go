@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultMaxFileSize      = 100 * 1024 * 1024
+	defaultRotationInterval = time.Hour
+	// retentionCheckInterval is the period at which the current file is rotated when it is too
+	// old and the retention limits are enforced, even when no logs are written.
+	retentionCheckInterval = time.Minute
+)
+
+var tlmWrite = telemetry.NewCounter("logs_client_file_destination", "write", []string{"error"}, "Payloads written to archive files")
+
+// Destination writes the JSON-encoded messages of the payloads to local files, one message per line.
+// It is only used when logs are sent over HTTP, the messages being encoded for TCP otherwise.
+// The payloads are written as they are received, the destination never retries.
+type Destination struct {
+	dir    string
+	writer *rollingWriter
+	// lastError is used to only log the first error of a series of failed writes.
+	lastError error
+	ticker    *time.Ticker
+}
+
+// NewDestination returns a new Destination writing to the directory of the endpoint,
+// each pipeline writes to its own sub-directory.
+func NewDestination(endpoint config.Endpoint, pipelineID int) *Destination {
+	archive := endpoint.Archive
+	maxFileSize := archive.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = defaultMaxFileSize
+	}
+	rotationInterval := time.Duration(archive.RotationInterval) * time.Second
+	if rotationInterval <= 0 {
+		rotationInterval = defaultRotationInterval
+	}
+	maxAge := time.Duration(archive.MaxAge) * time.Second
+	dir := archive.PipelineDir(pipelineID)
+	d := &Destination{dir: dir}
+	writer, err := newRollingWriter(dir, maxFileSize, rotationInterval, archive.MaxFiles, maxAge)
+	if err != nil {
+		log.Errorf("Could not create the log archive directory %s, logs won't be archived: %v", dir, err)
+		return d
+	}
+	d.writer = writer
+	return d
+}
+
+// Start starts reading the input channel
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{})
+	d.ticker = time.NewTicker(retentionCheckInterval)
+	go d.run(input, output, stop)
+	return stop
+}
+
+func (d *Destination) run(input chan *message.Payload, output chan *message.Payload, stopChan chan struct{}) {
+	defer d.ticker.Stop()
+	for {
+		select {
+		case payload, isOpen := <-input:
+			if !isOpen {
+				if d.writer != nil {
+					d.handleError(d.writer.close())
+				}
+				stopChan <- struct{}{}
+				return
+			}
+			d.write(payload)
+			output <- payload
+		case <-d.ticker.C:
+			if d.writer != nil {
+				d.handleError(d.writer.tick())
+			}
+		}
+	}
+}
+
+// write writes the messages of the payload and flushes them to the file.
+func (d *Destination) write(payload *message.Payload) {
+	if d.writer == nil {
+		tlmWrite.Inc("non-retryable")
+		return
+	}
+	var err error
+	for _, msg := range payload.Messages {
+		if err = d.writer.writeLine(msg.Content); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = d.writer.flush()
+	}
+	d.handleError(err)
+	if err != nil {
+		// the buffered data is lost, the next write opens a new file
+		d.writer.close() //nolint:errcheck
+		tlmWrite.Inc("non-retryable")
+	} else {
+		tlmWrite.Inc("none")
+	}
+}
+
+func (d *Destination) handleError(err error) {
+	if err != nil && d.lastError == nil {
+		log.Warnf("Could not write logs to the archive directory %s: %v", d.dir, err)
+	} else if err == nil && d.lastError != nil {
+		log.Infof("Writing logs to the archive directory %s again", d.dir)
+	}
+	d.lastError = err
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestDestinationWritesMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := NewDestination(config.Endpoint{Archive: &config.ArchiveConfig{Path: dir}}, 1)
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	stop := dest.Start(input, output, nil)
+
+	input <- &message.Payload{
+		Messages: []*message.Message{
+			{Content: []byte(`{"message":"foo"}`)},
+			{Content: []byte(`{"message":"bar"}`)},
+		},
+		Encoded: []byte("compressed"),
+	}
+	<-output
+	input <- &message.Payload{Messages: []*message.Message{{Content: []byte(`{"message":"baz"}`)}}}
+	<-output
+	close(input)
+	<-stop
+
+	names, err := filepath.Glob(filepath.Join(dir, "pipeline_1", "logs-*.json"))
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+	content, err := ioutil.ReadFile(names[0])
+	require.NoError(t, err)
+	assert.Equal(t, "{\"message\":\"foo\"}\n{\"message\":\"bar\"}\n{\"message\":\"baz\"}\n", string(content))
+}
+
+func TestDestinationDoesNotBlockOnErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the directory can't be created under a file
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+
+	dest := NewDestination(config.Endpoint{Archive: &config.ArchiveConfig{Path: path}}, 0)
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	stop := dest.Start(input, output, nil)
+
+	input <- &message.Payload{Messages: []*message.Message{{Content: []byte("foo")}}}
+	<-output
+	close(input)
+	<-stop
+}
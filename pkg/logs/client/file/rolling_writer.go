@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	archiveFilePrefix    = "logs-"
+	archiveFileExtension = ".json"
+	// archiveTimeLayout is the layout of the date in the file names, the files are sorted
+	// from the oldest to the newest when they are sorted by name.
+	archiveTimeLayout = "20060102T150405.000000000"
+)
+
+// rollingWriter writes to files in a directory, the current file is rotated when it is
+// larger than maxFileSize or older than rotationInterval, and the oldest files are removed
+// to keep at most maxFiles files, none of them older than maxAge.
+type rollingWriter struct {
+	dir              string
+	maxFileSize      int64
+	rotationInterval time.Duration
+	maxFiles         int
+	maxAge           time.Duration
+
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	openedAt time.Time
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// newRollingWriter returns a new writer, the directory is created if it does not exist.
+// A zero maxFiles or maxAge disables the corresponding retention limit.
+func newRollingWriter(dir string, maxFileSize int64, rotationInterval time.Duration, maxFiles int, maxAge time.Duration) (*rollingWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &rollingWriter{
+		dir:              dir,
+		maxFileSize:      maxFileSize,
+		rotationInterval: rotationInterval,
+		maxFiles:         maxFiles,
+		maxAge:           maxAge,
+		now:              time.Now,
+	}, nil
+}
+
+// writeLine writes the line and a line feed to the current file, rotating it first when needed.
+// A line is never split across files.
+func (w *rollingWriter) writeLine(line []byte) error {
+	size := int64(len(line)) + 1
+	if w.file != nil && (w.size+size > w.maxFileSize || w.isExpired()) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.writer.Write(line); err != nil {
+		return err
+	}
+	if err := w.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	w.size += size
+	return nil
+}
+
+// flush writes the buffered data to the current file.
+func (w *rollingWriter) flush() error {
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Flush()
+}
+
+// tick rotates the current file when it is too old and applies the retention limits,
+// it is called periodically so that the limits are enforced when no data is written.
+func (w *rollingWriter) tick() error {
+	if w.file != nil && w.isExpired() {
+		return w.rotate()
+	}
+	return w.removeOldFiles()
+}
+
+// close flushes and closes the current file.
+func (w *rollingWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.writer.Flush()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	w.writer = nil
+	return err
+}
+
+func (w *rollingWriter) isExpired() bool {
+	return w.rotationInterval > 0 && w.now().Sub(w.openedAt) >= w.rotationInterval
+}
+
+// rotate closes the current file, the next write opens a new one.
+func (w *rollingWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	return w.removeOldFiles()
+}
+
+// open creates a new file named after the current date.
+func (w *rollingWriter) open() error {
+	w.openedAt = w.now()
+	var f *os.File
+	var err error
+	// the date is shifted when a file with the same name exists, which can happen with a coarse clock
+	for date := w.openedAt.UTC(); ; date = date.Add(time.Nanosecond) {
+		name := archiveFilePrefix + date.Format(archiveTimeLayout) + archiveFileExtension
+		f, err = os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.writer = bufio.NewWriter(f)
+	w.size = 0
+	// the current file counts towards the maximum number of files
+	return w.removeOldFiles()
+}
+
+// removeOldFiles removes the oldest files exceeding the retention limits, the current file is never removed.
+func (w *rollingWriter) removeOldFiles() error {
+	if w.maxFiles <= 0 && w.maxAge <= 0 {
+		return nil
+	}
+	names, err := w.listFiles()
+	if err != nil {
+		return err
+	}
+	var current string
+	if w.file != nil {
+		current = filepath.Base(w.file.Name())
+	}
+	for i, name := range names {
+		if name == current {
+			continue
+		}
+		tooMany := w.maxFiles > 0 && len(names)-i > w.maxFiles
+		if !tooMany && !w.isTooOld(name) {
+			// the next files are more recent
+			break
+		}
+		if err := os.Remove(filepath.Join(w.dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove archive file %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// isTooOld returns true when the last data of the file has been written before maxAge,
+// the files are closed at most rotationInterval after they are created.
+func (w *rollingWriter) isTooOld(name string) bool {
+	if w.maxAge <= 0 {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(w.dir, name))
+	if err != nil {
+		return false
+	}
+	return w.now().Sub(info.ModTime()) > w.maxAge
+}
+
+// listFiles returns the names of the archive files from the oldest to the newest.
+func (w *rollingWriter) listFiles() ([]string, error) {
+	infos, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		name := info.Name()
+		if !info.IsDir() && strings.HasPrefix(name, archiveFilePrefix) && strings.HasSuffix(name, archiveFileExtension) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestWriter returns a writer with a clock advanced manually.
+func newTestWriter(t *testing.T, maxFileSize int64, rotationInterval time.Duration, maxFiles int, maxAge time.Duration) (*rollingWriter, *time.Time, func()) {
+	dir, err := ioutil.TempDir("", "logs-archive-")
+	require.NoError(t, err)
+	w, err := newRollingWriter(dir, maxFileSize, rotationInterval, maxFiles, maxAge)
+	require.NoError(t, err)
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	return w, &now, func() {
+		w.close()
+		os.RemoveAll(dir)
+	}
+}
+
+func readFiles(t *testing.T, w *rollingWriter) []string {
+	names, err := w.listFiles()
+	require.NoError(t, err)
+	var contents []string
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(w.dir, name))
+		require.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func TestRollingWriterRotatesOnSize(t *testing.T) {
+	w, now, cleanup := newTestWriter(t, 8, time.Hour, 0, 0)
+	defer cleanup()
+
+	for _, line := range []string{"foo", "bar", "baz"} {
+		require.NoError(t, w.writeLine([]byte(line)))
+		*now = now.Add(time.Millisecond)
+	}
+	// a line larger than the maximum size is written to its own file
+	require.NoError(t, w.writeLine([]byte("a long line")))
+	require.NoError(t, w.flush())
+
+	assert.Equal(t, []string{"foo\nbar\n", "baz\n", "a long line\n"}, readFiles(t, w))
+}
+
+func TestRollingWriterRotatesOnInterval(t *testing.T) {
+	w, now, cleanup := newTestWriter(t, 1024, time.Hour, 0, 0)
+	defer cleanup()
+
+	require.NoError(t, w.writeLine([]byte("foo")))
+	*now = now.Add(30 * time.Minute)
+	require.NoError(t, w.writeLine([]byte("bar")))
+	*now = now.Add(30 * time.Minute)
+	require.NoError(t, w.tick())
+	*now = now.Add(time.Minute)
+	require.NoError(t, w.writeLine([]byte("baz")))
+	require.NoError(t, w.flush())
+
+	assert.Equal(t, []string{"foo\nbar\n", "baz\n"}, readFiles(t, w))
+}
+
+func TestRollingWriterRemovesOldestFiles(t *testing.T) {
+	w, now, cleanup := newTestWriter(t, 4, time.Hour, 2, 0)
+	defer cleanup()
+
+	for _, line := range []string{"foo", "bar", "baz", "qux"} {
+		require.NoError(t, w.writeLine([]byte(line)))
+		*now = now.Add(time.Millisecond)
+	}
+	require.NoError(t, w.flush())
+
+	assert.Equal(t, []string{"baz\n", "qux\n"}, readFiles(t, w))
+}
+
+func TestRollingWriterRemovesExpiredFiles(t *testing.T) {
+	w, now, cleanup := newTestWriter(t, 4, time.Hour, 0, 24*time.Hour)
+	defer cleanup()
+
+	require.NoError(t, w.writeLine([]byte("foo")))
+	require.NoError(t, w.writeLine([]byte("bar")))
+	require.NoError(t, w.flush())
+	names, err := w.listFiles()
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+	// the first file has been written 2 days ago
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(w.dir, names[0]), old, old))
+	*now = time.Now()
+
+	require.NoError(t, w.tick())
+	// the current file is never removed
+	assert.Equal(t, []string{"bar\n"}, readFiles(t, w))
+}
@@ -0,0 +1,200 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/backoff"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// contentEncodingHeader is the record header holding the encoding of the payload, if any.
+const contentEncodingHeader = "content-encoding"
+
+var tlmSend = telemetry.NewCounter("logs_client_kafka_destination", "send", []string{"endpoint_host", "error"}, "Payloads sent")
+
+// Destination writes each payload as a record to a Kafka topic.
+type Destination struct {
+	host                string
+	topic               string
+	producer            *producer
+	destinationsContext *client.DestinationsContext
+
+	// Retry
+	backoff        backoff.Policy
+	nbErrors       int
+	blockedUntil   time.Time
+	retryLock      sync.Mutex
+	shouldRetry    bool
+	lastRetryError error
+}
+
+// NewDestination returns a new Destination writing to the topic of the endpoint,
+// the host and the port of the endpoint are the address of the bootstrap broker.
+func NewDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, shouldRetry bool) *Destination {
+	return newDestination(endpoint, destinationsContext, time.Second*10, shouldRetry)
+}
+
+func newDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, timeout time.Duration, shouldRetry bool) *Destination {
+	acks := int16(1)
+	if endpoint.Kafka.RequiredAcks != nil {
+		acks = int16(*endpoint.Kafka.RequiredAcks)
+	}
+	bootstrap := net.JoinHostPort(endpoint.Host, strconv.Itoa(endpoint.Port))
+
+	policy := backoff.NewPolicy(
+		endpoint.BackoffFactor,
+		endpoint.BackoffBase,
+		endpoint.BackoffMax,
+		endpoint.RecoveryInterval,
+		endpoint.RecoveryReset,
+	)
+
+	return &Destination{
+		host:                endpoint.Host,
+		topic:               endpoint.Kafka.Topic,
+		producer:            newProducer(bootstrap, endpoint.Kafka.Topic, acks, timeout, endpoint.Kafka.UseTLS),
+		destinationsContext: destinationsContext,
+		backoff:             policy,
+		shouldRetry:         shouldRetry,
+	}
+}
+
+func errorToTag(err error) string {
+	if err == nil {
+		return "none"
+	} else if _, ok := err.(*client.RetryableError); ok {
+		return "retryable"
+	} else {
+		return "non-retryable"
+	}
+}
+
+// Start starts reading the input channel
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{})
+	go d.run(input, output, stop, isRetrying)
+	return stop
+}
+
+// run writes the payloads one at a time to preserve their order within a partition.
+func (d *Destination) run(input chan *message.Payload, output chan *message.Payload, stopChan chan struct{}, isRetrying chan bool) {
+	for payload := range input {
+		d.sendAndRetry(payload, output, isRetrying)
+	}
+	d.producer.close()
+	d.updateRetryState(nil, isRetrying)
+	stopChan <- struct{}{}
+}
+
+func (d *Destination) sendAndRetry(payload *message.Payload, output chan *message.Payload, isRetrying chan bool) {
+	for {
+		d.retryLock.Lock()
+		d.blockedUntil = time.Now().Add(d.backoff.GetBackoffDuration(d.nbErrors))
+		if d.blockedUntil.After(time.Now()) {
+			log.Debugf("%s: sleeping until %v before retrying", d.host, d.blockedUntil)
+			d.waitForBackoff()
+		}
+		d.retryLock.Unlock()
+
+		err := d.unconditionalSend(payload)
+
+		if err != nil {
+			metrics.DestinationErrors.Add(1)
+			metrics.TlmDestinationErrors.Inc()
+			log.Warnf("Could not write payload to Kafka topic %s: %v", d.topic, err)
+		}
+
+		if err == context.Canceled {
+			d.updateRetryState(nil, isRetrying)
+			return
+		}
+
+		if d.shouldRetry {
+			d.updateRetryState(err, isRetrying)
+			if d.lastRetryError != nil {
+				continue
+			}
+		}
+
+		// the payload is dropped on a non-retriable error, it still goes to the output
+		// to not be sent again but it is not counted as sent.
+		if err == nil {
+			metrics.LogsSent.Add(int64(len(payload.Messages)))
+			metrics.TlmLogsSent.Add(float64(len(payload.Messages)))
+		}
+		output <- payload
+		return
+	}
+}
+
+func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
+	defer func() {
+		tlmSend.Inc(d.host, errorToTag(err))
+	}()
+
+	ctx := d.destinationsContext.Context()
+
+	r := record{
+		timestamp: time.Now(),
+		value:     payload.Encoded,
+	}
+	if payload.Encoding != "" {
+		r.headers = []recordHeader{{key: contentEncodingHeader, value: []byte(payload.Encoding)}}
+	}
+
+	err = d.producer.produce(ctx, r)
+	if err == nil {
+		metrics.BytesSent.Add(int64(payload.UnencodedSize))
+		metrics.EncodedBytesSent.Add(int64(len(payload.Encoded)))
+		return nil
+	}
+	if ctx.Err() == context.Canceled {
+		return ctx.Err()
+	}
+	if perr, ok := err.(*protocolError); ok && !isRetriable(perr.code) {
+		// the record is rejected whatever the state of the cluster,
+		// the topic or the size limits are likely to be misconfigured.
+		return err
+	}
+	// most likely a network error or a leader election in progress, the callee should retry.
+	return client.NewRetryableError(err)
+}
+
+func (d *Destination) updateRetryState(err error, isRetrying chan bool) {
+	d.retryLock.Lock()
+	defer d.retryLock.Unlock()
+
+	if _, ok := err.(*client.RetryableError); ok {
+		d.nbErrors = d.backoff.IncError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError == nil {
+			isRetrying <- true
+		}
+		d.lastRetryError = err
+	} else {
+		d.nbErrors = d.backoff.DecError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError != nil {
+			isRetrying <- false
+		}
+		d.lastRetryError = nil
+	}
+}
+
+func (d *Destination) waitForBackoff() {
+	ctx, cancel := context.WithDeadline(d.destinationsContext.Context(), d.blockedUntil)
+	defer cancel()
+	<-ctx.Done()
+}
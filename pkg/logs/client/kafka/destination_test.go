@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+func newTestDestination(t *testing.T, partitions int32, shouldRetry bool) (*TestBroker, *Destination, *client.DestinationsContext) {
+	broker, err := NewTestBroker(partitions)
+	require.NoError(t, err)
+	destCtx := client.NewDestinationsContext()
+	destCtx.Start()
+	return broker, newDestination(broker.Endpoint("logs"), destCtx, time.Second, shouldRetry), destCtx
+}
+
+func TestDestinationWritesRecords(t *testing.T) {
+	broker, dest, destCtx := newTestDestination(t, 2, true)
+	defer broker.Stop()
+	defer destCtx.Stop()
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	stop := dest.Start(input, output, nil)
+
+	input <- &message.Payload{Encoded: []byte("foo")}
+	<-output
+	input <- &message.Payload{Encoded: []byte("bar"), Encoding: "gzip"}
+	<-output
+	close(input)
+	<-stop
+
+	records := broker.Records("logs")
+	require.Len(t, records, 2)
+	assert.Equal(t, []byte("foo"), records[0].Value)
+	assert.Empty(t, records[0].Headers)
+	assert.Equal(t, []byte("bar"), records[1].Value)
+	assert.Equal(t, "gzip", records[1].Headers[contentEncodingHeader])
+	// the records are spread over the partitions.
+	assert.NotEqual(t, records[0].Partition, records[1].Partition)
+}
+
+func TestDestinationRetriesOnRetriableError(t *testing.T) {
+	broker, dest, destCtx := newTestDestination(t, 1, true)
+	defer broker.Stop()
+	defer destCtx.Stop()
+	broker.SetErrorCode(errUnknownTopicOrPartition)
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	isRetrying := make(chan bool, 1)
+	dest.Start(input, output, isRetrying)
+
+	input <- &message.Payload{Encoded: []byte("foo")}
+	assert.True(t, <-isRetrying)
+
+	broker.SetErrorCode(errNone)
+	<-output
+	assert.False(t, <-isRetrying)
+	assert.Len(t, broker.Records("logs"), 1)
+}
+
+func TestDestinationDropsOnNonRetriableError(t *testing.T) {
+	broker, dest, destCtx := newTestDestination(t, 1, true)
+	defer broker.Stop()
+	defer destCtx.Stop()
+	broker.SetErrorCode(errMessageTooLarge)
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	dest.Start(input, output, nil)
+
+	sent := metrics.LogsSent.Value()
+	input <- &message.Payload{Encoded: []byte("foo"), Messages: []*message.Message{{}}}
+	<-output
+	assert.Empty(t, broker.Records("logs"))
+	assert.Equal(t, sent, metrics.LogsSent.Value())
+}
+
+func TestDestinationWithoutAcks(t *testing.T) {
+	broker, err := NewTestBroker(1)
+	require.NoError(t, err)
+	defer broker.Stop()
+	destCtx := client.NewDestinationsContext()
+	destCtx.Start()
+	defer destCtx.Stop()
+
+	endpoint := broker.Endpoint("logs")
+	acks := 0
+	endpoint.Kafka.RequiredAcks = &acks
+	dest := newDestination(endpoint, destCtx, time.Second, true)
+	assert.Equal(t, int16(0), dest.producer.acks)
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	stop := dest.Start(input, output, nil)
+
+	input <- &message.Payload{Encoded: []byte("foo")}
+	<-output
+	close(input)
+	<-stop
+
+	assert.Eventually(t, func() bool { return len(broker.Records("logs")) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestDestinationReconnectsAfterBrokerFailure(t *testing.T) {
+	broker, dest, destCtx := newTestDestination(t, 1, true)
+	defer broker.Stop()
+	defer destCtx.Stop()
+
+	r := record{timestamp: time.Now(), value: []byte("foo")}
+	require.NoError(t, dest.producer.produce(destCtx.Context(), r))
+	// the connection is closed by the broker.
+	broker.mu.Lock()
+	for conn := range broker.conns {
+		conn.Close()
+	}
+	broker.mu.Unlock()
+
+	assert.Error(t, dest.producer.produce(destCtx.Context(), r))
+	assert.NoError(t, dest.producer.produce(destCtx.Context(), r))
+	assert.Len(t, broker.Records("logs"), 2)
+}
+
+func TestDestinationUnavailableBroker(t *testing.T) {
+	broker, dest, destCtx := newTestDestination(t, 1, false)
+	defer destCtx.Stop()
+	broker.Stop()
+
+	err := dest.unconditionalSend(&message.Payload{Encoded: []byte("foo")})
+	assert.IsType(t, &client.RetryableError{}, err)
+}
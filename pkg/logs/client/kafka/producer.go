@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const clientID = "datadog-agent"
+
+// producer writes records to the partitions of a topic, in turn.
+// The partition leaders are discovered from the bootstrap broker and
+// refreshed after each failure. A producer is not thread safe.
+type producer struct {
+	bootstrap string
+	topic     string
+	acks      int16
+	timeout   time.Duration
+	useTLS    bool
+
+	correlationID int32
+	conns         map[string]net.Conn
+	// leaders holds the address of the leader of each available partition.
+	leaders    map[int32]string
+	partitions []int32
+	next       int
+}
+
+func newProducer(bootstrap string, topic string, acks int16, timeout time.Duration, useTLS bool) *producer {
+	return &producer{
+		bootstrap: bootstrap,
+		topic:     topic,
+		acks:      acks,
+		timeout:   timeout,
+		useTLS:    useTLS,
+		conns:     make(map[string]net.Conn),
+	}
+}
+
+// produce writes a record to the next partition.
+// Errors are protocolError when the broker rejected the record.
+func (p *producer) produce(ctx context.Context, r record) error {
+	if len(p.partitions) == 0 {
+		if err := p.refreshMetadata(ctx); err != nil {
+			return err
+		}
+	}
+	partition := p.partitions[p.next%len(p.partitions)]
+	p.next++
+
+	request := produceRequest{
+		acks:      p.acks,
+		timeout:   p.timeout,
+		topic:     p.topic,
+		partition: partition,
+		records:   encodeRecordBatch([]record{r}),
+	}
+	addr := p.leaders[partition]
+	if p.acks == 0 {
+		// the broker does not respond when no acknowledgement is required.
+		return p.send(ctx, addr, apiKeyProduce, produceVersion, request.encode())
+	}
+	body, err := p.roundTrip(ctx, addr, apiKeyProduce, produceVersion, request.encode())
+	if err != nil {
+		return err
+	}
+	var response produceResponse
+	if err := response.decode(body); err != nil {
+		p.reset()
+		return err
+	}
+	if response.errorCode != errNone {
+		// the leader may have changed.
+		p.resetMetadata()
+		return &protocolError{code: response.errorCode}
+	}
+	return nil
+}
+
+// refreshMetadata fetches the leaders of the partitions of the topic from the bootstrap broker.
+func (p *producer) refreshMetadata(ctx context.Context) error {
+	request := metadataRequest{topics: []string{p.topic}}
+	body, err := p.roundTrip(ctx, p.bootstrap, apiKeyMetadata, metadataVersion, request.encode())
+	if err != nil {
+		return err
+	}
+	var response metadataResponse
+	if err := response.decode(body); err != nil {
+		p.reset()
+		return err
+	}
+	brokers := make(map[int32]string)
+	for _, b := range response.brokers {
+		brokers[b.nodeID] = net.JoinHostPort(b.host, strconv.Itoa(int(b.port)))
+	}
+	leaders := make(map[int32]string)
+	var partitions []int32
+	for _, t := range response.topics {
+		if t.name != p.topic {
+			continue
+		}
+		if t.errorCode != errNone {
+			return &protocolError{code: t.errorCode}
+		}
+		for _, partition := range t.partitions {
+			if addr, found := brokers[partition.leader]; found && partition.errorCode == errNone {
+				leaders[partition.id] = addr
+				partitions = append(partitions, partition.id)
+			}
+		}
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("no leader available for the partitions of topic %s", p.topic)
+	}
+	p.leaders = leaders
+	p.partitions = partitions
+	return nil
+}
+
+// roundTrip sends a request to a broker and returns the body of its response.
+func (p *producer) roundTrip(ctx context.Context, addr string, apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	if err := p.send(ctx, addr, apiKey, apiVersion, body); err != nil {
+		return nil, err
+	}
+	conn := p.conns[addr]
+	correlationID, response, err := readResponse(conn)
+	if err != nil {
+		p.reset()
+		return nil, err
+	}
+	if correlationID != p.correlationID {
+		p.reset()
+		return nil, fmt.Errorf("unexpected correlation ID %d from %s, expected %d", correlationID, addr, p.correlationID)
+	}
+	return response, nil
+}
+
+// send writes a request to a broker, connecting to it if needed.
+func (p *producer) send(ctx context.Context, addr string, apiKey, apiVersion int16, body []byte) error {
+	conn, err := p.connect(ctx, addr)
+	if err != nil {
+		// the broker may not be the leader anymore.
+		p.resetMetadata()
+		return err
+	}
+	deadline := time.Now().Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		p.reset()
+		return err
+	}
+	p.correlationID++
+	if err := writeRequest(conn, apiKey, apiVersion, p.correlationID, clientID, body); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+func (p *producer) connect(ctx context.Context, addr string) (net.Conn, error) {
+	if conn, exists := p.conns[addr]; exists {
+		return conn, nil
+	}
+	dialer := &net.Dialer{Timeout: p.timeout}
+	var conn net.Conn
+	var err error
+	if p.useTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// resetMetadata forces the partition leaders to be fetched again on the next record.
+func (p *producer) resetMetadata() {
+	p.leaders = nil
+	p.partitions = nil
+}
+
+// reset closes all the connections after a network or a protocol error,
+// the state of the connections is unknown so they can't be reused.
+func (p *producer) reset() {
+	p.close()
+	p.resetMetadata()
+}
+
+// close closes all the connections.
+func (p *producer) close() {
+	for addr, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+}
+
+// protocolError is an error returned by a broker.
+type protocolError struct {
+	code int16
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("kafka broker returned error code %d", e.code)
+}
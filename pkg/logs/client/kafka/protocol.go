@@ -0,0 +1,578 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// This file implements the subset of the Kafka protocol needed to produce records:
+// the Metadata (v1) and Produce (v3) requests, and the v2 record batch format.
+// Those are the oldest versions supporting record headers, available since Kafka 0.11.
+// See https://kafka.apache.org/protocol for the description of the messages.
+
+// API keys and versions of the requests.
+const (
+	apiKeyProduce   int16 = 0
+	apiKeyMetadata  int16 = 3
+	produceVersion  int16 = 3
+	metadataVersion int16 = 1
+)
+
+// Kafka error codes the destination handles specifically.
+const (
+	errNone                     int16 = 0
+	errUnknownTopicOrPartition  int16 = 3
+	errMessageTooLarge          int16 = 10
+	errInvalidTopic             int16 = 17
+	errRecordListTooLarge       int16 = 18
+	errTopicAuthorizationFailed int16 = 29
+	errInvalidRecord            int16 = 87
+)
+
+// maxResponseSize is the maximum size of a response, a larger size is most likely a protocol error.
+const maxResponseSize = 64 * 1024 * 1024
+
+const recordBatchMagic = 2
+
+var (
+	errShortBuffer = errors.New("kafka: not enough data to decode the message")
+	castagnoli     = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// isRetriable returns true if a produce request that failed with the error code can succeed later.
+func isRetriable(code int16) bool {
+	switch code {
+	case errMessageTooLarge, errInvalidTopic, errRecordListTooLarge, errTopicAuthorizationFailed, errInvalidRecord:
+		return false
+	}
+	return true
+}
+
+// encoder writes the primitive types of the protocol in a buffer.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) int8(v int8) {
+	e.buf.WriteByte(byte(v))
+}
+
+func (e *encoder) int16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	e.buf.Write(b[:n])
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.int8(1)
+	} else {
+		e.int8(0)
+	}
+}
+
+func (e *encoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.buf.WriteString(v)
+}
+
+// nullableString writes a null string when v is empty.
+func (e *encoder) nullableString(v string) {
+	if v == "" {
+		e.int16(-1)
+		return
+	}
+	e.string(v)
+}
+
+func (e *encoder) bytes(v []byte) {
+	if v == nil {
+		e.int32(-1)
+		return
+	}
+	e.int32(int32(len(v)))
+	e.buf.Write(v)
+}
+
+func (e *encoder) varbytes(v []byte) {
+	if v == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(v)))
+	e.buf.Write(v)
+}
+
+func (e *encoder) arrayLen(n int) {
+	e.int32(int32(n))
+}
+
+// decoder reads the primitive types of the protocol from a buffer,
+// the first error is kept and returned by err, next reads return zero values.
+type decoder struct {
+	b   []byte
+	off int
+	e   error
+}
+
+func (d *decoder) err() error {
+	return d.e
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.e != nil {
+		return nil
+	}
+	if n < 0 || d.off+n > len(d.b) {
+		d.e = errShortBuffer
+		return nil
+	}
+	b := d.b[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *decoder) varint() int64 {
+	if d.e != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b[d.off:])
+	if n <= 0 {
+		d.e = errShortBuffer
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+func (d *decoder) bool() bool {
+	return d.int8() != 0
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+func (d *decoder) varbytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+func (d *decoder) arrayLen() int {
+	n := int(d.int32())
+	// each element takes at least one byte, this prevents huge allocations on corrupted data.
+	if n > len(d.b)-d.off {
+		d.e = errShortBuffer
+		return 0
+	}
+	return n
+}
+
+// writeRequest writes a request with its header, prefixed by its size.
+func writeRequest(w io.Writer, apiKey, apiVersion int16, correlationID int32, clientID string, body []byte) error {
+	var e encoder
+	e.int32(int32(2 + 2 + 4 + 2 + len(clientID) + len(body)))
+	e.int16(apiKey)
+	e.int16(apiVersion)
+	e.int32(correlationID)
+	e.nullableString(clientID)
+	e.buf.Write(body)
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// readMessage reads a message prefixed by its size.
+func readMessage(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxResponseSize {
+		return nil, fmt.Errorf("kafka: message of %d bytes is too large", n)
+	}
+	message := make([]byte, n)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// readResponse reads a response and returns its correlation ID and its body.
+func readResponse(r io.Reader) (int32, []byte, error) {
+	message, err := readMessage(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	d := decoder{b: message}
+	correlationID := d.int32()
+	if d.err() != nil {
+		return 0, nil, d.err()
+	}
+	return correlationID, message[d.off:], nil
+}
+
+// recordHeader is a key/value pair attached to a record.
+type recordHeader struct {
+	key   string
+	value []byte
+}
+
+// record is a single message of a topic partition.
+type record struct {
+	timestamp time.Time
+	key       []byte
+	value     []byte
+	headers   []recordHeader
+}
+
+// encodeRecordBatch encodes uncompressed records in a v2 record batch.
+func encodeRecordBatch(records []record) []byte {
+	var firstTimestamp, maxTimestamp int64
+	for i, r := range records {
+		ts := r.timestamp.UnixNano() / int64(time.Millisecond)
+		if i == 0 {
+			firstTimestamp = ts
+		}
+		if ts > maxTimestamp {
+			maxTimestamp = ts
+		}
+	}
+
+	// the part of the batch covered by the CRC, starting with the attributes.
+	var body encoder
+	body.int16(0) // attributes: no compression, create time
+	body.int32(int32(len(records) - 1))
+	body.int64(firstTimestamp)
+	body.int64(maxTimestamp)
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.arrayLen(len(records))
+	for i, r := range records {
+		var rec encoder
+		rec.int8(0) // attributes
+		rec.varint(r.timestamp.UnixNano()/int64(time.Millisecond) - firstTimestamp)
+		rec.varint(int64(i))
+		rec.varbytes(r.key)
+		rec.varbytes(r.value)
+		rec.varint(int64(len(r.headers)))
+		for _, h := range r.headers {
+			rec.varbytes([]byte(h.key))
+			rec.varbytes(h.value)
+		}
+		body.varint(int64(rec.buf.Len()))
+		body.buf.Write(rec.buf.Bytes())
+	}
+
+	var e encoder
+	e.int64(0) // base offset, set by the broker
+	e.int32(int32(4 + 1 + 4 + body.buf.Len()))
+	e.int32(-1) // partition leader epoch
+	e.int8(recordBatchMagic)
+	e.int32(int32(crc32.Checksum(body.buf.Bytes(), castagnoli)))
+	e.buf.Write(body.buf.Bytes())
+	return e.buf.Bytes()
+}
+
+// decodeRecordBatches decodes the uncompressed v2 record batches of a produce request.
+func decodeRecordBatches(b []byte) ([]record, error) {
+	var records []record
+	d := decoder{b: b}
+	for d.off < len(d.b) && d.err() == nil {
+		d.int64() // base offset
+		length := d.int32()
+		batch := decoder{b: d.next(int(length))}
+		if d.err() != nil {
+			return nil, d.err()
+		}
+		batch.int32() // partition leader epoch
+		if magic := batch.int8(); magic != recordBatchMagic {
+			return nil, fmt.Errorf("kafka: unsupported record batch version %d", magic)
+		}
+		crc := uint32(batch.int32())
+		if batch.err() == nil && crc32.Checksum(batch.b[batch.off:], castagnoli) != crc {
+			return nil, errors.New("kafka: invalid record batch checksum")
+		}
+		if attributes := batch.int16(); attributes&0x7 != 0 {
+			return nil, errors.New("kafka: compressed record batches are not supported")
+		}
+		batch.int32() // last offset delta
+		firstTimestamp := batch.int64()
+		batch.int64() // max timestamp
+		batch.int64() // producer ID
+		batch.int16() // producer epoch
+		batch.int32() // base sequence
+		count := batch.arrayLen()
+		for i := 0; i < count && batch.err() == nil; i++ {
+			rec := decoder{b: batch.next(int(batch.varint()))}
+			rec.int8() // attributes
+			r := record{timestamp: time.Unix(0, (firstTimestamp+rec.varint())*int64(time.Millisecond))}
+			rec.varint() // offset delta
+			r.key = rec.varbytes()
+			r.value = rec.varbytes()
+			headers := int(rec.varint())
+			for j := 0; j < headers && rec.err() == nil; j++ {
+				r.headers = append(r.headers, recordHeader{key: string(rec.varbytes()), value: rec.varbytes()})
+			}
+			if rec.err() != nil {
+				return nil, rec.err()
+			}
+			records = append(records, r)
+		}
+		if batch.err() != nil {
+			return nil, batch.err()
+		}
+	}
+	return records, d.err()
+}
+
+// metadataRequest requests the brokers and the partitions of the topics.
+type metadataRequest struct {
+	topics []string
+}
+
+func (r *metadataRequest) encode() []byte {
+	var e encoder
+	e.arrayLen(len(r.topics))
+	for _, topic := range r.topics {
+		e.string(topic)
+	}
+	return e.buf.Bytes()
+}
+
+func (r *metadataRequest) decode(b []byte) error {
+	d := decoder{b: b}
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		r.topics = append(r.topics, d.string())
+	}
+	return d.err()
+}
+
+type brokerMetadata struct {
+	nodeID int32
+	host   string
+	port   int32
+}
+
+type partitionMetadata struct {
+	errorCode int16
+	id        int32
+	leader    int32
+}
+
+type topicMetadata struct {
+	errorCode  int16
+	name       string
+	partitions []partitionMetadata
+}
+
+type metadataResponse struct {
+	brokers []brokerMetadata
+	topics  []topicMetadata
+}
+
+func (r *metadataResponse) encode() []byte {
+	var e encoder
+	e.arrayLen(len(r.brokers))
+	for _, b := range r.brokers {
+		e.int32(b.nodeID)
+		e.string(b.host)
+		e.int32(b.port)
+		e.nullableString("") // rack
+	}
+	e.int32(-1) // controller ID
+	e.arrayLen(len(r.topics))
+	for _, t := range r.topics {
+		e.int16(t.errorCode)
+		e.string(t.name)
+		e.bool(false) // is internal
+		e.arrayLen(len(t.partitions))
+		for _, p := range t.partitions {
+			e.int16(p.errorCode)
+			e.int32(p.id)
+			e.int32(p.leader)
+			e.arrayLen(1) // replicas
+			e.int32(p.leader)
+			e.arrayLen(1) // in-sync replicas
+			e.int32(p.leader)
+		}
+	}
+	return e.buf.Bytes()
+}
+
+func (r *metadataResponse) decode(b []byte) error {
+	d := decoder{b: b}
+	n := d.arrayLen()
+	for i := 0; i < n; i++ {
+		r.brokers = append(r.brokers, brokerMetadata{nodeID: d.int32(), host: d.string(), port: d.int32()})
+		d.string() // rack
+	}
+	d.int32() // controller ID
+	n = d.arrayLen()
+	for i := 0; i < n; i++ {
+		t := topicMetadata{errorCode: d.int16(), name: d.string()}
+		d.bool() // is internal
+		partitions := d.arrayLen()
+		for j := 0; j < partitions; j++ {
+			t.partitions = append(t.partitions, partitionMetadata{errorCode: d.int16(), id: d.int32(), leader: d.int32()})
+			replicas := d.arrayLen()
+			for k := 0; k < replicas; k++ {
+				d.int32()
+			}
+			isr := d.arrayLen()
+			for k := 0; k < isr; k++ {
+				d.int32()
+			}
+		}
+		r.topics = append(r.topics, t)
+	}
+	return d.err()
+}
+
+// produceRequest writes a record batch to a single topic partition.
+type produceRequest struct {
+	acks      int16
+	timeout   time.Duration
+	topic     string
+	partition int32
+	records   []byte
+}
+
+func (r *produceRequest) encode() []byte {
+	var e encoder
+	e.nullableString("") // transactional ID
+	e.int16(r.acks)
+	e.int32(int32(r.timeout / time.Millisecond))
+	e.arrayLen(1)
+	e.string(r.topic)
+	e.arrayLen(1)
+	e.int32(r.partition)
+	e.bytes(r.records)
+	return e.buf.Bytes()
+}
+
+func (r *produceRequest) decode(b []byte) error {
+	d := decoder{b: b}
+	d.string() // transactional ID
+	r.acks = d.int16()
+	r.timeout = time.Duration(d.int32()) * time.Millisecond
+	if topics := d.arrayLen(); topics != 1 && d.err() == nil {
+		return fmt.Errorf("kafka: produce requests to %d topics are not supported", topics)
+	}
+	r.topic = d.string()
+	if partitions := d.arrayLen(); partitions != 1 && d.err() == nil {
+		return fmt.Errorf("kafka: produce requests to %d partitions are not supported", partitions)
+	}
+	r.partition = d.int32()
+	r.records = d.bytes()
+	return d.err()
+}
+
+// produceResponse is the response to a produce request to a single topic partition.
+type produceResponse struct {
+	topic      string
+	partition  int32
+	errorCode  int16
+	baseOffset int64
+}
+
+func (r *produceResponse) encode() []byte {
+	var e encoder
+	e.arrayLen(1)
+	e.string(r.topic)
+	e.arrayLen(1)
+	e.int32(r.partition)
+	e.int16(r.errorCode)
+	e.int64(r.baseOffset)
+	e.int64(-1) // log append time
+	e.int32(0)  // throttle time
+	return e.buf.Bytes()
+}
+
+func (r *produceResponse) decode(b []byte) error {
+	d := decoder{b: b}
+	if topics := d.arrayLen(); topics != 1 && d.err() == nil {
+		return fmt.Errorf("kafka: unexpected produce response for %d topics", topics)
+	}
+	r.topic = d.string()
+	if partitions := d.arrayLen(); partitions != 1 && d.err() == nil {
+		return fmt.Errorf("kafka: unexpected produce response for %d partitions", partitions)
+	}
+	r.partition = d.int32()
+	r.errorCode = d.int16()
+	r.baseOffset = d.int64()
+	return d.err()
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordBatchRoundTrip(t *testing.T) {
+	records := []record{
+		{timestamp: time.Unix(1600000000, 0), value: []byte("foo")},
+		{
+			timestamp: time.Unix(1600000001, 0),
+			key:       []byte("key"),
+			value:     []byte("bar"),
+			headers:   []recordHeader{{key: contentEncodingHeader, value: []byte("gzip")}},
+		},
+	}
+	decoded, err := decodeRecordBatches(encodeRecordBatch(records))
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	assert.Equal(t, []byte("foo"), decoded[0].value)
+	assert.Nil(t, decoded[0].key)
+	assert.Equal(t, []byte("bar"), decoded[1].value)
+	assert.Equal(t, []byte("key"), decoded[1].key)
+	assert.Equal(t, records[1].headers, decoded[1].headers)
+	assert.Equal(t, int64(1600000001000), decoded[1].timestamp.UnixNano()/int64(time.Millisecond))
+}
+
+func TestRecordBatchCorruptedCRC(t *testing.T) {
+	batch := encodeRecordBatch([]record{{timestamp: time.Now(), value: []byte("foo")}})
+	batch[len(batch)-1] ^= 0xff
+	_, err := decodeRecordBatches(batch)
+	assert.Error(t, err)
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	request := produceRequest{
+		acks:      -1,
+		timeout:   5 * time.Second,
+		topic:     "logs",
+		partition: 2,
+		records:   encodeRecordBatch([]record{{timestamp: time.Now(), value: []byte("foo")}}),
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeRequest(&buf, apiKeyProduce, produceVersion, 42, clientID, request.encode()))
+
+	message, err := readMessage(&buf)
+	require.NoError(t, err)
+	d := decoder{b: message}
+	assert.Equal(t, int16(apiKeyProduce), d.int16())
+	assert.Equal(t, int16(produceVersion), d.int16())
+	assert.Equal(t, int32(42), d.int32())
+	assert.Equal(t, clientID, d.string())
+	require.NoError(t, d.err())
+
+	var decoded produceRequest
+	require.NoError(t, decoded.decode(message[d.off:]))
+	assert.Equal(t, request, decoded)
+}
+
+func TestMetadataResponseRoundTrip(t *testing.T) {
+	response := metadataResponse{
+		brokers: []brokerMetadata{{nodeID: 1, host: "localhost", port: 9092}},
+		topics: []topicMetadata{{
+			name:       "logs",
+			partitions: []partitionMetadata{{id: 0, leader: 1}, {id: 1, leader: 1, errorCode: 5}},
+		}},
+	}
+	var decoded metadataResponse
+	require.NoError(t, decoded.decode(response.encode()))
+	assert.Equal(t, response, decoded)
+}
+
+func TestDecodeShortBuffer(t *testing.T) {
+	var response produceResponse
+	assert.Equal(t, errShortBuffer, response.decode([]byte{0, 0, 0, 1}))
+}
+
+func TestIsRetriable(t *testing.T) {
+	assert.True(t, isRetriable(errUnknownTopicOrPartition))
+	assert.False(t, isRetriable(errMessageTooLarge))
+	assert.False(t, isRetriable(errTopicAuthorizationFailed))
+}
@@ -0,0 +1,213 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// TestRecord is a record received by a test broker.
+type TestRecord struct {
+	Partition int32
+	Value     []byte
+	Headers   map[string]string
+}
+
+// TestBroker is a single node Kafka cluster leading all the partitions of any topic,
+// it stores the records it receives in memory.
+type TestBroker struct {
+	listener   net.Listener
+	host       string
+	port       int
+	partitions int32
+
+	mu        sync.Mutex
+	errorCode int16
+	records   map[string][]TestRecord
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// NewTestBroker returns a new test broker with the given number of partitions per topic.
+func NewTestBroker(partitions int32) (*TestBroker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	portNumber, _ := strconv.Atoi(port)
+	b := &TestBroker{
+		listener:   listener,
+		host:       host,
+		port:       portNumber,
+		partitions: partitions,
+		records:    make(map[string][]TestRecord),
+		conns:      make(map[net.Conn]struct{}),
+	}
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Endpoint returns an endpoint writing to the topic of the broker.
+func (b *TestBroker) Endpoint(topic string) config.Endpoint {
+	return config.Endpoint{
+		Host:             b.host,
+		Port:             b.port,
+		BackoffFactor:    1,
+		BackoffBase:      1,
+		BackoffMax:       10,
+		RecoveryInterval: 1,
+		Kafka:            &config.KafkaConfig{Topic: topic},
+	}
+}
+
+// SetErrorCode sets the error code returned for the next produce requests.
+func (b *TestBroker) SetErrorCode(code int16) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errorCode = code
+}
+
+// Records returns the records written to a topic.
+func (b *TestBroker) Records(topic string) []TestRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]TestRecord(nil), b.records[topic]...)
+}
+
+// Stop closes the listener and all the connections.
+func (b *TestBroker) Stop() {
+	b.listener.Close()
+	b.mu.Lock()
+	for conn := range b.conns {
+		conn.Close()
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+func (b *TestBroker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns[conn] = struct{}{}
+		b.mu.Unlock()
+		b.wg.Add(1)
+		go b.serve(conn)
+	}
+}
+
+func (b *TestBroker) serve(conn net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, conn)
+		b.mu.Unlock()
+		conn.Close()
+		b.wg.Done()
+	}()
+	for {
+		request, err := readMessage(conn)
+		if err != nil {
+			return
+		}
+		d := decoder{b: request}
+		apiKey := d.int16()
+		d.int16() // api version
+		correlationID := d.int32()
+		d.string() // client ID
+		if d.err() != nil {
+			return
+		}
+		var response []byte
+		acks := int16(1)
+		switch apiKey {
+		case apiKeyMetadata:
+			response, err = b.handleMetadata(request[d.off:])
+		case apiKeyProduce:
+			var produce produceRequest
+			if err = produce.decode(request[d.off:]); err == nil {
+				acks = produce.acks
+				response, err = b.handleProduce(&produce)
+			}
+		default:
+			return
+		}
+		if err != nil {
+			return
+		}
+		if acks == 0 {
+			continue
+		}
+		var e encoder
+		e.int32(int32(4 + len(response)))
+		e.int32(correlationID)
+		e.buf.Write(response)
+		if _, err := conn.Write(e.buf.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func (b *TestBroker) handleMetadata(body []byte) ([]byte, error) {
+	var request metadataRequest
+	if err := request.decode(body); err != nil {
+		return nil, err
+	}
+	response := metadataResponse{
+		brokers: []brokerMetadata{{nodeID: 1, host: b.host, port: int32(b.port)}},
+	}
+	for _, topic := range request.topics {
+		t := topicMetadata{name: topic}
+		for i := int32(0); i < b.partitions; i++ {
+			t.partitions = append(t.partitions, partitionMetadata{id: i, leader: 1})
+		}
+		response.topics = append(response.topics, t)
+	}
+	return response.encode(), nil
+}
+
+func (b *TestBroker) handleProduce(request *produceRequest) ([]byte, error) {
+	records, err := decodeRecordBatches(request.records)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	response := produceResponse{
+		topic:      request.topic,
+		partition:  request.partition,
+		errorCode:  b.errorCode,
+		baseOffset: int64(len(b.records[request.topic])),
+	}
+	if b.errorCode != errNone {
+		return response.encode(), nil
+	}
+	for _, r := range records {
+		headers := make(map[string]string)
+		for _, h := range r.headers {
+			headers[h.key] = string(h.value)
+		}
+		b.records[request.topic] = append(b.records[request.topic], TestRecord{
+			Partition: request.partition,
+			Value:     r.value,
+			Headers:   headers,
+		})
+	}
+	return response.encode(), nil
+}
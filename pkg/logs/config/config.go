@@ -185,11 +185,21 @@ func buildTCPEndpoints(logsConfig *LogsConfigKeys) (*Endpoints, error) {
 		main.UseSSL = !logsConfig.devModeNoSSL()
 	}
 
-	additionals := logsConfig.getAdditionalEndpoints()
+	additionals := withoutArchives(logsConfig.getAdditionalEndpoints())
 	for i := 0; i < len(additionals); i++ {
 		additionals[i].UseSSL = main.UseSSL
 		additionals[i].ProxyAddress = proxyAddress
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
+		if additionals[i].Kafka != nil {
+			if err := additionals[i].Kafka.validate(); err != nil {
+				return nil, fmt.Errorf("invalid kafka endpoint %s:%d: %v", additionals[i].Host, additionals[i].Port, err)
+			}
+			additionals[i].BackoffBase = logsConfig.senderBackoffBase()
+			additionals[i].BackoffMax = logsConfig.senderBackoffMax()
+			additionals[i].BackoffFactor = logsConfig.senderBackoffFactor()
+			additionals[i].RecoveryInterval = logsConfig.senderRecoveryInterval()
+			additionals[i].RecoveryReset = logsConfig.senderRecoveryReset()
+		}
 	}
 	return NewEndpoints(main, additionals, useProto, false), nil
 }
@@ -250,6 +260,12 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 		additionals[i].BackoffFactor = main.BackoffFactor
 		additionals[i].RecoveryInterval = main.RecoveryInterval
 		additionals[i].RecoveryReset = main.RecoveryReset
+		disableArchiveReliability(&additionals[i])
+		if additionals[i].Kafka != nil {
+			if err := additionals[i].Kafka.validate(); err != nil {
+				return nil, fmt.Errorf("invalid kafka endpoint %s:%d: %v", additionals[i].Host, additionals[i].Port, err)
+			}
+		}
 
 		if additionals[i].Version == 0 {
 			additionals[i].Version = main.Version
@@ -269,6 +285,29 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 	return NewEndpointsWithBatchSettings(main, additionals, false, true, batchWait, batchMaxConcurrentSend, batchMaxSize, batchMaxContentSize), nil
 }
 
+// withoutArchives returns the endpoints which are not archive endpoints. The messages are only encoded
+// in JSON when they are sent over HTTP, they can't be archived when the logs agent uses TCP.
+func withoutArchives(endpoints []Endpoint) []Endpoint {
+	filtered := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if endpoint.Archive != nil {
+			log.Warnf("Archive endpoints are only supported when logs are sent over HTTP, logs won't be written to %s", endpoint.Archive.Path)
+			continue
+		}
+		filtered = append(filtered, endpoint)
+	}
+	return filtered
+}
+
+// disableArchiveReliability makes archive endpoints unreliable, the pipeline must not be blocked
+// when the local files can't be written.
+func disableArchiveReliability(endpoint *Endpoint) {
+	if endpoint.Archive != nil && endpoint.IsReliable {
+		log.Warnf("Archive endpoints can't be reliable, logs written to %s won't block the other endpoints", endpoint.Archive.Path)
+		endpoint.IsReliable = false
+	}
+}
+
 // parseAddress returns the host and the port of the address.
 func parseAddress(address string) (string, int, error) {
 	host, portString, err := net.SplitHostPort(address)
@@ -101,8 +101,15 @@ func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
 
+// hasAdditionalEndpoints returns true if logs are sent to additional Datadog endpoints,
+// Kafka and archive endpoints can be used with both TCP and HTTP so they are not taken into account.
 func (l *LogsConfigKeys) hasAdditionalEndpoints() bool {
-	return len(l.getAdditionalEndpoints()) > 0
+	for _, endpoint := range l.getAdditionalEndpoints() {
+		if endpoint.Kafka == nil && endpoint.Archive == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // getLogsAPIKey provides the dd api key used by the main logs agent sender.
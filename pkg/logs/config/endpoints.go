@@ -6,7 +6,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -54,10 +56,69 @@ type Endpoint struct {
 	TrackType IntakeTrackType
 	Protocol  IntakeProtocol
 	Origin    IntakeOrigin
+
+	// Kafka is set when the payloads are written to a Kafka topic instead of being sent to Datadog,
+	// Host and Port are then the address of the bootstrap broker.
+	Kafka *KafkaConfig `mapstructure:"kafka" json:"kafka"`
+
+	// Archive is set when the messages are written to local files instead of being sent to Datadog,
+	// archive endpoints are never reliable so that they can't block the pipeline. They are only
+	// supported when logs are sent over HTTP.
+	Archive *ArchiveConfig `mapstructure:"archive" json:"archive"`
+}
+
+// KafkaConfig holds the parameters to write payloads to a Kafka topic.
+type KafkaConfig struct {
+	Topic string `mapstructure:"topic" json:"topic"`
+	// RequiredAcks is the number of acknowledgements the partition leader must wait for,
+	// 0 for none, 1 for the leader only (default when unset) or -1 for all the in-sync replicas.
+	RequiredAcks *int `mapstructure:"required_acks" json:"required_acks"`
+	UseTLS       bool `mapstructure:"use_tls" json:"use_tls"`
+}
+
+// validate returns an error when the topic is not set or the required acknowledgements are invalid.
+func (k *KafkaConfig) validate() error {
+	if k.Topic == "" {
+		return errors.New("the topic is not set")
+	}
+	if k.RequiredAcks != nil && (*k.RequiredAcks < -1 || *k.RequiredAcks > 1) {
+		return fmt.Errorf("required_acks must be -1, 0 or 1, got %d", *k.RequiredAcks)
+	}
+	return nil
+}
+
+// ArchiveConfig holds the parameters to write messages to rolling local files.
+type ArchiveConfig struct {
+	// Path is the directory of the files, each pipeline writes to its own sub-directory.
+	Path string `mapstructure:"path" json:"path"`
+	// MaxFileSize is the size in bytes after which a file is rotated.
+	MaxFileSize int64 `mapstructure:"max_file_size" json:"max_file_size"`
+	// RotationInterval is the number of seconds after which a file is rotated.
+	RotationInterval int `mapstructure:"rotation_interval" json:"rotation_interval"`
+	// MaxFiles is the number of files kept per pipeline, 0 for no limit.
+	MaxFiles int `mapstructure:"max_files" json:"max_files"`
+	// MaxAge is the number of seconds a file is kept after its last write, 0 for no limit.
+	MaxAge int `mapstructure:"max_age" json:"max_age"`
+}
+
+// PipelineDir returns the directory of the files written by a pipeline.
+func (a *ArchiveConfig) PipelineDir(pipelineID int) string {
+	return filepath.Join(a.Path, fmt.Sprintf("pipeline_%d", pipelineID))
 }
 
 // GetStatus returns the endpoint status
 func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
+	if e.Archive != nil {
+		return fmt.Sprintf("%sWriting logs to local files in %s", prefix, e.Archive.Path)
+	}
+	if e.Kafka != nil {
+		protocol := "Kafka"
+		if e.Kafka.UseTLS {
+			protocol = "TLS encrypted Kafka"
+		}
+		return fmt.Sprintf("%sWriting logs in %s to topic %s of %s on port %d", prefix, protocol, e.Kafka.Topic, e.Host, e.Port)
+	}
+
 	compression := "uncompressed"
 	if e.UseCompression {
 		compression = "compressed"
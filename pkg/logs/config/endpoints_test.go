@@ -6,6 +6,7 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -308,6 +309,90 @@ func (suite *EndpointsTestSuite) TestBuildEndpointsShouldTakeIntoAccountHTTPConn
 	suite.False(endpoints.UseHTTP)
 }
 
+func (suite *EndpointsTestSuite) TestKafkaAdditionalEndpointsDoNotForceTCP() {
+	suite.config.Set("logs_config.use_http", "false")
+	suite.config.Set("logs_config.use_tcp", "false")
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{
+			"host": "kafka.local",
+			"port": 9092,
+			"kafka": map[string]interface{}{
+				"topic":         "logs",
+				"required_acks": -1,
+			},
+		},
+	})
+	endpoints, err := BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.True(endpoints.UseHTTP)
+	suite.Len(endpoints.Endpoints, 2)
+	kafka := endpoints.Endpoints[1]
+	acks := -1
+	suite.Equal(&KafkaConfig{Topic: "logs", RequiredAcks: &acks}, kafka.Kafka)
+	suite.Equal("Writing logs in Kafka to topic logs of kafka.local on port 9092", kafka.GetStatus("", true))
+
+	endpoints, err = BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.False(endpoints.UseHTTP)
+	suite.Len(endpoints.Endpoints, 2)
+	suite.Equal(defaultLogsConfigKeys().senderBackoffMax(), endpoints.Endpoints[1].BackoffMax)
+}
+
+func (suite *EndpointsTestSuite) TestInvalidKafkaAdditionalEndpoints() {
+	for _, kafka := range []map[string]interface{}{
+		{"required_acks": 1},
+		{"topic": "logs", "required_acks": 2},
+		{"topic": "logs", "required_acks": -2},
+	} {
+		suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+			{"host": "kafka.local", "port": 9092, "kafka": kafka},
+		})
+		_, err := BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+		suite.Error(err, "%v", kafka)
+		suite.Contains(err.Error(), "invalid kafka endpoint kafka.local:9092")
+		_, err = BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+		suite.Error(err, "%v", kafka)
+	}
+
+	for _, acks := range []int{-1, 0, 1} {
+		suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+			{"host": "kafka.local", "port": 9092, "kafka": map[string]interface{}{"topic": "logs", "required_acks": acks}},
+		})
+		_, err := BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+		suite.NoError(err)
+	}
+}
+
+func (suite *EndpointsTestSuite) TestArchiveAdditionalEndpointsAreUnreliable() {
+	suite.config.Set("logs_config.use_http", "false")
+	suite.config.Set("logs_config.use_tcp", "false")
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{
+			"is_reliable": true,
+			"archive": map[string]interface{}{
+				"path":          "/var/lib/datadog/logs-archive",
+				"max_file_size": 1024,
+				"max_files":     10,
+			},
+		},
+	})
+	endpoints, err := BuildEndpoints(HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.True(endpoints.UseHTTP)
+	suite.Len(endpoints.GetReliableEndpoints(), 1)
+	unreliable := endpoints.GetUnReliableEndpoints()
+	suite.Len(unreliable, 1)
+	suite.Equal(&ArchiveConfig{Path: "/var/lib/datadog/logs-archive", MaxFileSize: 1024, MaxFiles: 10}, unreliable[0].Archive)
+	suite.Equal("Writing logs to local files in /var/lib/datadog/logs-archive", unreliable[0].GetStatus("", true))
+	suite.Equal(filepath.Join("/var/lib/datadog/logs-archive", "pipeline_2"), unreliable[0].Archive.PipelineDir(2))
+
+	// the messages are not encoded in JSON over TCP, they can't be archived
+	endpoints, err = BuildEndpoints(HTTPConnectivityFailure, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.False(endpoints.UseHTTP)
+	suite.Len(endpoints.GetUnReliableEndpoints(), 0)
+}
+
 func (suite *EndpointsTestSuite) TestIsSetAndNotEmpty() {
 	suite.config.Set("bob", "vanilla")
 	suite.config.Set("empty", "")
@@ -7,6 +7,7 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -22,6 +23,7 @@ const (
 	WindowsEventType  = "windows_event"
 	SnmpTrapsType     = "snmp_traps"
 	StringChannelType = "string_channel"
+	SyslogType        = "syslog"
 
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
@@ -38,11 +40,21 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
+	Protocol    string `mapstructure:"protocol" json:"protocol"`         // Syslog
 	Path        string // File, Journald
 
-	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
-	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
-	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	Encoding     string        `mapstructure:"encoding" json:"encoding"`             // File
+	ExcludePaths []string      `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
+	TailingMode  string        `mapstructure:"start_position" json:"start_position"` // File
+	Parser       *ParserConfig `mapstructure:"parser" json:"parser"`                 // File
+	// PathTagsPattern is a regular expression matched against the path of the tailed files,
+	// each named capture group is added as a tag to the logs of the file.
+	PathTagsPattern string `mapstructure:"path_tags_pattern" json:"path_tags_pattern"` // File
+	// TODO: should be moved out
+	PathTagsRegex *regexp.Regexp
+	// IngestGzipFiles enables the ingestion of the gzip compressed files matching the path,
+	// they are read once from the beginning instead of being tailed.
+	IngestGzipFiles bool `mapstructure:"ingest_gzip_files" json:"ingest_gzip_files"` // File
 
 	IncludeUnits  []string `mapstructure:"include_units" json:"include_units"`   // Journald
 	ExcludeUnits  []string `mapstructure:"exclude_units" json:"exclude_units"`   // Journald
@@ -67,12 +79,27 @@ type LogsConfig struct {
 	SourceCategory  string
 	Tags            []string
 	ProcessingRules []*ProcessingRule `mapstructure:"log_processing_rules" json:"log_processing_rules"`
+	LogMetrics      []*LogMetricRule  `mapstructure:"log_metrics" json:"log_metrics"`
 
 	AutoMultiLine               *bool   `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
 	AutoMultiLineSampleSize     int     `mapstructure:"auto_multi_line_sample_size" json:"auto_multi_line_sample_size"`
 	AutoMultiLineMatchThreshold float64 `mapstructure:"auto_multi_line_match_threshold" json:"auto_multi_line_match_threshold"`
 }
 
+// ParserConfig configures a parser extracting the timestamp, the status and
+// attributes of the log lines with a grok pattern or a regular expression.
+type ParserConfig struct {
+	// Format is the name of a built-in pattern, like nginx.
+	Format string `mapstructure:"format" json:"format"`
+	// Pattern is a grok pattern, the captures named timestamp, status and message
+	// are used for the metadata of the log and the other ones become attributes.
+	Pattern string `mapstructure:"pattern" json:"pattern"`
+	// Definitions are custom named patterns that can be referenced in the pattern.
+	Definitions map[string]string `mapstructure:"definitions" json:"definitions"`
+	// TimestampLayout is the layout of the captured timestamp, in Go reference time format.
+	TimestampLayout string `mapstructure:"timestamp_layout" json:"timestamp_layout"`
+}
+
 // TailingMode type
 type TailingMode uint8
 
@@ -130,16 +157,71 @@ func (c *LogsConfig) Validate() error {
 		if err != nil {
 			return err
 		}
+		if c.Parser != nil && (c.Parser.Format == "") == (c.Parser.Pattern == "") {
+			return fmt.Errorf("parser must have either a format or a pattern")
+		}
+		if err := c.compilePathTagsPattern(); err != nil {
+			return err
+		}
 	case c.Type == TCPType && c.Port == 0:
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case c.Type == SyslogType && c.Port == 0:
+		return fmt.Errorf("syslog source must have a port")
+	case c.Type == SyslogType && c.Protocol != "" && c.Protocol != TCPType && c.Protocol != UDPType:
+		return fmt.Errorf("invalid protocol '%v' for syslog source, must be %s or %s", c.Protocol, TCPType, UDPType)
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
 	}
-	return CompileProcessingRules(c.ProcessingRules)
+	err = CompileProcessingRules(c.ProcessingRules)
+	if err != nil {
+		return err
+	}
+	err = ValidateLogMetricRules(c.LogMetrics)
+	if err != nil {
+		return err
+	}
+	return CompileLogMetricRules(c.LogMetrics)
+}
+
+func (c *LogsConfig) compilePathTagsPattern() error {
+	if c.PathTagsPattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(c.PathTagsPattern)
+	if err != nil {
+		return fmt.Errorf("invalid path_tags_pattern %s: %v", c.PathTagsPattern, err)
+	}
+	hasNamedGroup := false
+	for _, name := range re.SubexpNames() {
+		hasNamedGroup = hasNamedGroup || name != ""
+	}
+	if !hasNamedGroup {
+		return fmt.Errorf("path_tags_pattern %s must have at least one named capture group", c.PathTagsPattern)
+	}
+	c.PathTagsRegex = re
+	return nil
+}
+
+// PathTags returns the tags captured from the path of a file by the path tags pattern.
+func (c *LogsConfig) PathTags(path string) []string {
+	if c.PathTagsRegex == nil {
+		return nil
+	}
+	matches := c.PathTagsRegex.FindStringSubmatch(path)
+	if matches == nil {
+		return nil
+	}
+	var tags []string
+	for i, name := range c.PathTagsRegex.SubexpNames() {
+		if name != "" && matches[i] != "" {
+			tags = append(tags, name+":"+matches[i])
+		}
+	}
+	return tags
 }
 
 func (c *LogsConfig) validateTailingMode() error {
@@ -23,6 +23,11 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: SnmpTrapsType},
+		{Type: SyslogType, Port: 514},
+		{Type: SyslogType, Port: 514, Protocol: UDPType},
+		{Type: FileType, Path: "/var/log/nginx/access.log", Parser: &ParserConfig{Format: "nginx"}},
+		{Type: FileType, Path: "/var/log/foo.log", Parser: &ParserConfig{Pattern: "%{GREEDYDATA:message}"}},
+		{Type: FileType, Path: "/var/log/tenants/*/app.log", PathTagsPattern: "/tenants/(?P<tenant>[^/]+)/"},
 	}
 
 	for _, config := range validConfigs {
@@ -35,8 +40,14 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 	invalidConfigs := []*LogsConfig{
 		{},
 		{Type: FileType},
+		{Type: FileType, Path: "/var/log/foo.log", Parser: &ParserConfig{}},
+		{Type: FileType, Path: "/var/log/foo.log", PathTagsPattern: "/tenants/([^/]+)/"},
+		{Type: FileType, Path: "/var/log/foo.log", PathTagsPattern: "(?P<tenant>"},
+		{Type: FileType, Path: "/var/log/foo.log", Parser: &ParserConfig{Format: "nginx", Pattern: "%{GREEDYDATA:message}"}},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: SyslogType},
+		{Type: SyslogType, Port: 514, Protocol: "sctp"},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -52,6 +63,15 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 	}
 }
 
+func TestPathTags(t *testing.T) {
+	config := &LogsConfig{Type: FileType, Path: "/var/log/tenants/*/*.log", PathTagsPattern: "/tenants/(?P<tenant>[^/]+)/(?P<app>[^/.]+)?"}
+	assert.Nil(t, config.Validate())
+	assert.Equal(t, []string{"tenant:acme", "app:billing"}, config.PathTags("/var/log/tenants/acme/billing.log"))
+	assert.Equal(t, []string{"tenant:acme"}, config.PathTags("/var/log/tenants/acme/.log"))
+	assert.Nil(t, config.PathTags("/var/log/other/billing.log"))
+	assert.Nil(t, (&LogsConfig{}).PathTags("/var/log/tenants/acme/billing.log"))
+}
+
 func TestAutoMultilineEnabled(t *testing.T) {
 	mockConfig := config.Mock()
 	decode := func(cfg string) *LogsConfig {
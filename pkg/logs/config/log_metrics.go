@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Log metric types
+const (
+	LogMetricCount        = "count"
+	LogMetricGauge        = "gauge"
+	LogMetricHistogram    = "histogram"
+	LogMetricDistribution = "distribution"
+)
+
+// logMetricValueGroup is the name of the capture group holding the value of a metric,
+// the first capture group is used when the pattern does not define it.
+const logMetricValueGroup = "value"
+
+// LogMetricRule defines a metric generated from the log lines of a source,
+// either by counting the lines matching a pattern or by extracting a numeric
+// value from a JSON field or a capture group of the pattern.
+type LogMetricRule struct {
+	Name    string
+	Type    string
+	Pattern string
+	Field   string
+	Tags    []string
+	// TODO: should be moved out
+	Regex      *regexp.Regexp
+	ValueGroup int
+	FieldPath  []string
+}
+
+// ValidateLogMetricRules validates the rules and raises an error if one is misconfigured.
+// Each log metric rule must have:
+// - a metric name
+// - a valid type
+// - a valid pattern that compiles, if any
+// - a field or a pattern with a capture group, except for counts
+func ValidateLogMetricRules(rules []*LogMetricRule) error {
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("all log metrics must have a name")
+		}
+		switch rule.Type {
+		case LogMetricCount, LogMetricGauge, LogMetricHistogram, LogMetricDistribution:
+			break
+		case "":
+			return fmt.Errorf("type must be set for log metric `%s`", rule.Name)
+		default:
+			return fmt.Errorf("type %s is not supported for log metric `%s`", rule.Type, rule.Name)
+		}
+		numGroups := 0
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for log metric: %s", rule.Pattern, rule.Name)
+			}
+			numGroups = re.NumSubexp()
+		}
+		if rule.Type != LogMetricCount && rule.Field == "" && numGroups == 0 {
+			return fmt.Errorf("no field or capture group provided for log metric: %s", rule.Name)
+		}
+	}
+	return nil
+}
+
+// CompileLogMetricRules compiles all log metric regular expressions.
+func CompileLogMetricRules(rules []*LogMetricRule) error {
+	for _, rule := range rules {
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return err
+			}
+			rule.Regex = re
+			// the value is only extracted from the pattern when no field is set.
+			if rule.Field == "" && re.NumSubexp() > 0 {
+				rule.ValueGroup = 1
+				if index := re.SubexpIndex(logMetricValueGroup); index > 0 {
+					rule.ValueGroup = index
+				}
+			}
+		}
+		if rule.Field != "" {
+			rule.FieldPath = strings.Split(rule.Field, ".")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLogMetricRules(t *testing.T) {
+	validRules := []*LogMetricRule{
+		{Name: "foo", Type: LogMetricCount},
+		{Name: "foo", Type: LogMetricCount, Pattern: "error"},
+		{Name: "foo", Type: LogMetricGauge, Field: "duration"},
+		{Name: "foo", Type: LogMetricHistogram, Pattern: `latency=(\d+)`},
+		{Name: "foo", Type: LogMetricDistribution, Field: "duration", Pattern: "GET"},
+	}
+	for _, rule := range validRules {
+		assert.Nil(t, ValidateLogMetricRules([]*LogMetricRule{rule}))
+	}
+
+	invalidRules := []*LogMetricRule{
+		{Type: LogMetricCount},
+		{Name: "foo"},
+		{Name: "foo", Type: "rate"},
+		{Name: "foo", Type: LogMetricGauge},
+		{Name: "foo", Type: LogMetricGauge, Pattern: "latency"},
+		{Name: "foo", Type: LogMetricCount, Pattern: "(?=abf)"},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateLogMetricRules([]*LogMetricRule{rule}))
+	}
+}
+
+func TestCompileLogMetricRules(t *testing.T) {
+	rules := []*LogMetricRule{
+		{Type: LogMetricCount, Pattern: "error"},
+		{Type: LogMetricGauge, Pattern: `latency=(\d+)`},
+		{Type: LogMetricGauge, Pattern: `(GET|POST) .* latency=(?P<value>\d+)`},
+		{Type: LogMetricGauge, Pattern: `latency=(\d+)`, Field: "http.duration"},
+	}
+	assert.Nil(t, CompileLogMetricRules(rules))
+
+	assert.NotNil(t, rules[0].Regex)
+	assert.Equal(t, 0, rules[0].ValueGroup)
+	assert.Equal(t, 1, rules[1].ValueGroup)
+	assert.Equal(t, 2, rules[2].ValueGroup)
+	assert.Equal(t, 0, rules[3].ValueGroup)
+	assert.Equal(t, []string{"http", "duration"}, rules[3].FieldPath)
+}
@@ -8,6 +8,7 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // Processing rule types
@@ -16,6 +17,19 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+
+	// Sampling processing rules keep a part of the matching lines of each source.
+	SampleAtMatch    = "sample_at_match"
+	RateLimitAtMatch = "rate_limit_at_match"
+
+	// Field processing rules apply on a field of JSON logs, addressed by a dot-separated path.
+	ExcludeAtFieldMatch = "exclude_at_field_match"
+	IncludeAtFieldMatch = "include_at_field_match"
+	MaskField           = "mask_field"
+	RemoveField         = "remove_field"
+	RenameField         = "rename_field"
+	StatusFromField     = "status_from_field"
+	ServiceFromField    = "service_from_field"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,32 +39,76 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	Field              string  // Field processing rules
+	Target             string  // RenameField
+	SampleRate         float64 `mapstructure:"sample_rate" json:"sample_rate"`           // SampleAtMatch
+	LinesPerSecond     int     `mapstructure:"lines_per_second" json:"lines_per_second"` // RateLimitAtMatch
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
+	FieldPath   []string
+	TargetPath  []string
+}
+
+// IsFieldRule returns true if the rule applies on a field of JSON logs rather than on the raw content.
+func (r *ProcessingRule) IsFieldRule() bool {
+	switch r.Type {
+	case ExcludeAtFieldMatch, IncludeAtFieldMatch, MaskField, RemoveField, RenameField, StatusFromField, ServiceFromField:
+		return true
+	}
+	return false
+}
+
+// requiresPattern returns true if the rule can't be applied without a pattern.
+func (r *ProcessingRule) requiresPattern() bool {
+	switch r.Type {
+	case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine, ExcludeAtFieldMatch, IncludeAtFieldMatch:
+		return true
+	}
+	return false
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
 // - a valid type
-// - a valid pattern that compiles
+// - a valid pattern that compiles, optional for some field rules
+// - a field for field rules
+// - a sample rate or a number of lines per second for sampling rules
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
 			return fmt.Errorf("all processing rules must have a name")
 		}
 
-		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+		switch {
+		case rule.IsFieldRule():
+			if rule.Field == "" {
+				return fmt.Errorf("no field provided for processing rule: %s", rule.Name)
+			}
+			if rule.Type == RenameField && rule.Target == "" {
+				return fmt.Errorf("no target provided for processing rule: %s", rule.Name)
+			}
+		case rule.Type == SampleAtMatch:
+			if rule.SampleRate <= 0 || rule.SampleRate > 1 {
+				return fmt.Errorf("sample_rate must be greater than 0 and lower or equal to 1 for processing rule: %s", rule.Name)
+			}
+		case rule.Type == RateLimitAtMatch:
+			if rule.LinesPerSecond <= 0 {
+				return fmt.Errorf("lines_per_second must be greater than 0 for processing rule: %s", rule.Name)
+			}
+		case rule.Type == ExcludeAtMatch, rule.Type == IncludeAtMatch, rule.Type == MaskSequences, rule.Type == MultiLine:
 			break
-		case "":
+		case rule.Type == "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
 			return fmt.Errorf("type %s is not supported for processing rule `%s`", rule.Type, rule.Name)
 		}
 
 		if rule.Pattern == "" {
+			if !rule.requiresPattern() {
+				continue
+			}
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
 		_, err := regexp.Compile(rule.Pattern)
@@ -69,7 +127,7 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, SampleAtMatch, RateLimitAtMatch:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
@@ -79,6 +137,19 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 			if err != nil {
 				return err
 			}
+		case ExcludeAtFieldMatch, IncludeAtFieldMatch:
+			rule.Regex = re
+		case MaskField:
+			if rule.Pattern != "" {
+				rule.Regex = re
+			}
+			rule.Placeholder = []byte(rule.ReplacePlaceholder)
+		}
+		if rule.IsFieldRule() {
+			rule.FieldPath = strings.Split(rule.Field, ".")
+			if rule.Target != "" {
+				rule.TargetPath = strings.Split(rule.Target, ".")
+			}
 		}
 	}
 	return nil
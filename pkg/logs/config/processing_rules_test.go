@@ -33,3 +33,55 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateFieldRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "foo", Type: ExcludeAtFieldMatch, Field: "level", Pattern: "debug"},
+		{Name: "foo", Type: MaskField, Field: "user.email"},
+		{Name: "foo", Type: RemoveField, Field: "request.id"},
+		{Name: "foo", Type: RenameField, Field: "usr", Target: "user.name"},
+		{Name: "foo", Type: StatusFromField, Field: "level"},
+		{Name: "foo", Type: ServiceFromField, Field: "app"},
+	}
+	for _, rule := range validRules {
+		assert.Nil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Type)
+	}
+
+	invalidRules := []*ProcessingRule{
+		{Name: "foo", Type: ExcludeAtFieldMatch, Field: "level"},
+		{Name: "foo", Type: IncludeAtFieldMatch, Pattern: "debug"},
+		{Name: "foo", Type: RemoveField},
+		{Name: "foo", Type: RenameField, Field: "usr"},
+		{Name: "foo", Type: MaskField, Field: "user.email", Pattern: "(?=abf)"},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Type)
+	}
+}
+
+func TestCompileFieldRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: RenameField, Field: "usr", Target: "user.name"},
+		{Type: MaskField, Field: "user.email", ReplacePlaceholder: "[masked]"},
+	}
+	err := CompileProcessingRules(rules)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"usr"}, rules[0].FieldPath)
+	assert.Equal(t, []string{"user", "name"}, rules[0].TargetPath)
+	assert.Nil(t, rules[1].Regex)
+	assert.Equal(t, []byte("[masked]"), rules[1].Placeholder)
+}
+
+func TestValidateSamplingRules(t *testing.T) {
+	assert.Nil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: SampleAtMatch, Pattern: "debug", SampleRate: 0.1}}))
+	assert.Nil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: SampleAtMatch, SampleRate: 1}}))
+	assert.Nil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: RateLimitAtMatch, LinesPerSecond: 100}}))
+
+	assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: SampleAtMatch, Pattern: "debug"}}))
+	assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: SampleAtMatch, SampleRate: 1.5}}))
+	assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{{Name: "foo", Type: RateLimitAtMatch, Pattern: "debug"}}))
+
+	rules := []*ProcessingRule{{Type: RateLimitAtMatch, LinesPerSecond: 100}}
+	assert.Nil(t, CompileProcessingRules(rules))
+	assert.True(t, rules[0].Regex.MatchString("any line"))
+}
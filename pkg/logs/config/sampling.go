@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// SampledOutInfoKey is the key of the number of lines dropped by the sampling rules of a source on the status page.
+const SampledOutInfoKey = "Lines dropped by sampling rules"
+
+// SamplingState tracks the lines kept by a sampling processing rule for one source,
+// the lines of a source can be processed by several pipelines so it is thread safe.
+type SamplingState struct {
+	mu sync.Mutex
+	// credit accumulates the sample rate of the matching lines, a line is kept
+	// each time it reaches 1 so that exactly a fraction of the lines is kept.
+	credit float64
+	// window is the second of the current rate limiting window.
+	window      int64
+	windowCount int
+	sampledOut  *CountInfo
+}
+
+// Keep returns true if the line matching the rule must be kept.
+func (s *SamplingState) Keep(rule *ProcessingRule, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := true
+	switch rule.Type {
+	case SampleAtMatch:
+		s.credit += rule.SampleRate
+		if s.credit >= 1 {
+			s.credit--
+		} else {
+			keep = false
+		}
+	case RateLimitAtMatch:
+		if second := now.Unix(); second != s.window {
+			s.window = second
+			s.windowCount = 0
+		}
+		if s.windowCount < rule.LinesPerSecond {
+			s.windowCount++
+		} else {
+			keep = false
+		}
+	}
+	if !keep {
+		s.sampledOut.Add(1)
+	}
+	return keep
+}
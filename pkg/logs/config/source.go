@@ -49,6 +49,7 @@ type LogSource struct {
 	// the duration between when a message is decoded by the tailer/listener/decoder and when the message is handled by a sender
 	LatencyStats     *util.StatsTracker
 	hiddenFromStatus bool
+	samplingStates   map[*ProcessingRule]*SamplingState
 }
 
 // NewLogSource creates a new log source.
@@ -64,6 +65,7 @@ func NewLogSource(name string, config *LogsConfig) *LogSource {
 		info:             make(map[string]InfoProvider),
 		LatencyStats:     util.NewStatsTracker(time.Hour*24, time.Hour),
 		hiddenFromStatus: false,
+		samplingStates:   make(map[*ProcessingRule]*SamplingState),
 	}
 }
 
@@ -120,6 +122,24 @@ func (s *LogSource) GetInfo(key string) InfoProvider {
 	return s.info[key]
 }
 
+// GetSamplingState returns the state of a sampling rule for this source,
+// the lines dropped by the rule are reported on the status page of the source.
+func (s *LogSource) GetSamplingState(rule *ProcessingRule) *SamplingState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if state, exists := s.samplingStates[rule]; exists {
+		return state
+	}
+	sampledOut, ok := s.info[SampledOutInfoKey].(*CountInfo)
+	if !ok {
+		sampledOut = NewCountInfo(SampledOutInfoKey)
+		s.info[SampledOutInfoKey] = sampledOut
+	}
+	state := &SamplingState{sampledOut: sampledOut}
+	s.samplingStates[rule] = state
+	return state
+}
+
 // GetInfoStatus returns a primitive representation of the info for the status page
 func (s *LogSource) GetInfoStatus() map[string][]string {
 	s.lock.Lock()
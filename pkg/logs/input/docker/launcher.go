@@ -289,6 +289,7 @@ func (l *Launcher) getFileSource(container *Container, source *config.LogSource)
 		Source:          sourceName,
 		Tags:            source.Config.Tags,
 		ProcessingRules: source.Config.ProcessingRules,
+		Parser:          source.Config.Parser,
 	})
 	fileSource.SetSourceType(config.DockerSourceType)
 	fileSource.Status = source.Status
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -21,6 +22,12 @@ import (
 // files are tailed
 const openFilesLimitWarningType = "open_files_limit_warning"
 
+// matchedFilesInfoKey is the key of the files matching a wildcard path on the status page.
+const matchedFilesInfoKey = "Matched files"
+
+// maxMatchedFilesInfo is the maximum number of files listed on the status page for a wildcard path.
+const maxMatchedFilesInfo = 100
+
 // File represents a file to tail
 type File struct {
 	Path string
@@ -75,7 +82,7 @@ func (p *Provider) FilesToTail(sources []*config.LogSource) []*File {
 	for i := 0; i < len(sources); i++ {
 		source := sources[i]
 		tailedFileCounter := 0
-		files, err := p.CollectFiles(source)
+		files, excludedPaths, err := p.collectFiles(source)
 		isWildcardPath := config.ContainsWildcard(source.Config.Path)
 		if err != nil {
 			source.Status.Error(err)
@@ -107,6 +114,7 @@ func (p *Provider) FilesToTail(sources []*config.LogSource) []*File {
 
 		if isWildcardPath {
 			source.Messages.AddMessage(source.Config.Path, fmt.Sprintf("%d files tailed out of %d files matching", tailedFileCounter, len(files)))
+			source.RegisterInfo(newMatchedFilesInfo(source, files, tailedFileCounter, excludedPaths))
 		}
 	}
 
@@ -120,32 +128,40 @@ func (p *Provider) FilesToTail(sources []*config.LogSource) []*File {
 
 // CollectFiles returns all the files matching the source path.
 func (p *Provider) CollectFiles(source *config.LogSource) ([]*File, error) {
+	files, _, err := p.collectFiles(source)
+	return files, err
+}
+
+// collectFiles returns all the files matching the source path,
+// and the paths matching the source path that are excluded.
+func (p *Provider) collectFiles(source *config.LogSource) ([]*File, []string, error) {
 	path := source.Config.Path
 	_, err := os.Stat(path)
 	switch {
 	case err == nil:
 		return []*File{
 			NewFile(path, source, false),
-		}, nil
+		}, nil, nil
 	case config.ContainsWildcard(path):
 		pattern := path
 		return p.searchFiles(pattern, source)
 	default:
-		return nil, fmt.Errorf("cannot read file %s: %s", path, err)
+		return nil, nil, fmt.Errorf("cannot read file %s: %s", path, err)
 	}
 }
 
-// searchFiles returns all the files matching the source path pattern.
-func (p *Provider) searchFiles(pattern string, source *config.LogSource) ([]*File, error) {
+// searchFiles returns all the files matching the source path pattern, and the excluded ones.
+func (p *Provider) searchFiles(pattern string, source *config.LogSource) ([]*File, []string, error) {
 	paths, err := filepath.Glob(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("malformed pattern, could not find any file: %s", pattern)
+		return nil, nil, fmt.Errorf("malformed pattern, could not find any file: %s", pattern)
 	}
 	if len(paths) == 0 {
 		// no file was found, its parent directories might have wrong permissions or it just does not exist
-		return nil, fmt.Errorf("could not find any file matching pattern %s, check that all its subdirectories are executable", pattern)
+		return nil, nil, fmt.Errorf("could not find any file matching pattern %s, check that all its subdirectories are executable", pattern)
 	}
 	var files []*File
+	var excludedPaths []string
 
 	// Files are sorted because of a heuristic on the filename: often the filename and/or the folder name
 	// contains information in the file datetime. Most of the time we want the most recent files.
@@ -162,26 +178,106 @@ func (p *Provider) searchFiles(pattern string, source *config.LogSource) ([]*Fil
 		return filepath.Base(paths[i]) > filepath.Base(paths[j])
 	})
 
-	// Resolve excluded path(s)
-	excludedPaths := make(map[string]int)
 	for _, excludePattern := range source.Config.ExcludePaths {
-		excludedGlob, err := filepath.Glob(excludePattern)
-		if err != nil {
-			return nil, fmt.Errorf("malformed exclusion pattern: %s, %s", excludePattern, err)
+		if _, err := filepath.Match(excludePattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("malformed exclusion pattern: %s, %s", excludePattern, err)
+		}
+	}
+
+	for _, path := range paths {
+		if excludePattern, excluded := isExcluded(path, source.Config.ExcludePaths); excluded {
+			log.Debugf("Excluding path %s matching %s", path, excludePattern)
+			excludedPaths = append(excludedPaths, path)
+			continue
 		}
-		for _, excludedPath := range excludedGlob {
-			log.Debugf("Adding excluded path: %s", excludedPath)
-			excludedPaths[excludedPath]++
-			if excludedPaths[excludedPath] > 1 {
-				log.Debugf("Overlapping excluded path: %s", excludedPath)
+		files = append(files, NewFile(path, source, true))
+	}
+	return files, excludedPaths, nil
+}
+
+// isExcluded returns the first exclusion pattern matching the path, if any.
+// A pattern without any separator is matched against the name of the file,
+// "**" matches any number of directories.
+func isExcluded(path string, excludePatterns []string) (string, bool) {
+	for _, excludePattern := range excludePatterns {
+		var match bool
+		if !strings.Contains(filepath.ToSlash(excludePattern), "/") {
+			match, _ = filepath.Match(excludePattern, filepath.Base(path))
+		} else {
+			match = matchSegments(splitPath(excludePattern), splitPath(path))
+		}
+		if match {
+			return excludePattern, true
+		}
+	}
+	return "", false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(path), "/")
+}
+
+// matchSegments matches the segments of a path against the segments of a pattern.
+func matchSegments(pattern []string, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// "**" matches zero or more segments.
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
 			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if match, _ := filepath.Match(pattern[0], path[0]); !match {
+			return false
 		}
+		pattern = pattern[1:]
+		path = path[1:]
 	}
+	return len(path) == 0
+}
 
-	for _, path := range paths {
-		if excludedPaths[path] == 0 {
-			files = append(files, NewFile(path, source, true))
+// matchedFilesInfo lists the files matching a wildcard path on the status page.
+type matchedFilesInfo struct {
+	info []string
+}
+
+func newMatchedFilesInfo(source *config.LogSource, files []*File, tailedFiles int, excludedPaths []string) *matchedFilesInfo {
+	var info []string
+	for i, file := range files {
+		if len(info) == maxMatchedFilesInfo {
+			break
 		}
+		line := file.Path
+		if i >= tailedFiles {
+			line += " (not tailed, open files limit reached)"
+		} else if tags := source.Config.PathTags(file.Path); len(tags) > 0 {
+			line += fmt.Sprintf(" (tags: %s)", strings.Join(tags, ","))
+		}
+		info = append(info, line)
+	}
+	for _, path := range excludedPaths {
+		if len(info) == maxMatchedFilesInfo {
+			break
+		}
+		info = append(info, path+" (excluded)")
+	}
+	if total := len(files) + len(excludedPaths); total > len(info) {
+		info = append(info, fmt.Sprintf("and %d more files", total-len(info)))
 	}
-	return files, nil
+	return &matchedFilesInfo{info: info}
+}
+
+// InfoKey returns the key
+func (m *matchedFilesInfo) InfoKey() string {
+	return matchedFilesInfoKey
+}
+
+// Info returns the info
+func (m *matchedFilesInfo) Info() []string {
+	return m.info
 }
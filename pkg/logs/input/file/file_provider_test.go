@@ -13,6 +13,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -272,6 +273,75 @@ func (suite *ProviderTestSuite) TestExcludePath() {
 	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[2].Path)
 }
 
+func (suite *ProviderTestSuite) TestExcludePathByFileName() {
+	path := fmt.Sprintf("%s/*/*.log", suite.testDir)
+	fileProvider := NewProvider(6)
+	logSources := []*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, ExcludePaths: []string{"2.log"}}),
+	}
+
+	files := fileProvider.FilesToTail(logSources)
+	suite.Equal(3, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/3.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/1.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[2].Path)
+}
+
+func (suite *ProviderTestSuite) TestExcludePathWithDoubleStar() {
+	path := fmt.Sprintf("%s/*/*.log", suite.testDir)
+	fileProvider := NewProvider(6)
+	logSources := []*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, ExcludePaths: []string{fmt.Sprintf("%s/**/1.log", suite.testDir)}}),
+	}
+
+	files := fileProvider.FilesToTail(logSources)
+	suite.Equal(3, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/3.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/2.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/1/2.log", suite.testDir), files[2].Path)
+}
+
+func (suite *ProviderTestSuite) TestMatchedFilesInfo() {
+	path := fmt.Sprintf("%s/*/*.log", suite.testDir)
+	fileProvider := NewProvider(2)
+	logsConfig := &config.LogsConfig{
+		Type:            config.FileType,
+		Path:            path,
+		ExcludePaths:    []string{"3.log"},
+		PathTagsPattern: `/(?P<tenant>\d+)/\d+\.log$`,
+	}
+	suite.Nil(logsConfig.Validate())
+	logSources := []*config.LogSource{config.NewLogSource("", logsConfig)}
+
+	files := fileProvider.FilesToTail(logSources)
+	suite.Equal(2, len(files))
+	suite.Equal(map[string][]string{
+		matchedFilesInfoKey: {
+			fmt.Sprintf("%s/2/2.log (tags: tenant:2)", suite.testDir),
+			fmt.Sprintf("%s/1/2.log (tags: tenant:1)", suite.testDir),
+			fmt.Sprintf("%s/2/1.log (not tailed, open files limit reached)", suite.testDir),
+			fmt.Sprintf("%s/1/1.log (not tailed, open files limit reached)", suite.testDir),
+			fmt.Sprintf("%s/1/3.log (excluded)", suite.testDir),
+		},
+	}, logSources[0].GetInfoStatus())
+}
+
+func TestIsExcluded(t *testing.T) {
+	patterns := []string{"*.gz", "/var/log/app/archive/**", "/var/log/*/debug.log"}
+	for path, expected := range map[string]bool{
+		"/var/log/app/app.log":                false,
+		"/var/log/app/app.log.1.gz":           true,
+		"/var/log/app/archive/app.log":        true,
+		"/var/log/app/archive/2021/01/01.log": true,
+		"/var/log/app/debug.log":              true,
+		"/var/log/app/sub/debug.log":          false,
+		"/var/log/app/archived.log":           false,
+	} {
+		_, excluded := isExcluded(path, patterns)
+		assert.Equal(t, expected, excluded, path)
+	}
+}
+
 func TestProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(ProviderTestSuite))
 }
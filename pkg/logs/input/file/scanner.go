@@ -36,13 +36,15 @@ var ContainersLogsDir = "/var/log/containers"
 // Scanner checks all files provided by fileProvider and create new tailers
 // or update the old ones if needed
 type Scanner struct {
-	pipelineProvider    pipeline.Provider
-	addedSources        chan *config.LogSource
-	removedSources      chan *config.LogSource
-	activeSources       []*config.LogSource
-	tailingLimit        int
-	fileProvider        *Provider
-	tailers             map[string]*Tailer
+	pipelineProvider pipeline.Provider
+	addedSources     chan *config.LogSource
+	removedSources   chan *config.LogSource
+	activeSources    []*config.LogSource
+	tailingLimit     int
+	fileProvider     *Provider
+	tailers          map[string]*Tailer
+	// ingestedFiles holds the keys of the compressed files that have been read completely.
+	ingestedFiles       map[string]struct{}
 	registry            auditor.Registry
 	tailerSleepDuration time.Duration
 	stop                chan struct{}
@@ -63,6 +65,7 @@ func NewScanner(sources *config.LogSources, tailingLimit int, pipelineProvider p
 		removedSources:         sources.GetRemovedForType(config.FileType),
 		fileProvider:           NewProvider(tailingLimit),
 		tailers:                make(map[string]*Tailer),
+		ingestedFiles:          make(map[string]struct{}),
 		registry:               registry,
 		tailerSleepDuration:    tailerSleepDuration,
 		stop:                   make(chan struct{}),
@@ -120,6 +123,7 @@ func (s *Scanner) cleanup() {
 func (s *Scanner) scan() {
 	files := s.fileProvider.FilesToTail(s.activeSources)
 	filesTailed := make(map[string]bool)
+	filesMatched := make(map[string]bool)
 	tailersLen := len(s.tailers)
 
 	for _, file := range files {
@@ -133,8 +137,19 @@ func (s *Scanner) scan() {
 		// when a tailer for a dead container is still tailing the file, and another
 		// tailer is tailing the file for the new container).
 		tailerKey := file.GetScanKey()
+		filesMatched[tailerKey] = true
+		if _, isIngested := s.ingestedFiles[tailerKey]; isIngested {
+			// the compressed file has already been read
+			continue
+		}
 		tailer, isTailed := s.tailers[tailerKey]
 		if isTailed && atomic.LoadInt32(&tailer.shouldStop) != 0 {
+			if tailer.isGzip && atomic.LoadInt32(&tailer.gzipEOF) != 0 {
+				// the compressed file has been read until EOF, it must not be read again.
+				// It is read again from the registered offset when the tailer stopped on
+				// an error, for instance when the file is truncated or still being written.
+				s.ingestedFiles[tailerKey] = struct{}{}
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
@@ -155,6 +170,12 @@ func (s *Scanner) scan() {
 			continue
 		}
 
+		if tailer.isGzip {
+			// compressed files are not rotated, they are read once
+			filesTailed[tailerKey] = true
+			continue
+		}
+
 		didRotate, err := DidRotate(tailer.osFile, tailer.GetReadOffset())
 		if err != nil {
 			continue
@@ -178,6 +199,14 @@ func (s *Scanner) scan() {
 			s.stopTailer(tailer)
 		}
 	}
+
+	// forget the compressed files that are not matched anymore, their registry
+	// offset prevents them from being read again if they are matched again.
+	for key := range s.ingestedFiles {
+		if _, isMatched := filesMatched[key]; !isMatched {
+			delete(s.ingestedFiles, key)
+		}
+	}
 }
 
 // addSource keeps track of the new source and launch new tailers for this source.
@@ -212,6 +241,9 @@ func (s *Scanner) launchTailers(source *config.LogSource) {
 		if _, isTailed := s.tailers[file.GetScanKey()]; isTailed {
 			continue
 		}
+		if _, isIngested := s.ingestedFiles[file.GetScanKey()]; isIngested {
+			continue
+		}
 
 		mode, _ := config.TailingModeFromString(source.Config.TailingMode)
 
@@ -256,12 +288,22 @@ func (s *Scanner) startNewTailer(file *File, m config.TailingMode) bool {
 	var offset int64
 	var whence int
 	mode := s.handleTailingModeChange(tailer.Identifier(), m)
+	if tailer.isGzip && (mode == config.End || mode == config.ForceEnd) {
+		// compressed files are meant to be read completely
+		mode = config.Beginning
+	}
 
 	offset, whence, err := Position(s.registry, tailer.Identifier(), mode)
 	if err != nil {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
 
+	if tailer.isGzip && mode != config.ForceBeginning && isGzipFileRead(file.Path, offset) {
+		log.Debugf("Compressed file %s has already been read", file.Path)
+		s.ingestedFiles[file.GetScanKey()] = struct{}{}
+		return false
+	}
+
 	log.Infof("Starting a new tailer for: %s (offset: %d, whence: %d) for tailer key %s", file.Path, offset, whence, file.GetScanKey())
 	err = tailer.Start(offset, whence)
 	if err != nil {
@@ -8,6 +8,8 @@
 package file
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -344,6 +346,107 @@ func TestScannerTailFromTheBeginning(t *testing.T) {
 	}
 }
 
+func TestScannerIngestsGzipFilesOnce(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-scanner-test-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte("Once\nUpon\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, file.Close())
+
+	sleepDuration := 20 * time.Millisecond
+	registry := auditor.NewRegistry()
+	pipelineProvider := mock.NewMockProvider()
+	scanner := NewScanner(config.NewLogSources(), 3, pipelineProvider, registry, sleepDuration, false, 10*time.Second)
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/*.gz", testDir), IngestGzipFiles: true})
+	scanner.addSource(source)
+	assert.Equal(t, 1, len(scanner.tailers))
+
+	outputChan := pipelineProvider.NextPipelineChan()
+	msg := <-outputChan
+	assert.Equal(t, "Once", string(msg.Content))
+	msg = <-outputChan
+	assert.Equal(t, "Upon", string(msg.Content))
+	assert.Equal(t, "10", msg.Origin.Offset)
+
+	// the tailer stops at EOF and the file is not read again
+	tailer := scanner.tailers[getScanKey(path, source)]
+	<-tailer.done
+	scanner.scan()
+	scanner.scan()
+	assert.Equal(t, 0, len(scanner.tailers))
+	assert.Equal(t, 0, len(outputChan))
+
+	// the file is not read again after a restart when the registry holds its uncompressed size
+	registry.SetOffset("10")
+	scanner = NewScanner(config.NewLogSources(), 3, pipelineProvider, registry, sleepDuration, false, 10*time.Second)
+	scanner.addSource(source)
+	assert.Equal(t, 0, len(scanner.tailers))
+	scanner.scan()
+	assert.Equal(t, 0, len(scanner.tailers))
+
+	// a partially read file is resumed from the registered offset
+	registry.SetOffset("5")
+	scanner = NewScanner(config.NewLogSources(), 3, pipelineProvider, registry, sleepDuration, false, 10*time.Second)
+	scanner.addSource(source)
+	assert.Equal(t, 1, len(scanner.tailers))
+	msg = <-outputChan
+	assert.Equal(t, "Upon", string(msg.Content))
+	scanner.cleanup()
+}
+
+func TestScannerReadsTruncatedGzipFilesAgain(t *testing.T) {
+	testDir := t.TempDir()
+	path := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	var content bytes.Buffer
+	writer := gzip.NewWriter(&content)
+	_, err := writer.Write([]byte("Once\nUpon\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	// the file is still being written, its trailer is missing
+	assert.Nil(t, ioutil.WriteFile(path, content.Bytes()[:content.Len()-8], 0644))
+
+	sleepDuration := 20 * time.Millisecond
+	pipelineProvider := mock.NewMockProvider()
+	scanner := NewScanner(config.NewLogSources(), 3, pipelineProvider, auditor.NewRegistry(), sleepDuration, false, 10*time.Second)
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/*.gz", testDir), IngestGzipFiles: true})
+	scanner.addSource(source)
+	assert.Equal(t, 1, len(scanner.tailers))
+
+	// the tailer stops on the unexpected EOF, the file is not recorded as ingested
+	tailer := scanner.tailers[getScanKey(path, source)]
+	<-tailer.done
+	scanner.scan()
+	assert.Equal(t, 0, len(scanner.ingestedFiles))
+	assert.Equal(t, 0, len(scanner.tailers))
+
+	// the file is read again once complete
+	outputChan := pipelineProvider.NextPipelineChan()
+	for len(outputChan) > 0 {
+		<-outputChan
+	}
+	assert.Nil(t, ioutil.WriteFile(path, content.Bytes(), 0644))
+	scanner.scan()
+	assert.Equal(t, 1, len(scanner.tailers))
+	msg := <-outputChan
+	assert.Equal(t, "Once", string(msg.Content))
+	msg = <-outputChan
+	assert.Equal(t, "Upon", string(msg.Content))
+
+	// and ingested once read until EOF
+	tailer = scanner.tailers[getScanKey(path, source)]
+	<-tailer.done
+	scanner.scan()
+	assert.Equal(t, 1, len(scanner.ingestedFiles))
+	assert.Equal(t, 0, len(scanner.tailers))
+}
+
 func TestScannerScanWithTooManyFiles(t *testing.T) {
 	var err error
 	var path string
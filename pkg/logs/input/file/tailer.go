@@ -6,6 +6,7 @@
 package file
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -50,6 +51,12 @@ type Tailer struct {
 
 	sleepDuration time.Duration
 
+	// isGzip is true when the file is compressed, it is then read once until EOF.
+	isGzip     bool
+	gzipReader *gzip.Reader
+	// gzipEOF is set once the compressed file has been read until a clean EOF.
+	gzipEOF int32
+
 	closeTimeout  time.Duration
 	shouldStop    int32
 	didFileRotate int32
@@ -102,6 +109,15 @@ func NewDecoderFromSourceWithPattern(source *config.LogSource, multiLinePattern
 		}
 	}
 
+	if source.Config.Parser != nil {
+		patternParser, err := parser.NewPatternParser(source.Config.Parser, lineParser)
+		if err != nil {
+			log.Warnf("Invalid parser for source %s, the log lines will not be parsed: %v", source.Name, err)
+		} else {
+			lineParser = patternParser
+		}
+	}
+
 	return decoder.NewDecoderWithEndLineMatcher(source, lineParser, matcher, multiLinePattern)
 }
 
@@ -120,6 +136,7 @@ func NewTailer(outputChan chan *message.Message, file *File, sleepDuration time.
 
 	return &Tailer{
 		file:           file,
+		isGzip:         file.Source.Config.IngestGzipFiles && isGzipFile(file.Path),
 		outputChan:     outputChan,
 		decoder:        decoder,
 		tagProvider:    tagProvider,
@@ -145,7 +162,12 @@ func (t *Tailer) Identifier() string {
 
 // Start let's the tailer open a file and tail from whence
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	var err error
+	if t.isGzip {
+		err = t.setupGzip(offset)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status.Error(err)
 		return err
@@ -162,19 +184,34 @@ func (t *Tailer) Start(offset int64, whence int) error {
 
 // readForever lets the tailer tail the content of a file
 // until it is closed or the tailer is stopped.
+// After a rotation, the tailer keeps reading the rotated file until EOF before stopping.
 func (t *Tailer) readForever() {
 	defer t.onStop()
+	draining := false
 	for {
-		n, err := t.read()
+		n, err := t.readNext()
 		if err != nil {
 			return
 		}
 		t.recordBytes(int64(n))
 
+		if n == 0 && (draining || t.isGzip) {
+			// the rotated or compressed file has been read until EOF
+			if draining {
+				go t.stopForwardAfterTimeout()
+			}
+			return
+		}
+
 		select {
 		case <-t.stop:
-			if n != 0 && atomic.LoadInt32(&t.didFileRotate) == 1 {
-				log.Warn("Tailer stopped after rotation close timeout with remaining unread data")
+			if atomic.LoadInt32(&t.didFileRotate) == 1 {
+				if n != 0 {
+					log.Infof("Rotated file %s still has unread data after close timeout, reading it until EOF", t.file.Path)
+					draining = true
+					continue
+				}
+				go t.stopForwardAfterTimeout()
 			}
 			// stop reading data from file
 			return
@@ -187,13 +224,21 @@ func (t *Tailer) readForever() {
 	}
 }
 
-// buildTailerTags groups the file tag, directory (if wildcard path) and user tags
+// readNext reads the next chunk of data of the file.
+func (t *Tailer) readNext() (int, error) {
+	if t.isGzip {
+		return t.readGzip()
+	}
+	return t.read()
+}
+
+// buildTailerTags groups the file tag, directory (if wildcard path), path tags and user tags
 func (t *Tailer) buildTailerTags() []string {
 	tags := []string{fmt.Sprintf("filename:%s", filepath.Base(t.file.Path))}
 	if t.file.IsWildcardPath {
 		tags = append(tags, fmt.Sprintf("dirname:%s", filepath.Dir(t.file.Path)))
 	}
-	return tags
+	return append(tags, t.file.Source.Config.PathTags(t.file.Path)...)
 }
 
 // StartFromBeginning lets the tailer start tailing its file
@@ -223,10 +268,21 @@ func (t *Tailer) StopAfterFileRotation() {
 func (t *Tailer) startStopTimer() {
 	stopTimer := time.NewTimer(t.closeTimeout)
 	<-stopTimer.C
-	t.stopForward()
 	t.stop <- struct{}{}
 }
 
+// stopForwardAfterTimeout cancels the forwarding of the remaining messages of a rotated file
+// when they can't be sent before the timeout, for instance because the pipeline is blocked.
+func (t *Tailer) stopForwardAfterTimeout() {
+	stopTimer := time.NewTimer(t.closeTimeout)
+	defer stopTimer.Stop()
+	select {
+	case <-t.done:
+	case <-stopTimer.C:
+		t.stopForward()
+	}
+}
+
 // onStop finishes to stop the tailer
 func (t *Tailer) onStop() {
 	t.osFile.Close()
@@ -261,8 +317,15 @@ func (t *Tailer) forwardMessages() {
 		// after a file rotation when it is stuck on it.
 		// We don't return directly to keep the same shutdown sequence that in the
 		// normal case.
+		msg := message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp)
+		if t.file.Source.Config.Parser != nil && output.Timestamp != "" {
+			// the timestamp extracted by the parser is used as the date of the log.
+			if timestamp, err := time.Parse(time.RFC3339Nano, output.Timestamp); err == nil {
+				msg.Timestamp = timestamp
+			}
+		}
 		select {
-		case t.outputChan <- message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp):
+		case t.outputChan <- msg:
 		case <-t.forwardContext.Done():
 		}
 	}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const gzipExtension = ".gz"
+
+// isGzipFile returns true if the file is compressed with gzip.
+func isGzipFile(path string) bool {
+	return strings.HasSuffix(path, gzipExtension)
+}
+
+// setupGzip sets up the tailer of a compressed file, the offset is
+// the number of uncompressed bytes already read.
+func (t *Tailer) setupGzip(offset int64) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+
+	// adds metadata to enable users to filter logs by filename
+	t.tags = t.buildTailerTags()
+
+	log.Info("Opening compressed file ", t.file.Path, " for tailer key ", t.file.GetScanKey())
+	f, err := openFile(fullpath)
+	if err != nil {
+		return err
+	}
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	// the content can only be read sequentially, skip what has already been read
+	skipped, err := io.CopyN(ioutil.Discard, reader, offset)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return err
+	}
+
+	t.osFile = f
+	t.gzipReader = reader
+	t.readOffset = skipped
+	t.decodedOffset = skipped
+
+	return nil
+}
+
+// readGzip reads the next chunk of uncompressed data, it returns 0 at EOF.
+func (t *Tailer) readGzip() (int, error) {
+	inBuf := make([]byte, 4096)
+	n, err := t.gzipReader.Read(inBuf)
+	if err != nil && err != io.EOF {
+		// the file is likely to be corrupted or truncated
+		t.file.Source.Status.Error(err)
+		return 0, log.Errorf("Unexpected error occurred while reading compressed file %s: %v", t.file.Path, err)
+	}
+	if n == 0 {
+		if err == io.EOF {
+			atomic.StoreInt32(&t.gzipEOF, 1)
+		}
+		return 0, nil
+	}
+	t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+	t.incrementReadOffset(n)
+	return n, nil
+}
+
+// isGzipFileRead returns true if the number of uncompressed bytes read from the file, as registered
+// by the auditor, matches the uncompressed size stored in the trailer of the file.
+func isGzipFileRead(path string, offset int64) bool {
+	if offset <= 0 {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	// the last 4 bytes are the uncompressed size of the last member modulo 2^32,
+	// files with several members are read again to know if they are complete.
+	var trailer [4]byte
+	if _, err := f.Seek(-int64(len(trailer)), io.SeekEnd); err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(f, trailer[:]); err != nil {
+		return false
+	}
+	return uint32(offset) == binary.LittleEndian.Uint32(trailer[:])
+}
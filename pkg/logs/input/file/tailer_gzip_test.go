@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGzipFileRead(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-tailer-gzip-test-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := filepath.Join(testDir, "app.log.gz")
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte("hello world\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, file.Close())
+
+	assert.False(t, isGzipFileRead(path, 0))
+	assert.False(t, isGzipFileRead(path, 5))
+	assert.True(t, isGzipFileRead(path, 12))
+	assert.False(t, isGzipFileRead(filepath.Join(testDir, "missing.gz"), 12))
+}
+
+func TestIsGzipFile(t *testing.T) {
+	assert.True(t, isGzipFile("/var/log/app.log.1.gz"))
+	assert.False(t, isGzipFile("/var/log/app.log"))
+	assert.False(t, isGzipFile("/var/log/app.gz.log"))
+}
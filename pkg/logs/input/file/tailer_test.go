@@ -14,6 +14,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -94,6 +95,35 @@ func (suite *TailerTestSuite) TestStopAfterFileRotationWhenStuck() {
 	}
 }
 
+func (suite *TailerTestSuite) TestReadRotatedFileUntilEOF() {
+	// the file is larger than what the tailer can buffer while the output channel
+	// is not consumed, it can't be read entirely before the close timeout
+	lines := 500
+	padding := strings.Repeat("a", 100)
+	for i := 0; i < lines; i++ {
+		_, err := suite.testFile.WriteString(fmt.Sprintf("line %d %s\n", i, padding))
+		suite.Nil(err)
+	}
+	suite.tailer.closeTimeout = 200 * time.Millisecond
+	suite.Nil(suite.tailer.StartFromBeginning())
+	suite.tailer.StopAfterFileRotation()
+	time.Sleep(2 * suite.tailer.closeTimeout)
+
+	for i := 0; i < lines; i++ {
+		select {
+		case msg := <-suite.tailer.outputChan:
+			suite.Equal(fmt.Sprintf("line %d %s", i, padding), string(msg.Content))
+		case <-time.After(10 * time.Second):
+			suite.FailNow("timeout")
+		}
+	}
+	select {
+	case <-suite.tailer.done:
+	case <-time.After(10 * time.Second):
+		suite.Fail("timeout")
+	}
+}
+
 func (suite *TailerTestSuite) TestTialerTimeDurationConfig() {
 	// To satisfy the suite level tailer
 	suite.tailer.StartFromBeginning()
@@ -295,6 +325,24 @@ func (suite *TailerTestSuite) TestBuildTagsFileDir() {
 	suite.Equal("dirname:"+filepath.Dir(suite.testFile.Name()), tags[1])
 }
 
+func (suite *TailerTestSuite) TestBuildTagsPathTags() {
+	pathTaggedSource := config.NewLogSource("", &config.LogsConfig{
+		Type:            config.FileType,
+		Path:            suite.testPath,
+		PathTagsPattern: `/(?P<file>[^/]+)\.log$`,
+	})
+	suite.Nil(pathTaggedSource.Config.Validate())
+	sleepDuration := 10 * time.Millisecond
+	suite.tailer = NewTailer(suite.outputChan, NewFile(suite.testPath, pathTaggedSource, false), sleepDuration, NewDecoderFromSource(suite.source))
+	suite.tailer.StartFromBeginning()
+
+	tags := suite.tailer.buildTailerTags()
+	suite.Equal([]string{
+		"filename:" + filepath.Base(suite.testFile.Name()),
+		"file:tailer",
+	}, tags)
+}
+
 func (suite *TailerTestSuite) TestMutliLineAutoDetect() {
 	lines := "Jul 12, 2021 12:55:15 PM test message 1\n"
 	lines += "Jul 12, 2021 12:55:15 PM test message 2\n"
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// maxFrameSize represents the max size for a syslog message,
+// the remaining of a longer message is dropped.
+const maxFrameSize = 256 * 1000
+
+// maxFrameLenDigits is the max number of digits of an octet count.
+const maxFrameLenDigits = 10
+
+// frameReader splits a TCP stream into syslog messages, as described in RFC 6587.
+// Both framing methods are supported and detected per message:
+// - octet counting, 'MSG-LEN SP SYSLOG-MSG', when the frame starts with a digit,
+// - non-transparent framing, messages terminated by a line feed, otherwise.
+type frameReader struct {
+	reader       *bufio.Reader
+	maxFrameSize int
+}
+
+// newFrameReader returns a new frameReader.
+func newFrameReader(reader io.Reader, maxFrameSize int) *frameReader {
+	return &frameReader{
+		reader:       bufio.NewReader(reader),
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+// next returns the next syslog message of the stream.
+func (r *frameReader) next() ([]byte, error) {
+	first, err := r.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		return r.nextOctetCounted()
+	}
+	return r.nextLine()
+}
+
+// nextOctetCounted reads a frame prefixed with its length.
+func (r *frameReader) nextOctetCounted() ([]byte, error) {
+	header, err := r.reader.ReadSlice(' ')
+	if err == bufio.ErrBufferFull || len(header) > maxFrameLenDigits+1 {
+		return nil, fmt.Errorf("invalid octet count")
+	}
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(string(header[:len(header)-1]))
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("invalid octet count: %q", header)
+	}
+	size := length
+	if size > r.maxFrameSize {
+		size = r.maxFrameSize
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, frame); err != nil {
+		return nil, err
+	}
+	if length > size {
+		if _, err := io.CopyN(ioutil.Discard, r.reader, int64(length-size)); err != nil {
+			return nil, err
+		}
+	}
+	return frame, nil
+}
+
+// nextLine reads a frame terminated by a line feed.
+func (r *frameReader) nextLine() ([]byte, error) {
+	var frame []byte
+	for {
+		line, err := r.reader.ReadSlice('\n')
+		if remaining := r.maxFrameSize - len(frame); remaining > 0 {
+			if len(line) > remaining {
+				frame = append(frame, line[:remaining]...)
+			} else {
+				frame = append(frame, line...)
+			}
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(frame) > 0:
+			// the stream was closed after an unterminated message.
+			return frame, nil
+		case err != nil:
+			return nil, err
+		}
+		return frame, nil
+	}
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameReaderWithNonTransparentFraming(t *testing.T) {
+	reader := newFrameReader(strings.NewReader("<13>foo\n<13>bar\r\n<13>baz"), maxFrameSize)
+
+	frame, err := reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>foo\n", string(frame))
+
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>bar\r\n", string(frame))
+
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>baz", string(frame))
+
+	_, err = reader.next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFrameReaderWithOctetCounting(t *testing.T) {
+	reader := newFrameReader(strings.NewReader("11 <13>foo\nbar7 <13>baz<13>qux\n"), maxFrameSize)
+
+	frame, err := reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>foo\nbar", string(frame))
+
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>baz", string(frame))
+
+	// framing methods can be mixed on the same stream.
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>qux\n", string(frame))
+}
+
+func TestFrameReaderShouldTruncateLongMessages(t *testing.T) {
+	reader := newFrameReader(strings.NewReader("10 <13>abcdef"+strings.Repeat("a", 5000)+"\n<13>foo\n"), 8)
+
+	frame, err := reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>abcd", string(frame))
+
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "aaaaaaaa", string(frame))
+
+	frame, err = reader.next()
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>foo\n", string(frame))
+}
+
+func TestFrameReaderShouldFailWithInvalidOctetCount(t *testing.T) {
+	reader := newFrameReader(strings.NewReader("12345678901234 <13>foo"), maxFrameSize)
+	_, err := reader.next()
+	assert.NotNil(t, err)
+
+	reader = newFrameReader(strings.NewReader("12a <13>foo"), maxFrameSize)
+	_, err = reader.next()
+	assert.NotNil(t, err)
+
+	reader = newFrameReader(strings.NewReader("20 <13>foo"), maxFrameSize)
+	_, err = reader.next()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog collects syslog messages, formatted according to RFC 5424 or RFC 3164,
+// received over TCP or UDP.
+package syslog
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// Launcher starts a syslog listener for every syslog source,
+// listening on TCP unless the source protocol is UDP.
+type Launcher struct {
+	pipelineProvider pipeline.Provider
+	frameSize        int
+	sources          chan *config.LogSource
+	listeners        []restart.Restartable
+	stop             chan struct{}
+}
+
+// NewLauncher returns an initialized Launcher
+func NewLauncher(sources *config.LogSources, frameSize int, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		pipelineProvider: pipelineProvider,
+		frameSize:        frameSize,
+		sources:          sources.GetAddedForType(config.SyslogType),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// run starts new syslog listeners.
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.sources:
+			var listener restart.Restartable
+			if source.Config.Protocol == config.UDPType {
+				udpListener := NewUDPListener(l.pipelineProvider, source, l.frameSize)
+				udpListener.Start()
+				listener = udpListener
+			} else {
+				tcpListener := NewTCPListener(l.pipelineProvider, source)
+				tcpListener.Start()
+				listener = tcpListener
+			}
+			l.listeners = append(l.listeners, listener)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop stops all listeners
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for _, l := range l.listeners {
+		stopper.Add(l)
+	}
+	stopper.Stop()
+}
+
+// isClosedConnError returns true if the error is related to a closed connection,
+// for more details, see: https://golang.org/src/internal/poll/fd.go#L18.
+func isClosedConnError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// nilValue represents an empty header field in RFC 5424.
+const nilValue = "-"
+
+// maxPriority is the highest valid PRI value, facility 23 with severity 7.
+const maxPriority = 191
+
+// utf8BOM may prefix the MSG part of an RFC 5424 message.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var (
+	errNoPriority      = errors.New("message does not start with a priority")
+	errInvalidPriority = errors.New("invalid priority")
+	errMissingHeader   = errors.New("message header is truncated")
+	errInvalidSD       = errors.New("invalid structured data")
+)
+
+// Message represents a syslog message split into its header fields.
+// Fields missing from the original message are left empty.
+type Message struct {
+	Facility       int
+	Severity       int
+	Version        int
+	Timestamp      string
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Msg            []byte
+}
+
+// Status returns the status matching the severity of the message.
+func (m *Message) Status() string {
+	return message.SeverityToStatus(m.Severity)
+}
+
+// Time returns the timestamp of the message when it carries a full RFC 3339 timestamp,
+// returns the zero time otherwise.
+func (m *Message) Time() time.Time {
+	if m.Timestamp == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// Parse parses a single syslog message formatted according to either RFC 5424
+// or RFC 3164, the format is detected from the version field that follows the priority.
+func Parse(data []byte) (*Message, error) {
+	pri, rest, err := parsePriority(data)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{
+		Facility: pri / 8,
+		Severity: pri % 8,
+	}
+	if version, remaining, ok := parseVersion(rest); ok {
+		msg.Version = version
+		err = parseRFC5424(msg, remaining)
+	} else {
+		parseRFC3164(msg, rest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parsePriority parses the '<PRI>' part that starts every syslog message.
+func parsePriority(data []byte) (int, []byte, error) {
+	if len(data) == 0 || data[0] != '<' {
+		return 0, nil, errNoPriority
+	}
+	end := bytes.IndexByte(data, '>')
+	// PRI is made of 1 to 3 digits.
+	if end < 2 || end > 4 {
+		return 0, nil, errInvalidPriority
+	}
+	pri, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || pri < 0 || pri > maxPriority {
+		return 0, nil, errInvalidPriority
+	}
+	return pri, data[end+1:], nil
+}
+
+// parseVersion parses the version field of a RFC 5424 header,
+// returns false if the message does not have one.
+func parseVersion(data []byte) (int, []byte, bool) {
+	end := bytes.IndexByte(data, ' ')
+	// VERSION is a non-zero digit followed by at most 2 digits.
+	if end < 1 || end > 3 || data[0] < '1' || data[0] > '9' {
+		return 0, nil, false
+	}
+	version, err := strconv.Atoi(string(data[:end]))
+	if err != nil {
+		return 0, nil, false
+	}
+	return version, data[end+1:], true
+}
+
+// parseRFC5424 parses the remaining of a RFC 5424 message,
+// 'TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]'.
+func parseRFC5424(msg *Message, data []byte) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		end := bytes.IndexByte(data, ' ')
+		if end < 0 {
+			return errMissingHeader
+		}
+		if field := string(data[:end]); field != nilValue {
+			fields[i] = field
+		}
+		data = data[end+1:]
+	}
+	msg.Timestamp, msg.Hostname, msg.AppName, msg.ProcID, msg.MsgID = fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	sd, rest, err := parseStructuredData(data)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = sd
+	if len(rest) > 0 && rest[0] == ' ' {
+		rest = rest[1:]
+	}
+	msg.Msg = bytes.TrimPrefix(rest, utf8BOM)
+	return nil
+}
+
+// parseStructuredData parses the STRUCTURED-DATA part of a RFC 5424 message,
+// '[SD-ID PARAM-NAME="PARAM-VALUE" ...][...]' or '-'.
+func parseStructuredData(data []byte) (map[string]map[string]string, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, errMissingHeader
+	}
+	if data[0] == '-' {
+		return nil, data[1:], nil
+	}
+	sd := make(map[string]map[string]string)
+	for len(data) > 0 && data[0] == '[' {
+		data = data[1:]
+		end := bytes.IndexAny(data, " ]")
+		if end < 1 {
+			return nil, nil, errInvalidSD
+		}
+		params := make(map[string]string)
+		sd[string(data[:end])] = params
+		data = data[end:]
+		for len(data) > 0 && data[0] == ' ' {
+			data = data[1:]
+			eq := bytes.IndexByte(data, '=')
+			if eq < 1 || eq+1 >= len(data) || data[eq+1] != '"' {
+				return nil, nil, errInvalidSD
+			}
+			name := string(data[:eq])
+			value, rest, err := parseParamValue(data[eq+2:])
+			if err != nil {
+				return nil, nil, err
+			}
+			params[name] = value
+			data = rest
+		}
+		if len(data) == 0 || data[0] != ']' {
+			return nil, nil, errInvalidSD
+		}
+		data = data[1:]
+	}
+	return sd, data, nil
+}
+
+// parseParamValue parses a quoted PARAM-VALUE, starting after the opening quote,
+// where '"', '\' and ']' are escaped with a backslash.
+func parseParamValue(data []byte) (string, []byte, error) {
+	var value []byte
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			if i+1 < len(data) && (data[i+1] == '"' || data[i+1] == '\\' || data[i+1] == ']') {
+				i++
+			}
+			value = append(value, data[i])
+		case '"':
+			return string(value), data[i+1:], nil
+		default:
+			value = append(value, data[i])
+		}
+	}
+	return "", nil, errInvalidSD
+}
+
+// rfc3164TimestampLen is the length of a 'Mmm dd hh:mm:ss' timestamp.
+const rfc3164TimestampLen = len(time.Stamp)
+
+// parseRFC3164 parses the remaining of a RFC 3164 message, 'TIMESTAMP HOSTNAME TAG[PID]: MSG'.
+// RFC 3164 only documents observed formats, so fields are parsed on a best effort basis
+// and any content that does not fit a header is kept as the message.
+func parseRFC3164(msg *Message, data []byte) {
+	if len(data) > rfc3164TimestampLen && data[rfc3164TimestampLen] == ' ' {
+		if _, err := time.Parse(time.Stamp, string(data[:rfc3164TimestampLen])); err == nil {
+			msg.Timestamp = string(data[:rfc3164TimestampLen])
+			data = data[rfc3164TimestampLen+1:]
+		}
+	}
+	if msg.Timestamp == "" {
+		// some senders use a RFC 3339 timestamp instead of the BSD one.
+		if end := bytes.IndexByte(data, ' '); end > 0 {
+			if _, err := time.Parse(time.RFC3339Nano, string(data[:end])); err == nil {
+				msg.Timestamp = string(data[:end])
+				data = data[end+1:]
+			}
+		}
+	}
+	if msg.Timestamp != "" {
+		if end := bytes.IndexByte(data, ' '); end > 0 && !isTag(data[:end]) {
+			msg.Hostname = string(data[:end])
+			data = data[end+1:]
+		}
+	}
+	if end := bytes.IndexAny(data, "[: "); end > 0 && isTag(data[:end+1]) {
+		msg.AppName = string(data[:end])
+		data = data[end:]
+		if data[0] == '[' {
+			if pidEnd := bytes.IndexByte(data, ']'); pidEnd > 0 {
+				msg.ProcID = string(data[1:pidEnd])
+				data = data[pidEnd+1:]
+			}
+		}
+		data = bytes.TrimPrefix(data, []byte(":"))
+		data = bytes.TrimPrefix(data, []byte(" "))
+	}
+	msg.Msg = data
+}
+
+// isTag returns true if the token ends like a RFC 3164 TAG, 'name:' or 'name[pid]:'.
+func isTag(token []byte) bool {
+	if len(token) == 0 {
+		return false
+	}
+	last := token[len(token)-1]
+	return last == ':' || last == '['
+}
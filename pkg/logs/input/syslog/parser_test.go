@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	msg, err := Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high"] ` + "\xEF\xBB\xBF" + `An application event`))
+	assert.Nil(t, err)
+	assert.Equal(t, 20, msg.Facility)
+	assert.Equal(t, 5, msg.Severity)
+	assert.Equal(t, message.StatusNotice, msg.Status())
+	assert.Equal(t, 1, msg.Version)
+	assert.Equal(t, "2003-10-11T22:14:15.003Z", msg.Timestamp)
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), msg.Time())
+	assert.Equal(t, "mymachine.example.com", msg.Hostname)
+	assert.Equal(t, "evntslog", msg.AppName)
+	assert.Equal(t, "1234", msg.ProcID)
+	assert.Equal(t, "ID47", msg.MsgID)
+	assert.Equal(t, map[string]map[string]string{
+		"exampleSDID@32473":     {"iut": "3", "eventSource": "Application"},
+		"examplePriority@32473": {"class": "high"},
+	}, msg.StructuredData)
+	assert.Equal(t, "An application event", string(msg.Msg))
+}
+
+func TestParseRFC5424WithNilValues(t *testing.T) {
+	msg, err := Parse([]byte(`<34>1 - - - - - -`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusCritical, msg.Status())
+	assert.Equal(t, "", msg.Timestamp)
+	assert.True(t, msg.Time().IsZero())
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "", msg.AppName)
+	assert.Nil(t, msg.StructuredData)
+	assert.Equal(t, "", string(msg.Msg))
+}
+
+func TestParseRFC5424WithEscapedStructuredData(t *testing.T) {
+	msg, err := Parse([]byte(`<14>1 2021-01-01T00:00:00+01:00 host app - - [meta key="a \"quoted\" \] value\\"] hello`))
+	assert.Nil(t, err)
+	assert.Equal(t, `a "quoted" ] value\`, msg.StructuredData["meta"]["key"])
+	assert.Equal(t, "hello", string(msg.Msg))
+	assert.Equal(t, time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC), msg.Time())
+}
+
+func TestParseRFC5424ShouldFailWithInvalidHeaders(t *testing.T) {
+	for _, data := range []string{
+		`<14>1 2021-01-01T00:00:00Z host`,
+		`<14>1 2021-01-01T00:00:00Z host app - - [meta key="value] hello`,
+		`<14>1 2021-01-01T00:00:00Z host app - - [meta key=value] hello`,
+		`<14>1 2021-01-01T00:00:00Z host app - - [meta key="value" hello`,
+	} {
+		_, err := Parse([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	msg, err := Parse([]byte(`<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, msg.Facility)
+	assert.Equal(t, 2, msg.Severity)
+	assert.Equal(t, 0, msg.Version)
+	assert.Equal(t, "Oct 11 22:14:15", msg.Timestamp)
+	assert.Equal(t, "mymachine", msg.Hostname)
+	assert.Equal(t, "su", msg.AppName)
+	assert.Equal(t, "", msg.ProcID)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", string(msg.Msg))
+}
+
+func TestParseRFC3164WithProcID(t *testing.T) {
+	msg, err := Parse([]byte(`<86>Feb  5 17:32:18 10.0.0.99 sshd[1234]: Accepted publickey`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusInfo, msg.Status())
+	assert.Equal(t, "Feb  5 17:32:18", msg.Timestamp)
+	assert.Equal(t, "10.0.0.99", msg.Hostname)
+	assert.Equal(t, "sshd", msg.AppName)
+	assert.Equal(t, "1234", msg.ProcID)
+	assert.Equal(t, "Accepted publickey", string(msg.Msg))
+}
+
+func TestParseRFC3164WithoutHostname(t *testing.T) {
+	msg, err := Parse([]byte(`<15>Feb  5 17:32:18 cron[42]: job started`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusDebug, msg.Status())
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "cron", msg.AppName)
+	assert.Equal(t, "42", msg.ProcID)
+	assert.Equal(t, "job started", string(msg.Msg))
+}
+
+func TestParseRFC3164WithRFC3339Timestamp(t *testing.T) {
+	msg, err := Parse([]byte(`<13>2021-06-01T10:00:00.123456+00:00 host app: hello`))
+	assert.Nil(t, err)
+	assert.Equal(t, "host", msg.Hostname)
+	assert.Equal(t, "app", msg.AppName)
+	assert.Equal(t, "hello", string(msg.Msg))
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 0, 0, 123456000, time.UTC), msg.Time())
+}
+
+func TestParseRFC3164WithoutHeader(t *testing.T) {
+	msg, err := Parse([]byte(`<13>hello world`))
+	assert.Nil(t, err)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "", msg.AppName)
+	assert.Equal(t, "hello world", string(msg.Msg))
+}
+
+func TestParseShouldFailWithInvalidPriority(t *testing.T) {
+	for _, data := range []string{
+		``,
+		`hello world`,
+		`<>1 - - - - - -`,
+		`<192>1 - - - - - -`,
+		`<1234>1 - - - - - -`,
+		`<ab>1 - - - - - -`,
+	} {
+		_, err := Parse([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// syslogIntegration represents the name of the integration,
+// it's used as the default source of the messages.
+const syslogIntegration = "syslog"
+
+// Tailer reads syslog messages from a connection.
+type Tailer struct {
+	source     *config.LogSource
+	conn       net.Conn
+	outputChan chan *message.Message
+	read       func(*Tailer) ([]byte, error)
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewTailer returns a new Tailer, read must return exactly one syslog message per call.
+func NewTailer(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error)) *Tailer {
+	return &Tailer{
+		source:     source,
+		conn:       conn,
+		outputChan: outputChan,
+		read:       read,
+		stop:       make(chan struct{}, 1),
+		done:       make(chan struct{}, 1),
+	}
+}
+
+// Start starts reading messages from the connection.
+func (t *Tailer) Start() {
+	go t.readForever()
+}
+
+// Stop stops the tailer and waits for the last message to be forwarded.
+func (t *Tailer) Stop() {
+	t.stop <- struct{}{}
+	t.conn.Close()
+	<-t.done
+}
+
+// readForever reads the data from conn.
+func (t *Tailer) readForever() {
+	defer func() {
+		t.conn.Close()
+		t.done <- struct{}{}
+	}()
+	for {
+		select {
+		case <-t.stop:
+			// stop reading data from the connection
+			return
+		default:
+			frame, err := t.read(t)
+			if err != nil && err == io.EOF {
+				// connection has been closed client-side, stop from reading new data
+				return
+			}
+			if err != nil {
+				// an error occurred, stop from reading new data
+				log.Warnf("Couldn't read message from connection: %v", err)
+				return
+			}
+			t.source.BytesRead.Add(int64(len(frame)))
+			frame = bytes.TrimRight(frame, "\r\n")
+			if len(frame) == 0 {
+				continue
+			}
+			t.outputChan <- t.toMessage(frame)
+		}
+	}
+}
+
+// toMessage transforms a syslog frame into a message,
+// frames that can not be parsed are forwarded as is.
+// ex:
+// * frame:
+//  <165>1 2003-10-11T22:14:15.003Z mymachine evntslog 1234 ID47 [exampleSDID@32473 iut="3"] foo
+// * message-content:
+//  {
+//    "message": "foo",
+//    "syslog": {
+//      "hostname": "mymachine",
+//      "appname": "evntslog",
+//      "procid": "1234",
+//      ...
+//      "structured_data": {
+//        "exampleSDID@32473": {"iut": "3"}
+//      }
+//    }
+//  }
+func (t *Tailer) toMessage(frame []byte) *message.Message {
+	origin := message.NewOrigin(t.source)
+	origin.SetSource(syslogIntegration)
+
+	msg, err := Parse(frame)
+	if err != nil {
+		log.Debugf("Couldn't parse syslog message: %v", err)
+		return message.NewMessage(frame, origin, message.StatusInfo, time.Now().UnixNano())
+	}
+
+	content, err := json.Marshal(t.getPayload(msg))
+	if err != nil {
+		// ensure the message has some content if the json encoding failed
+		content = msg.Msg
+	}
+	if msg.AppName != "" {
+		origin.SetService(msg.AppName)
+	}
+	m := message.NewMessage(content, origin, msg.Status(), time.Now().UnixNano())
+	m.Timestamp = msg.Time()
+	return m
+}
+
+// getPayload returns the attributes of the message, empty header fields are omitted.
+func (t *Tailer) getPayload(msg *Message) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"facility": msg.Facility,
+		"severity": msg.Severity,
+	}
+	for key, value := range map[string]string{
+		"timestamp": msg.Timestamp,
+		"hostname":  msg.Hostname,
+		"appname":   msg.AppName,
+		"procid":    msg.ProcID,
+		"msgid":     msg.MsgID,
+	} {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+	if msg.Version > 0 {
+		attributes["version"] = msg.Version
+	}
+	if len(msg.StructuredData) > 0 {
+		attributes["structured_data"] = msg.StructuredData
+	}
+	return map[string]interface{}{
+		"message": string(msg.Msg),
+		"syslog":  attributes,
+	}
+}
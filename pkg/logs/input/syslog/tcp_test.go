@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+// use a randomly assigned port
+var tcpTestPort = 0
+
+func TestTCPShouldReceiveMessages(t *testing.T) {
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	listener := NewTCPListener(pp, config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Port: tcpTestPort}))
+	listener.Start()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()))
+	assert.Nil(t, err)
+
+	var msg *message.Message
+
+	fmt.Fprintf(conn, "<11>1 2021-01-01T00:00:00Z host app 42 - [meta key=\"value\"] hello world\n")
+	msg = <-msgChan
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, "app", msg.Origin.Service())
+	assert.Equal(t, "syslog", msg.Origin.Source())
+
+	var content map[string]interface{}
+	assert.Nil(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "hello world", content["message"])
+	attributes := content["syslog"].(map[string]interface{})
+	assert.Equal(t, "host", attributes["hostname"])
+	assert.Equal(t, "app", attributes["appname"])
+	assert.Equal(t, "42", attributes["procid"])
+	assert.Equal(t, map[string]interface{}{"meta": map[string]interface{}{"key": "value"}}, attributes["structured_data"])
+	assert.NotContains(t, attributes, "msgid")
+
+	fmt.Fprintf(conn, "25 <13>Feb  5 17:32:18 a b\nc")
+	msg = <-msgChan
+	assert.Nil(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "b\nc", content["message"])
+
+	// unparsable messages are forwarded as is
+	fmt.Fprintf(conn, "hello world\n")
+	msg = <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+
+	assert.Equal(t, 1, len(listener.tailers))
+	listener.Stop()
+}
+
+func TestTCPStopWhileReading(t *testing.T) {
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	listener := NewTCPListener(pp, config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Port: tcpTestPort}))
+	listener.Start()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()))
+	assert.Nil(t, err)
+	defer conn.Close()
+	go func() {
+		for {
+			if _, err := fmt.Fprintf(conn, "<13>Feb  5 17:32:18 host app: hello world\n"); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for range msgChan {
+		}
+	}()
+	<-time.After(10 * time.Millisecond)
+
+	// the tailers keep reading the connection while the listener is stopped
+	stopped := make(chan struct{})
+	go func() {
+		listener.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the listener did not stop")
+	}
+}
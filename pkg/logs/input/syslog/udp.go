@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+)
+
+// A UDPListener opens a new UDP connection, keeps it alive and delegates the read operations to a tailer.
+// As described in RFC 5426, each datagram contains exactly one syslog message,
+// messages bigger than the read buffer are truncated.
+type UDPListener struct {
+	pipelineProvider pipeline.Provider
+	source           *config.LogSource
+	frameSize        int
+	tailer           *Tailer
+}
+
+// NewUDPListener returns an initialized UDPListener
+func NewUDPListener(pipelineProvider pipeline.Provider, source *config.LogSource, frameSize int) *UDPListener {
+	return &UDPListener{
+		pipelineProvider: pipelineProvider,
+		source:           source,
+		frameSize:        frameSize,
+	}
+}
+
+// Start opens a new UDP connection and starts a tailer.
+func (l *UDPListener) Start() {
+	log.Infof("Starting syslog UDP forwarder on port: %d, with read buffer size: %d", l.source.Config.Port, l.frameSize)
+	err := l.startNewTailer()
+	if err != nil {
+		log.Errorf("Can't start syslog UDP forwarder on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	l.source.Status.Success()
+}
+
+// Stop stops the tailer.
+func (l *UDPListener) Stop() {
+	log.Infof("Stopping syslog UDP forwarder on port: %d", l.source.Config.Port)
+	if l.tailer != nil {
+		l.tailer.Stop()
+	}
+}
+
+// startNewTailer starts a new Tailer
+func (l *UDPListener) startNewTailer() error {
+	udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", l.source.Config.Port))
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	l.tailer = NewTailer(l.source, conn, l.pipelineProvider.NextPipelineChan(), l.read)
+	l.tailer.Start()
+	return nil
+}
+
+// read reads a datagram from the tailer connection, returns an error if it failed and reset the tailer.
+func (l *UDPListener) read(tailer *Tailer) ([]byte, error) {
+	frame := make([]byte, l.frameSize)
+	n, err := tailer.conn.Read(frame)
+	switch {
+	case err != nil && isClosedConnError(err):
+		return nil, err
+	case err != nil:
+		go l.resetTailer()
+		return nil, err
+	default:
+		return frame[:n], nil
+	}
+}
+
+// resetTailer creates a new tailer.
+func (l *UDPListener) resetTailer() {
+	log.Infof("Resetting the syslog UDP connection on port: %d", l.source.Config.Port)
+	l.tailer.Stop()
+	err := l.startNewTailer()
+	if err != nil {
+		log.Errorf("Could not reset the syslog UDP connection on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	l.source.Status.Success()
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+// use a randomly assigned port
+var udpTestPort = 0
+
+func TestUDPShouldReceiveMessages(t *testing.T) {
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	listener := NewUDPListener(pp, config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Port: udpTestPort, Protocol: config.UDPType}), 9000)
+	listener.Start()
+
+	conn, err := net.Dial("udp", fmt.Sprintf("%s", listener.tailer.conn.LocalAddr()))
+	assert.Nil(t, err)
+
+	fmt.Fprintf(conn, "<12>Oct 11 22:14:15 host app[7]: hello world")
+	msg := <-msgChan
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, "app", msg.Origin.Service())
+
+	var content map[string]interface{}
+	assert.Nil(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "hello world", content["message"])
+	attributes := content["syslog"].(map[string]interface{})
+	assert.Equal(t, "host", attributes["hostname"])
+	assert.Equal(t, "7", attributes["procid"])
+
+	listener.Stop()
+}
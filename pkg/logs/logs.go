@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -50,13 +51,14 @@ var (
 // instead of directly using it.
 // The parameter serverless indicates whether or not this Logs Agent is running
 // in a serverless environment.
-func Start(getAC func() *autodiscovery.AutoConfig) error {
-	return start(getAC, false, nil, nil)
+// demux receives the metrics generated from logs.
+func Start(getAC func() *autodiscovery.AutoConfig, demux aggregator.Demultiplexer) error {
+	return start(getAC, false, nil, nil, demux)
 }
 
 // StartServerless starts a Serverless instance of the Logs Agent.
 func StartServerless(getAC func() *autodiscovery.AutoConfig, logsChan chan *config.ChannelMessage, extraTags []string) error {
-	return start(getAC, true, logsChan, extraTags)
+	return start(getAC, true, logsChan, extraTags, nil)
 }
 
 // buildEndpoints builds endpoints for the logs agent
@@ -71,7 +73,7 @@ func buildEndpoints(serverless bool) (*config.Endpoints, error) {
 	return config.BuildEndpoints(httpConnectivity, intakeTrackType, AgentJSONIntakeProtocol, config.DefaultIntakeOrigin)
 }
 
-func start(getAC func() *autodiscovery.AutoConfig, serverless bool, logsChan chan *config.ChannelMessage, extraTags []string) error {
+func start(getAC func() *autodiscovery.AutoConfig, serverless bool, logsChan chan *config.ChannelMessage, extraTags []string, demux aggregator.Demultiplexer) error {
 	if IsAgentRunning() {
 		return nil
 	}
@@ -112,7 +114,7 @@ func start(getAC func() *autodiscovery.AutoConfig, serverless bool, logsChan cha
 	if !serverless {
 		// regular logs agent
 		log.Info("Starting logs-agent...")
-		agent = NewAgent(sources, services, processingRules, endpoints)
+		agent = NewAgent(sources, services, processingRules, endpoints, demux)
 	} else {
 		// serverless logs agent
 		log.Info("Starting a serverless logs-agent...")
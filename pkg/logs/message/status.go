@@ -5,6 +5,8 @@
 
 package message
 
+import "strings"
+
 // Status values
 const (
 	StatusEmergency = "emergency"
@@ -48,3 +50,58 @@ func StatusToSeverity(status string) []byte {
 	}
 	return SevInfo
 }
+
+// SeverityToStatus transforms a syslog severity number into a status.
+func SeverityToStatus(severity int) string {
+	if severity < 0 || severity >= len(severityStatusMapping) {
+		return StatusInfo
+	}
+	return severityStatusMapping[severity]
+}
+
+// statusPrefixes maps the prefixes of common level names to the statuses,
+// they are checked in order and the first matching prefix wins.
+var statusPrefixes = []struct {
+	prefix string
+	status string
+}{
+	{"emerg", StatusEmergency},
+	{"fatal", StatusEmergency},
+	{"panic", StatusEmergency},
+	{"alert", StatusAlert},
+	{"crit", StatusCritical},
+	{"err", StatusError},
+	{"warn", StatusWarning},
+	{"notice", StatusNotice},
+	{"info", StatusInfo},
+	{"debug", StatusDebug},
+	{"trace", StatusDebug},
+	{"verbose", StatusDebug},
+}
+
+// severityStatusMapping represents the 1:1 mapping between syslog severity numbers and statuses.
+var severityStatusMapping = []string{
+	StatusEmergency,
+	StatusAlert,
+	StatusCritical,
+	StatusError,
+	StatusWarning,
+	StatusNotice,
+	StatusInfo,
+	StatusDebug,
+}
+
+// ParseStatus returns the status matching a level name like "WARNING" or "err",
+// or a syslog severity number, returns false if the value is not recognized.
+func ParseStatus(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) == 1 && value[0] >= '0' && value[0] <= '7' {
+		return severityStatusMapping[value[0]-'0'], true
+	}
+	for _, s := range statusPrefixes {
+		if strings.HasPrefix(value, s.prefix) {
+			return s.status, true
+		}
+	}
+	return "", false
+}
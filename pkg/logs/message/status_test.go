@@ -33,3 +33,13 @@ func TestStatusToSeverity(t *testing.T) {
 	// default value should be "info"
 	assert.Equal(t, 0, bytes.Compare(SevInfo, StatusToSeverity("foo")))
 }
+
+func TestSeverityToStatus(t *testing.T) {
+	assert.Equal(t, StatusEmergency, SeverityToStatus(0))
+	assert.Equal(t, StatusWarning, SeverityToStatus(4))
+	assert.Equal(t, StatusDebug, SeverityToStatus(7))
+
+	// default value should be "info"
+	assert.Equal(t, StatusInfo, SeverityToStatus(-1))
+	assert.Equal(t, StatusInfo, SeverityToStatus(8))
+}
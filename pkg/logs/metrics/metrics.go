@@ -30,6 +30,16 @@ var (
 	// TlmLogsSent is the total number of sent logs.
 	TlmLogsSent = telemetry.NewCounter("logs", "sent",
 		nil, "Total number of sent logs")
+	// LogsSampledOut is the total number of logs dropped by the sampling processing rules.
+	LogsSampledOut = expvar.Int{}
+	// TlmLogsSampledOut is the total number of logs dropped by the sampling processing rules.
+	TlmLogsSampledOut = telemetry.NewCounter("logs", "sampled_out",
+		[]string{"rule_type"}, "Total number of logs dropped by the sampling processing rules")
+	// LogMetricSamples is the total number of metric samples generated from logs.
+	LogMetricSamples = expvar.Int{}
+	// TlmLogMetricSamples is the total number of metric samples generated from logs.
+	TlmLogMetricSamples = telemetry.NewCounter("logs", "metric_samples",
+		nil, "Total number of metric samples generated from logs")
 	// DestinationErrors is the total number of network errors.
 	DestinationErrors = expvar.Int{}
 	// TlmDestinationErrors is the total number of network errors.
@@ -58,6 +68,8 @@ var (
 		nil, "Histogram of http sender latency in ms", []float64{10, 25, 50, 75, 100, 250, 500, 1000, 10000})
 	// DestinationExpVars a map of sender utilization metrics for each http destination
 	DestinationExpVars = expvar.Map{}
+	// DiskSpoolExpVars a map of metrics for the on-disk payload spools of the senders
+	DiskSpoolExpVars = expvar.Map{}
 	// TODO: Add LogsCollected for the total number of collected logs.
 
 )
@@ -67,10 +79,13 @@ func init() {
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
 	LogsExpvars.Set("LogsSent", &LogsSent)
+	LogsExpvars.Set("LogsSampledOut", &LogsSampledOut)
+	LogsExpvars.Set("LogMetricSamples", &LogMetricSamples)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
 	LogsExpvars.Set("BytesSent", &BytesSent)
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
 	LogsExpvars.Set("SenderLatency", &SenderLatency)
 	LogsExpvars.Set("HttpDestinationStats", &DestinationExpVars)
+	LogsExpvars.Set("DiskSpool", &DiskSpoolExpVars)
 }
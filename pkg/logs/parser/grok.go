@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// grokDefinitions are the named patterns that can be referenced with %{NAME} in a grok pattern,
+// they are a subset of the patterns shipped with Logstash.
+var grokDefinitions = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"INT":               `(?:[+-]?(?:[0-9]+))`,
+	"POSINT":            `\b(?:[1-9][0-9]*)\b`,
+	"NONNEGINT":         `\b(?:[0-9]+)\b`,
+	"NUMBER":            `(?:[+-]?(?:(?:[0-9]+(?:\.[0-9]*)?)|(?:\.[0-9]+)))`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}(?:[0-9A-Fa-f]{0,4}|%{IPV4})`,
+	"IP":                `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME":          `\b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*\.?`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"URIPATH":           `(?:/[^\s?#]*)+`,
+	"URIPARAM":          `\?\S*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"MONTHDAY":          `(?:0[1-9]|[12][0-9]|3[01]|[1-9])`,
+	"MONTHNUM":          `(?:0?[1-9]|1[0-2])`,
+	"MONTH":             `\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\b`,
+	"YEAR":              `[0-9]{4}`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `(?:[0-5][0-9])`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}:%{SECOND}`,
+	"ISO8601_TIMEZONE":  `(?:Z|[+-]%{HOUR}(?::?%{MINUTE}))`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"LOGLEVEL":          `(?i:alert|trace|debug|notice|info|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?)`,
+}
+
+// grokReference matches %{NAME}, %{NAME:field} and %{NAME:field:type} references.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::(int|float))?\}`)
+
+// maxGrokDepth limits the nesting of the definitions to detect recursive ones.
+const maxGrokDepth = 16
+
+// grokField is a field captured by a grok pattern.
+type grokField struct {
+	name string
+	// kind is the type the value is converted to, "int", "float" or empty for strings.
+	kind string
+}
+
+// grokPattern is a grok pattern compiled to a regular expression,
+// the capture groups are named after their index in fields.
+type grokPattern struct {
+	regex  *regexp.Regexp
+	fields map[string]grokField
+}
+
+// compileGrok expands the references of a grok pattern and compiles it, the pattern can also
+// use named capture groups "(?P<field>...)". The custom definitions override the built-in ones.
+func compileGrok(pattern string, definitions map[string]string) (*grokPattern, error) {
+	fields := make(map[string]grokField)
+	expanded, err := expandGrok(pattern, definitions, fields, 0)
+	if err != nil {
+		return nil, err
+	}
+	regex, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+	}
+	for _, name := range regex.SubexpNames() {
+		if _, exists := fields[name]; name != "" && !exists {
+			fields[name] = grokField{name: name}
+		}
+	}
+	return &grokPattern{regex: regex, fields: fields}, nil
+}
+
+func expandGrok(pattern string, definitions map[string]string, fields map[string]grokField, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("grok definitions are nested too deeply in %s", pattern)
+	}
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+		parts := grokReference.FindStringSubmatch(reference)
+		definition, exists := definitions[parts[1]]
+		if !exists {
+			definition, exists = grokDefinitions[parts[1]]
+		}
+		if !exists {
+			err = fmt.Errorf("unknown grok pattern %s", parts[1])
+			return ""
+		}
+		var inner string
+		inner, err = expandGrok(definition, definitions, fields, depth+1)
+		if parts[2] == "" {
+			return "(?:" + inner + ")"
+		}
+		// the field names can contain characters that are not allowed in group names.
+		group := fmt.Sprintf("_%d", len(fields))
+		fields[group] = grokField{name: parts[2], kind: parts[3]}
+		return "(?P<" + group + ">" + inner + ")"
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Reserved captures of the pattern parser.
+const (
+	timestampField = "timestamp"
+	statusField    = "status"
+	messageField   = "message"
+)
+
+const httpStatusCodeField = "http.status_code"
+
+// patternFormat is a built-in pattern.
+type patternFormat struct {
+	pattern         string
+	timestampLayout string
+	// statusFromHTTPCode sets the status from the captured http status code.
+	statusFromHTTPCode bool
+}
+
+const (
+	commonLogPattern   = `%{IPORHOST:network.client.ip} %{USER:http.ident} %{USER:http.auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:http.method} %{NOTSPACE:http.url}(?: HTTP/%{NUMBER:http.version})?|%{DATA:http.request})" %{INT:http.status_code:int} (?:%{INT:network.bytes_written:int}|-)`
+	combinedLogPattern = commonLogPattern + ` "%{DATA:http.referer}" "%{DATA:http.useragent}"`
+	commonLogLayout    = "02/Jan/2006:15:04:05 -0700"
+)
+
+// patternFormats are the formats that can be selected by name.
+var patternFormats = map[string]patternFormat{
+	"nginx":           {pattern: combinedLogPattern, timestampLayout: commonLogLayout, statusFromHTTPCode: true},
+	"apache_common":   {pattern: commonLogPattern, timestampLayout: commonLogLayout, statusFromHTTPCode: true},
+	"apache_combined": {pattern: combinedLogPattern, timestampLayout: commonLogLayout, statusFromHTTPCode: true},
+	// postgres logs with the default log_line_prefix '%m [%p] ', optionally followed by '%q%u@%d '.
+	"postgres": {
+		pattern:         `(?P<timestamp>%{YEAR}-%{MONTHNUM}-%{MONTHDAY} %{TIME} [A-Z]+) \[%{POSINT:postgres.pid:int}\] (?:%{USER:usr.name}@%{NOTSPACE:db.instance} )?%{WORD:status}:\s+%{GREEDYDATA:message}`,
+		timestampLayout: "2006-01-02 15:04:05 MST",
+	},
+}
+
+var errNoMatch = errors.New("the log line does not match the pattern of the parser")
+
+// patternParser extracts the metadata and the attributes of the log lines with a grok pattern.
+// The lines are first parsed by an inner parser, for instance to remove the header of
+// container runtimes. Partial lines are not supported and are forwarded as is.
+type patternParser struct {
+	inner              Parser
+	pattern            *grokPattern
+	timestampLayout    string
+	statusFromHTTPCode bool
+}
+
+// NewPatternParser returns a parser applying the configured pattern on the content parsed by inner.
+func NewPatternParser(cfg *config.ParserConfig, inner Parser) (Parser, error) {
+	format := patternFormat{
+		pattern:         cfg.Pattern,
+		timestampLayout: cfg.TimestampLayout,
+	}
+	if cfg.Format != "" {
+		var exists bool
+		if format, exists = patternFormats[cfg.Format]; !exists {
+			return nil, fmt.Errorf("unknown parser format %s", cfg.Format)
+		}
+	}
+	if format.pattern == "" {
+		return nil, errors.New("the parser must have a pattern")
+	}
+	pattern, err := compileGrok(format.pattern, cfg.Definitions)
+	if err != nil {
+		return nil, err
+	}
+	return &patternParser{
+		inner:              inner,
+		pattern:            pattern,
+		timestampLayout:    format.timestampLayout,
+		statusFromHTTPCode: format.statusFromHTTPCode,
+	}, nil
+}
+
+// Parse implements Parser#Parse
+func (p *patternParser) Parse(msg []byte) ([]byte, string, string, bool, error) {
+	content, status, timestamp, partial, err := p.inner.Parse(msg)
+	if err != nil || partial {
+		return content, status, timestamp, partial, err
+	}
+	matches := p.pattern.regex.FindSubmatchIndex(content)
+	if matches == nil {
+		return content, status, timestamp, partial, errNoMatch
+	}
+	var attributes map[string]interface{}
+	messageContent := content
+	for i, group := range p.pattern.regex.SubexpNames() {
+		field, exists := p.pattern.fields[group]
+		if !exists || matches[2*i] < 0 {
+			continue
+		}
+		value := string(content[matches[2*i]:matches[2*i+1]])
+		switch field.name {
+		case messageField:
+			messageContent = content[matches[2*i]:matches[2*i+1]]
+			continue
+		case statusField:
+			if s, ok := message.ParseStatus(value); ok {
+				status = s
+			}
+			continue
+		case timestampField:
+			if t, ok := p.parseTimestamp(value); ok {
+				timestamp = t
+				continue
+			}
+		case httpStatusCodeField:
+			if p.statusFromHTTPCode {
+				status = httpStatus(value)
+			}
+		}
+		if attributes == nil {
+			attributes = make(map[string]interface{})
+		}
+		setAttribute(attributes, field.name, convert(value, field.kind))
+	}
+	if attributes == nil {
+		return messageContent, status, timestamp, partial, nil
+	}
+	attributes[messageField] = string(messageContent)
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return content, status, timestamp, partial, err
+	}
+	return encoded, status, timestamp, partial, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *patternParser) SupportsPartialLine() bool {
+	return p.inner.SupportsPartialLine()
+}
+
+// parseTimestamp converts the captured timestamp to RFC3339.
+func (p *patternParser) parseTimestamp(value string) (string, bool) {
+	layout := p.timestampLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return "", false
+	}
+	return t.UTC().Format(time.RFC3339Nano), true
+}
+
+// httpStatus returns the status of an access log from its http status code.
+func httpStatus(code string) string {
+	switch {
+	case strings.HasPrefix(code, "5"):
+		return message.StatusError
+	case strings.HasPrefix(code, "4"):
+		return message.StatusWarning
+	default:
+		return message.StatusInfo
+	}
+}
+
+// convert converts a captured value to its type, the value is kept as a string when it is invalid.
+func convert(value string, kind string) interface{} {
+	switch kind {
+	case "int":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "float":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// setAttribute sets an attribute, the dots of its name denote nested attributes.
+func setAttribute(attributes map[string]interface{}, name string, value interface{}) {
+	path := strings.Split(name, ".")
+	for _, key := range path[:len(path)-1] {
+		nested, ok := attributes[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			attributes[key] = nested
+		}
+		attributes = nested
+	}
+	attributes[path[len(path)-1]] = value
+}
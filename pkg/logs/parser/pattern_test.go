@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestPatternParserNginx(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Format: "nginx"}, Noop)
+	require.NoError(t, err)
+
+	line := `172.17.0.1 - bob [20/Sep/2018:11:54:11 +0200] "GET /api/v1/users?id=3 HTTP/1.1" 503 612 "-" "curl/7.58.0"`
+	content, status, timestamp, partial, err := p.Parse([]byte(line))
+	require.NoError(t, err)
+	assert.False(t, partial)
+	assert.Equal(t, message.StatusError, status)
+	assert.Equal(t, "2018-09-20T09:54:11Z", timestamp)
+	assert.JSONEq(t, `{
+		"message": "`+`172.17.0.1 - bob [20/Sep/2018:11:54:11 +0200] \"GET /api/v1/users?id=3 HTTP/1.1\" 503 612 \"-\" \"curl/7.58.0\"",
+		"network": {"client": {"ip": "172.17.0.1"}, "bytes_written": 612},
+		"http": {
+			"ident": "-",
+			"auth": "bob",
+			"method": "GET",
+			"url": "/api/v1/users?id=3",
+			"version": "1.1",
+			"status_code": 503,
+			"referer": "-",
+			"useragent": "curl/7.58.0"
+		}
+	}`, string(content))
+}
+
+func TestPatternParserApacheCommon(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Format: "apache_common"}, Noop)
+	require.NoError(t, err)
+
+	content, status, _, _, err := p.Parse([]byte(`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 404 -`))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusWarning, status)
+	assert.Contains(t, string(content), `"status_code":404`)
+	assert.NotContains(t, string(content), "bytes_written")
+}
+
+func TestPatternParserPostgres(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Format: "postgres"}, Noop)
+	require.NoError(t, err)
+
+	content, status, timestamp, _, err := p.Parse([]byte("2021-09-20 11:54:11.753 UTC [123] ERROR:  relation \"foo\" does not exist"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusError, status)
+	assert.Equal(t, "2021-09-20T11:54:11.753Z", timestamp)
+	assert.JSONEq(t, `{"message": "relation \"foo\" does not exist", "postgres": {"pid": 123}}`, string(content))
+
+	content, status, _, _, err = p.Parse([]byte("2021-09-20 11:54:12 UTC [124] alice@orders LOG:  connection authorized"))
+	require.NoError(t, err)
+	assert.Equal(t, "", status)
+	assert.JSONEq(t, `{"message": "connection authorized", "postgres": {"pid": 124}, "usr": {"name": "alice"}, "db": {"instance": "orders"}}`, string(content))
+}
+
+func TestPatternParserCustomPattern(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{
+		Pattern:         `%{MYTIME:timestamp} %{LOGLEVEL:status} \[%{WORD:logger.name}\] took=%{NUMBER:duration:float} %{GREEDYDATA:message}`,
+		Definitions:     map[string]string{"MYTIME": `\d{2}:\d{2}:\d{2} \d{4}-\d{2}-\d{2}`},
+		TimestampLayout: "15:04:05 2006-01-02",
+	}, Noop)
+	require.NoError(t, err)
+
+	content, status, timestamp, _, err := p.Parse([]byte("11:54:11 2021-09-20 WARNING [main] took=1.5 slow request"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusWarning, status)
+	assert.Equal(t, "2021-09-20T11:54:11Z", timestamp)
+	assert.JSONEq(t, `{"message": "slow request", "logger": {"name": "main"}, "duration": 1.5}`, string(content))
+}
+
+func TestPatternParserRegexWithoutAttributes(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Pattern: `^(?P<status>[A-Z]+) (?P<message>.*)$`}, Noop)
+	require.NoError(t, err)
+
+	content, status, timestamp, _, err := p.Parse([]byte("DEBUG hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusDebug, status)
+	assert.Equal(t, "", timestamp)
+	assert.Equal(t, []byte("hello world"), content)
+}
+
+func TestPatternParserKeepsInvalidTimestamp(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Pattern: `^%{NOTSPACE:timestamp} %{GREEDYDATA:message}`}, Noop)
+	require.NoError(t, err)
+
+	content, _, timestamp, _, err := p.Parse([]byte("yesterday hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "", timestamp)
+	assert.JSONEq(t, `{"message": "hello", "timestamp": "yesterday"}`, string(content))
+}
+
+func TestPatternParserNoMatch(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Format: "postgres"}, Noop)
+	require.NoError(t, err)
+
+	content, status, _, _, err := p.Parse([]byte("not a postgres log"))
+	assert.Equal(t, errNoMatch, err)
+	assert.Equal(t, "", status)
+	assert.Equal(t, []byte("not a postgres log"), content)
+}
+
+func TestPatternParserWithKubernetesFormat(t *testing.T) {
+	p, err := NewPatternParser(&config.ParserConfig{Pattern: `^%{LOGLEVEL:status}: %{GREEDYDATA:message}`}, KubernetesFormat)
+	require.NoError(t, err)
+	assert.True(t, p.SupportsPartialLine())
+
+	content, status, timestamp, partial, err := p.Parse([]byte("2018-09-20T11:54:11.753589172Z stdout F ERROR: oops"))
+	require.NoError(t, err)
+	assert.False(t, partial)
+	assert.Equal(t, message.StatusError, status)
+	assert.Equal(t, "2018-09-20T11:54:11.753589172Z", timestamp)
+	assert.Equal(t, []byte("oops"), content)
+
+	// partial lines are forwarded as is
+	content, status, _, partial, err = p.Parse([]byte("2018-09-20T11:54:11.753589172Z stderr P ERROR: oo"))
+	require.NoError(t, err)
+	assert.True(t, partial)
+	assert.Equal(t, message.StatusError, status)
+	assert.Equal(t, []byte("ERROR: oo"), content)
+}
+
+func TestNewPatternParserErrors(t *testing.T) {
+	_, err := NewPatternParser(&config.ParserConfig{Format: "unknown"}, Noop)
+	assert.Error(t, err)
+	_, err = NewPatternParser(&config.ParserConfig{}, Noop)
+	assert.Error(t, err)
+	_, err = NewPatternParser(&config.ParserConfig{Pattern: "%{UNKNOWN:foo}"}, Noop)
+	assert.Error(t, err)
+	_, err = NewPatternParser(&config.ParserConfig{Pattern: "%{LOOP}", Definitions: map[string]string{"LOOP": "%{LOOP}"}}, Noop)
+	assert.Error(t, err)
+	_, err = NewPatternParser(&config.ParserConfig{Pattern: "(unbalanced"}, Noop)
+	assert.Error(t, err)
+}
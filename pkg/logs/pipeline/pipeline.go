@@ -8,9 +8,16 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/file"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/kafka"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
@@ -33,6 +40,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	endpoints *config.Endpoints,
 	destinationsContext *client.DestinationsContext,
 	diagnosticMessageReceiver diagnostic.MessageReceiver,
+	demux aggregator.Demultiplexer,
 	serverless bool,
 	pipelineID int) *Pipeline {
 
@@ -44,7 +52,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	var logsSender *sender.Sender
 
 	strategy := getStrategy(strategyInput, senderInput, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	logsSender = sender.NewSenderWithSpool(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize, getSpool(serverless, pipelineID))
 
 	var encoder processor.Encoder
 	if serverless {
@@ -58,7 +66,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver, demux)
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -94,23 +102,64 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	if endpoints.UseHTTP {
 		for i, endpoint := range endpoints.GetReliableEndpoints() {
 			telemetryName := fmt.Sprintf("logs_%d_reliable_%d", pipelineID, i)
+			if endpoint.Kafka != nil {
+				reliable = append(reliable, kafka.NewDestination(endpoint, destinationsContext, true))
+				continue
+			}
 			reliable = append(reliable, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, true, telemetryName))
 		}
 		for i, endpoint := range endpoints.GetUnReliableEndpoints() {
 			telemetryName := fmt.Sprintf("logs_%d_unreliable_%d", pipelineID, i)
+			if endpoint.Archive != nil {
+				additionals = append(additionals, file.NewDestination(endpoint, pipelineID))
+				continue
+			}
+			if endpoint.Kafka != nil {
+				additionals = append(additionals, kafka.NewDestination(endpoint, destinationsContext, false))
+				continue
+			}
 			additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, false, telemetryName))
 		}
 		return client.NewDestinations(reliable, additionals)
 	}
 	for _, endpoint := range endpoints.GetReliableEndpoints() {
+		if endpoint.Kafka != nil {
+			reliable = append(reliable, kafka.NewDestination(endpoint, destinationsContext, true))
+			continue
+		}
 		reliable = append(reliable, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, true))
 	}
 	for _, endpoint := range endpoints.GetUnReliableEndpoints() {
+		if endpoint.Kafka != nil {
+			additionals = append(additionals, kafka.NewDestination(endpoint, destinationsContext, false))
+			continue
+		}
 		additionals = append(additionals, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, false))
 	}
 	return client.NewDestinations(reliable, additionals)
 }
 
+// getSpool returns the disk spool of the pipeline, or nil when spooling is disabled.
+// The disk space is evenly shared between the pipelines, each one storing its payloads in its own folder.
+func getSpool(serverless bool, pipelineID int) *sender.DiskSpool {
+	maxSizeInBytes := coreConfig.Datadog.GetInt64("logs_config.spool_max_size_in_bytes")
+	if serverless || maxSizeInBytes <= 0 {
+		return nil
+	}
+	storagePath := coreConfig.Datadog.GetString("logs_config.spool_path")
+	if storagePath == "" {
+		storagePath = filepath.Join(coreConfig.Datadog.GetString("logs_config.run_path"), "spool")
+	}
+	storagePath = filepath.Join(storagePath, fmt.Sprintf("pipeline_%d", pipelineID))
+	removalPolicy := coreConfig.Datadog.GetString("logs_config.spool_removal_policy")
+	spool, err := sender.NewDiskSpool(storagePath, maxSizeInBytes/config.NumberOfPipelines, removalPolicy, fmt.Sprintf("logs_%d", pipelineID))
+	if err != nil {
+		log.Errorf("Could not initialize the disk spool of pipeline %d, payloads will only be buffered in memory: %v", pipelineID, err)
+		return nil
+	}
+	return spool
+}
+
 func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
 	if endpoints.UseHTTP || serverless {
 		encoder := sender.IdentityContentType
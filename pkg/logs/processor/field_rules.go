@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// jsonContent lazily decodes the content of a message to apply field processing rules,
+// the content is only re-encoded when a field has been modified.
+type jsonContent struct {
+	raw     []byte
+	object  map[string]interface{}
+	decoded bool
+	dirty   bool
+}
+
+// fields returns the decoded JSON object, or false if the content is not a JSON object.
+func (c *jsonContent) fields() (map[string]interface{}, bool) {
+	if !c.decoded {
+		c.decoded = true
+		c.object = nil
+		decoder := json.NewDecoder(bytes.NewReader(c.raw))
+		// keep the exact representation of numbers.
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err == nil {
+			c.object = object
+		}
+	}
+	return c.object, c.object != nil
+}
+
+// bytes returns the content, including the modifications made on its fields.
+func (c *jsonContent) bytes() []byte {
+	if c.dirty {
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(c.object); err == nil {
+			c.raw = bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+		}
+		c.dirty = false
+	}
+	return c.raw
+}
+
+// set replaces the content, the fields are decoded again on the next field rule.
+func (c *jsonContent) set(raw []byte) {
+	c.raw = raw
+	c.decoded = false
+	c.dirty = false
+}
+
+// applyFieldRule applies a field processing rule on the content of the message,
+// returns false if the message must be dropped.
+// Rules are no-ops on fields that do not exist, except include_at_field_match.
+func applyFieldRule(rule *config.ProcessingRule, content *jsonContent, msg *message.Message) bool {
+	fields, isJSON := content.fields()
+	if !isJSON {
+		return rule.Type != config.IncludeAtFieldMatch
+	}
+	value, exists := getField(fields, rule.FieldPath)
+	switch rule.Type {
+	case config.ExcludeAtFieldMatch:
+		return !exists || !rule.Regex.MatchString(fieldToString(value))
+	case config.IncludeAtFieldMatch:
+		return exists && rule.Regex.MatchString(fieldToString(value))
+	}
+	if !exists {
+		return true
+	}
+	switch rule.Type {
+	case config.MaskField:
+		if rule.Regex != nil {
+			value = rule.Regex.ReplaceAllString(fieldToString(value), rule.ReplacePlaceholder)
+		} else {
+			value = rule.ReplacePlaceholder
+		}
+		setField(fields, rule.FieldPath, value)
+		content.dirty = true
+	case config.RemoveField:
+		removeField(fields, rule.FieldPath)
+		content.dirty = true
+	case config.RenameField:
+		removeField(fields, rule.FieldPath)
+		setField(fields, rule.TargetPath, value)
+		content.dirty = true
+	case config.StatusFromField:
+		if status, ok := message.ParseStatus(fieldToString(value)); ok {
+			msg.SetStatus(status)
+		}
+	case config.ServiceFromField:
+		// the service of the log source, when defined, still has the precedence.
+		if service := fieldToString(value); service != "" {
+			msg.Origin.SetService(service)
+		}
+	}
+	return true
+}
+
+// getField returns the value at path.
+func getField(fields map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = fields
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setField sets the value at path, creating the intermediate objects if needed.
+func setField(fields map[string]interface{}, path []string, value interface{}) {
+	object := fields
+	for _, key := range path[:len(path)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+		object = child
+	}
+	object[path[len(path)-1]] = value
+}
+
+// removeField removes the value at path.
+func removeField(fields map[string]interface{}, path []string) {
+	parent, ok := getField(fields, path[:len(path)-1])
+	if !ok {
+		return
+	}
+	if object, ok := parent.(map[string]interface{}); ok {
+		delete(object, path[len(path)-1])
+	}
+}
+
+// fieldToString returns the string representation of a field value,
+// objects and arrays are represented in JSON.
+func fieldToString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(content)
+	}
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	logsMetrics "github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+var logMetricTypes = map[string]metrics.MetricType{
+	config.LogMetricCount:        metrics.CountType,
+	config.LogMetricGauge:        metrics.GaugeType,
+	config.LogMetricHistogram:    metrics.HistogramType,
+	config.LogMetricDistribution: metrics.DistributionType,
+}
+
+// generateLogMetrics sends the metrics defined on the source of the message to the demultiplexer.
+// It runs before the processing rules so that the logs excluded from the output
+// still generate metrics.
+func (p *Processor) generateLogMetrics(msg *message.Message) {
+	if p.demux == nil || msg.Origin == nil || len(msg.Origin.LogSource.Config.LogMetrics) == 0 {
+		return
+	}
+	content := &jsonContent{raw: msg.Content}
+	var samples []metrics.MetricSample
+	var tags []string
+	var hostname string
+	for _, rule := range msg.Origin.LogSource.Config.LogMetrics {
+		value, ok := logMetricValue(rule, content)
+		if !ok {
+			continue
+		}
+		if samples == nil {
+			tags = msg.Origin.Tags()
+			hostname = msg.GetHostname()
+		}
+		sampleTags := make([]string, 0, len(rule.Tags)+len(tags))
+		sampleTags = append(sampleTags, rule.Tags...)
+		sampleTags = append(sampleTags, tags...)
+		samples = append(samples, metrics.MetricSample{
+			Name:       rule.Name,
+			Value:      value,
+			Mtype:      logMetricTypes[rule.Type],
+			Tags:       sampleTags,
+			Host:       hostname,
+			SampleRate: 1,
+		})
+	}
+	if len(samples) == 0 {
+		return
+	}
+	logsMetrics.LogMetricSamples.Add(int64(len(samples)))
+	logsMetrics.TlmLogMetricSamples.Add(float64(len(samples)))
+	p.demux.AddTimeSamples(samples)
+}
+
+// logMetricValue returns the value of the metric for the content,
+// returns false if the content does not match the rule.
+func logMetricValue(rule *config.LogMetricRule, content *jsonContent) (float64, bool) {
+	value := 1.0
+	if rule.Regex != nil {
+		if rule.ValueGroup == 0 {
+			if !rule.Regex.Match(content.raw) {
+				return 0, false
+			}
+		} else {
+			groups := rule.Regex.FindSubmatch(content.raw)
+			if groups == nil {
+				return 0, false
+			}
+			v, err := strconv.ParseFloat(string(groups[rule.ValueGroup]), 64)
+			if err != nil {
+				return 0, false
+			}
+			value = v
+		}
+	}
+	if len(rule.FieldPath) > 0 {
+		fields, isJSON := content.fields()
+		if !isJSON {
+			return 0, false
+		}
+		field, exists := getField(fields, rule.FieldPath)
+		if !exists {
+			return 0, false
+		}
+		v, ok := toFloat(field)
+		if !ok {
+			return 0, false
+		}
+		value = v
+	}
+	return value, true
+}
+
+// toFloat converts a JSON number or a numeric string to a float.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
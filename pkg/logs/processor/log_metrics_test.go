@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// recordingDemultiplexer records the time samples it receives.
+type recordingDemultiplexer struct {
+	aggregator.Demultiplexer
+	samples []metrics.MetricSample
+}
+
+func (d *recordingDemultiplexer) AddTimeSamples(samples []metrics.MetricSample) {
+	d.samples = append(d.samples, samples...)
+}
+
+func newLogMetricsSource(t *testing.T, rules ...*config.LogMetricRule) *config.LogSource {
+	logsConfig := &config.LogsConfig{Type: config.TCPType, Port: 10514, Tags: []string{"env:prod"}, LogMetrics: rules}
+	require.NoError(t, logsConfig.Validate())
+	return config.NewLogSource("", logsConfig)
+}
+
+func TestLogMetricsCount(t *testing.T) {
+	demux := &recordingDemultiplexer{}
+	p := &Processor{demux: demux}
+	source := newLogMetricsSource(t, &config.LogMetricRule{Name: "http.errors", Type: config.LogMetricCount, Pattern: `" 5\d\d `, Tags: []string{"team:web"}})
+
+	p.generateLogMetrics(newMessage([]byte(`127.0.0.1 "GET / HTTP/1.1" 503 12`), source, ""))
+	p.generateLogMetrics(newMessage([]byte(`127.0.0.1 "GET / HTTP/1.1" 200 12`), source, ""))
+
+	require.Len(t, demux.samples, 1)
+	sample := demux.samples[0]
+	assert.Equal(t, "http.errors", sample.Name)
+	assert.Equal(t, 1.0, sample.Value)
+	assert.Equal(t, metrics.CountType, sample.Mtype)
+	assert.Equal(t, 1.0, sample.SampleRate)
+	assert.ElementsMatch(t, []string{"team:web", "env:prod"}, sample.Tags)
+}
+
+func TestLogMetricsValues(t *testing.T) {
+	demux := &recordingDemultiplexer{}
+	p := &Processor{demux: demux}
+	source := newLogMetricsSource(t,
+		&config.LogMetricRule{Name: "request.latency", Type: config.LogMetricDistribution, Field: "http.latency"},
+		&config.LogMetricRule{Name: "request.size", Type: config.LogMetricHistogram, Pattern: `status=(\d+) size=(?P<value>\d+)`},
+		&config.LogMetricRule{Name: "request.retries", Type: config.LogMetricGauge, Field: "retries", Pattern: "GET"},
+	)
+
+	p.generateLogMetrics(newMessage([]byte(`{"http":{"latency":12.5},"retries":"2","message":"GET status=200 size=512"}`), source, ""))
+	// no numeric value to extract
+	p.generateLogMetrics(newMessage([]byte(`{"http":{"latency":"fast"},"message":"POST status=200 size=?"}`), source, ""))
+
+	require.Len(t, demux.samples, 3)
+	assert.Equal(t, "request.latency", demux.samples[0].Name)
+	assert.Equal(t, 12.5, demux.samples[0].Value)
+	assert.Equal(t, metrics.DistributionType, demux.samples[0].Mtype)
+	assert.Equal(t, "request.size", demux.samples[1].Name)
+	assert.Equal(t, 512.0, demux.samples[1].Value)
+	assert.Equal(t, metrics.HistogramType, demux.samples[1].Mtype)
+	assert.Equal(t, "request.retries", demux.samples[2].Name)
+	assert.Equal(t, 2.0, demux.samples[2].Value)
+	assert.Equal(t, metrics.GaugeType, demux.samples[2].Mtype)
+}
+
+func TestLogMetricsAreGeneratedForExcludedLogs(t *testing.T) {
+	demux := &recordingDemultiplexer{}
+	outputChan := make(chan *message.Message, 1)
+	p := New(nil, outputChan, []*config.ProcessingRule{newProcessingRule(config.ExcludeAtMatch, "", "debug")}, RawEncoder, &diagnostic.NoopMessageReceiver{}, demux)
+	source := newLogMetricsSource(t, &config.LogMetricRule{Name: "debug.lines", Type: config.LogMetricCount, Pattern: "debug"})
+
+	p.processMessage(newMessage([]byte("debug: cache miss"), source, ""))
+
+	assert.Len(t, outputChan, 0)
+	require.Len(t, demux.samples, 1)
+	assert.Equal(t, "debug.lines", demux.samples[0].Name)
+}
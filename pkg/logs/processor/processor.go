@@ -8,7 +8,9 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -26,11 +28,13 @@ type Processor struct {
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	demux                     aggregator.Demultiplexer
 	mu                        sync.Mutex
 }
 
-// New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+// New returns an initialized Processor,
+// demux receives the metrics generated from logs and can be nil.
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, demux aggregator.Demultiplexer) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
@@ -38,6 +42,7 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		demux:                     demux,
 	}
 }
 
@@ -86,6 +91,7 @@ func (p *Processor) run() {
 func (p *Processor) processMessage(msg *message.Message) {
 	metrics.LogsDecoded.Add(1)
 	metrics.TlmLogsDecoded.Inc()
+	p.generateLogMetrics(msg)
 	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
 		metrics.LogsProcessed.Add(1)
 		metrics.TlmLogsProcessed.Inc()
@@ -106,21 +112,33 @@ func (p *Processor) processMessage(msg *message.Message) {
 // applyRedactingRules returns given a message if we should process it or not,
 // and a copy of the message with some fields redacted, depending on config
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
-	content := msg.Content
+	content := &jsonContent{raw: msg.Content}
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
 	for _, rule := range rules {
+		if rule.IsFieldRule() {
+			if !applyFieldRule(rule, content, msg) {
+				return false, nil
+			}
+			continue
+		}
 		switch rule.Type {
 		case config.ExcludeAtMatch:
-			if rule.Regex.Match(content) {
+			if rule.Regex.Match(content.bytes()) {
 				return false, nil
 			}
 		case config.IncludeAtMatch:
-			if !rule.Regex.Match(content) {
+			if !rule.Regex.Match(content.bytes()) {
 				return false, nil
 			}
 		case config.MaskSequences:
-			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+			content.set(rule.Regex.ReplaceAll(content.bytes(), rule.Placeholder))
+		case config.SampleAtMatch, config.RateLimitAtMatch:
+			if rule.Regex.Match(content.bytes()) && !msg.Origin.LogSource.GetSamplingState(rule).Keep(rule, time.Now()) {
+				metrics.LogsSampledOut.Add(1)
+				metrics.TlmLogsSampledOut.Inc(rule.Type)
+				return false, nil
+			}
 		}
 	}
-	return true, content
+	return true, content.bytes()
 }
@@ -8,6 +8,7 @@ package processor
 import (
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -156,3 +157,173 @@ func newSource(ruleType, replacePlaceholder, pattern string) config.LogSource {
 func newMessage(content []byte, source *config.LogSource, status string) *message.Message {
 	return message.NewMessageWithSource(content, status, source, 0)
 }
+
+func newFieldProcessingRule(ruleType, field, pattern string) *config.ProcessingRule {
+	rule := &config.ProcessingRule{Type: ruleType, Name: "test", Field: field, Pattern: pattern}
+	if err := config.CompileProcessingRules([]*config.ProcessingRule{rule}); err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+func TestFieldExclusion(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{newFieldProcessingRule(config.ExcludeAtFieldMatch, "level", "^debug$")}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"level":"debug","message":"hello"}`), source, ""))
+	assert.False(t, shouldProcess)
+	assert.Nil(t, redactedMessage)
+
+	// the content is left untouched when no field is modified
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"level": "info", "message": "hello"}`), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte(`{"level": "info", "message": "hello"}`), redactedMessage)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"message":"debug"}`), source, ""))
+	assert.True(t, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`level=debug`), source, ""))
+	assert.True(t, shouldProcess)
+}
+
+func TestFieldInclusion(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{newFieldProcessingRule(config.IncludeAtFieldMatch, "http.status_code", "^5")}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte(`{"http":{"status_code":503}}`), source, ""))
+	assert.True(t, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":{"status_code":200}}`), source, ""))
+	assert.False(t, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":"503"}`), source, ""))
+	assert.False(t, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`503`), source, ""))
+	assert.False(t, shouldProcess)
+}
+
+func TestFieldMask(t *testing.T) {
+	maskEmail := newFieldProcessingRule(config.MaskField, "user.email", "")
+	maskEmail.ReplacePlaceholder = "[masked]"
+	maskDigits := newFieldProcessingRule(config.MaskField, "card", "[0-9]{12}")
+	maskDigits.ReplacePlaceholder = "xxxx"
+	p := &Processor{processingRules: []*config.ProcessingRule{maskEmail, maskDigits}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"user":{"email":"bob@datadoghq.com","id":123456789012345678},"card":"4323124312341234 <ok>"}`), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, `{"card":"xxxx1234 <ok>","user":{"email":"[masked]","id":123456789012345678}}`, string(redactedMessage))
+}
+
+func TestFieldRemoveAndRename(t *testing.T) {
+	rename := newFieldProcessingRule(config.RenameField, "usr", "")
+	rename.Target = "user.name"
+	config.CompileProcessingRules([]*config.ProcessingRule{rename})
+	p := &Processor{processingRules: []*config.ProcessingRule{
+		newFieldProcessingRule(config.RemoveField, "request.id", ""),
+		rename,
+	}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"request":{"id":"abc","path":"/"},"usr":"bob"}`), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, `{"request":{"path":"/"},"user":{"name":"bob"}}`, string(redactedMessage))
+}
+
+func TestFieldRulesWithRawRules(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{
+		newFieldProcessingRule(config.RemoveField, "secret", ""),
+		newProcessingRule(config.ExcludeAtMatch, "", "s3cr3t"),
+		newProcessingRule(config.MaskSequences, "[masked]", "bob"),
+		newFieldProcessingRule(config.ExcludeAtFieldMatch, "user", "^alice$"),
+	}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"secret":"s3cr3t","user":"bob"}`), source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, `{"user":"[masked]"}`, string(redactedMessage))
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"secret":"s3cr3t","user":"alice"}`), source, ""))
+	assert.False(t, shouldProcess)
+}
+
+func TestStatusAndServiceFromField(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{
+		newFieldProcessingRule(config.StatusFromField, "level", ""),
+		newFieldProcessingRule(config.ServiceFromField, "app", ""),
+	}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	for level, status := range map[string]string{
+		`"WARNING"`: message.StatusWarning,
+		`"err"`:     message.StatusError,
+		`"Fatal"`:   message.StatusEmergency,
+		`"trace"`:   message.StatusDebug,
+		`3`:         message.StatusError,
+		`"foo"`:     message.StatusNotice,
+	} {
+		msg := newMessage([]byte(`{"level":`+level+`,"app":"billing"}`), source, message.StatusNotice)
+		shouldProcess, _ := p.applyRedactingRules(msg)
+		assert.True(t, shouldProcess)
+		assert.Equal(t, status, msg.GetStatus(), level)
+		assert.Equal(t, "billing", msg.Origin.Service())
+	}
+
+	// the service of the source has the precedence
+	source = config.NewLogSource("", &config.LogsConfig{Service: "payments"})
+	msg := newMessage([]byte(`{"app":"billing"}`), source, "")
+	p.applyRedactingRules(msg)
+	assert.Equal(t, "payments", msg.Origin.Service())
+}
+
+func TestSampling(t *testing.T) {
+	rule := newProcessingRule(config.SampleAtMatch, "", "debug")
+	rule.SampleRate = 0.25
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+	otherSource := config.NewLogSource("", &config.LogsConfig{})
+
+	kept := 0
+	for i := 0; i < 100; i++ {
+		if shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("debug line"), source, "")); shouldProcess {
+			kept++
+		}
+		// lines that don't match are always kept
+		shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("info line"), source, ""))
+		assert.True(t, shouldProcess)
+	}
+	assert.Equal(t, 25, kept)
+	assert.Equal(t, []string{"75"}, source.GetInfoStatus()[config.SampledOutInfoKey])
+
+	// each source is sampled independently
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("debug line"), otherSource, ""))
+	assert.False(t, shouldProcess)
+	assert.Equal(t, []string{"1"}, otherSource.GetInfoStatus()[config.SampledOutInfoKey])
+}
+
+func TestRateLimiting(t *testing.T) {
+	rule := newProcessingRule(config.RateLimitAtMatch, "", "")
+	rule.LinesPerSecond = 3
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	state := source.GetSamplingState(rule)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(t, state.Keep(rule, now))
+	}
+	assert.False(t, state.Keep(rule, now))
+	assert.True(t, state.Keep(rule, now.Add(time.Second)))
+
+	rule = newProcessingRule(config.RateLimitAtMatch, "", "")
+	rule.LinesPerSecond = 3
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("hello"), source, "")); shouldProcess {
+			kept++
+		}
+	}
+	// the lines can be processed across two windows
+	assert.True(t, kept >= 3 && kept <= 6, kept)
+}
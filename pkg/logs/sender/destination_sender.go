@@ -46,7 +46,11 @@ func (d *DestinationSender) startRetryReader() {
 		for v := range d.retryReader {
 			d.retryLock.Lock()
 			if d.cancelSendChan != nil && !d.lastRetryState {
-				d.cancelSendChan <- struct{}{}
+				// the send may have completed already, never block while holding the lock.
+				select {
+				case d.cancelSendChan <- struct{}{}:
+				default:
+				}
 			}
 			d.lastRetryState = v
 			d.retryLock.Unlock()
@@ -66,7 +70,7 @@ func (d *DestinationSender) Stop() {
 func (d *DestinationSender) Send(payload *message.Payload) bool {
 	d.lastSendSucceeded = false
 	d.retryLock.Lock()
-	d.cancelSendChan = make(chan struct{})
+	d.cancelSendChan = make(chan struct{}, 1)
 	isRetrying := d.lastRetryState
 	d.retryLock.Unlock()
 
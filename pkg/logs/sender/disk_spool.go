@@ -0,0 +1,247 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const spoolFileExtension = ".spool"
+const spoolFileFormat = "2006_01_02__15_04_05.000000000_"
+
+// Spool removal policies
+const (
+	// SpoolDropOldest removes the oldest payloads to make room for new ones when the spool is full.
+	SpoolDropOldest = "drop_oldest"
+	// SpoolBlock stops spooling when the spool is full, the pipeline then blocks until a destination recovers.
+	SpoolBlock = "block"
+)
+
+var errSpoolFull = errors.New("the disk spool is full")
+
+var (
+	tlmSpoolPayloadsStored = telemetry.NewCounter("logs_sender_disk_spool", "payloads_stored",
+		[]string{"pipeline"}, "The number of payloads stored on the disk")
+	tlmSpoolPayloadsReplayed = telemetry.NewCounter("logs_sender_disk_spool", "payloads_replayed",
+		[]string{"pipeline"}, "The number of payloads read from the disk and sent to a destination")
+	tlmSpoolFilesRemoved = telemetry.NewCounter("logs_sender_disk_spool", "files_removed_count",
+		[]string{"pipeline"}, "The number of files removed because the disk limit was reached")
+	tlmSpoolErrors = telemetry.NewCounter("logs_sender_disk_spool", "errors_count",
+		[]string{"pipeline"}, "The number of errors while reading or writing payloads")
+	tlmSpoolCurrentSizeInBytes = telemetry.NewGauge("logs_sender_disk_spool", "current_size_in_bytes",
+		[]string{"pipeline"}, "The number of bytes used to store payloads on the disk")
+	tlmSpoolFilesCount = telemetry.NewGauge("logs_sender_disk_spool", "files_count",
+		[]string{"pipeline"}, "The number of files")
+	tlmSpoolReloadedFilesCount = telemetry.NewGauge("logs_sender_disk_spool", "startup_reloaded_files_count",
+		[]string{"pipeline"}, "The number of files reloaded from a previous run of the Agent")
+)
+
+// spoolHeader holds the payload fields stored along with the encoded content.
+type spoolHeader struct {
+	Encoding      string `json:"encoding"`
+	UnencodedSize int    `json:"unencoded_size"`
+}
+
+// DiskSpool stores payloads on the disk while no reliable destination can accept them,
+// so that they can be replayed in order once a destination recovers.
+// Each payload is stored in its own file, the oldest file being the next payload to replay.
+// A DiskSpool is not thread safe.
+type DiskSpool struct {
+	storagePath        string
+	maxSizeInBytes     int64
+	removalPolicy      string
+	pipelineName       string
+	filenames          []string
+	fileSizes          map[string]int64
+	currentSizeInBytes int64
+}
+
+// NewDiskSpool returns a new DiskSpool storing at most maxSizeInBytes in storagePath,
+// payloads stored by a previous run of the agent are reloaded.
+func NewDiskSpool(storagePath string, maxSizeInBytes int64, removalPolicy string, pipelineName string) (*DiskSpool, error) {
+	if removalPolicy != SpoolDropOldest && removalPolicy != SpoolBlock {
+		return nil, fmt.Errorf("invalid spool removal policy '%s', must be %s or %s", removalPolicy, SpoolDropOldest, SpoolBlock)
+	}
+	if err := os.MkdirAll(storagePath, 0700); err != nil {
+		return nil, err
+	}
+	spool := &DiskSpool{
+		storagePath:    storagePath,
+		maxSizeInBytes: maxSizeInBytes,
+		removalPolicy:  removalPolicy,
+		pipelineName:   pipelineName,
+		fileSizes:      make(map[string]int64),
+	}
+	if err := spool.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+	return spool, nil
+}
+
+// IsEmpty returns true if there is no payload to replay.
+func (s *DiskSpool) IsEmpty() bool {
+	return len(s.filenames) == 0
+}
+
+// Store writes a payload to the disk, returns errSpoolFull if the spool
+// is full and the removal policy does not allow to make room for it.
+func (s *DiskSpool) Store(payload *message.Payload) error {
+	header, err := json.Marshal(spoolHeader{
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+	})
+	if err != nil {
+		return s.error(err)
+	}
+	var buffer bytes.Buffer
+	buffer.Write(header)
+	buffer.WriteByte('\n')
+	buffer.Write(payload.Encoded)
+	size := int64(buffer.Len())
+
+	if err := s.makeRoomFor(size); err != nil {
+		return err
+	}
+
+	filename := time.Now().UTC().Format(spoolFileFormat)
+	file, err := ioutil.TempFile(s.storagePath, filename+"*"+spoolFileExtension)
+	if err != nil {
+		return s.error(err)
+	}
+	if _, err = file.Write(buffer.Bytes()); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return s.error(err)
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return s.error(err)
+	}
+
+	s.currentSizeInBytes += size
+	s.filenames = append(s.filenames, file.Name())
+	s.fileSizes[file.Name()] = size
+	tlmSpoolPayloadsStored.Inc(s.pipelineName)
+	metrics.DiskSpoolExpVars.Add("PayloadsStored", 1)
+	s.updateSizeTelemetry()
+	return nil
+}
+
+// Peek reads the oldest payload from the disk without removing it.
+func (s *DiskSpool) Peek() (*message.Payload, error) {
+	if s.IsEmpty() {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(s.filenames[0])
+	if err != nil {
+		return nil, s.error(err)
+	}
+	reader := bufio.NewReader(bytes.NewReader(content))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, s.error(fmt.Errorf("invalid spool file %s: %v", s.filenames[0], err))
+	}
+	var header spoolHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, s.error(fmt.Errorf("invalid spool file %s: %v", s.filenames[0], err))
+	}
+	return &message.Payload{
+		Encoded:       content[len(line):],
+		Encoding:      header.Encoding,
+		UnencodedSize: header.UnencodedSize,
+	}, nil
+}
+
+// Remove removes the oldest payload from the disk.
+func (s *DiskSpool) Remove() error {
+	if s.IsEmpty() {
+		return nil
+	}
+	err := s.removeFileAt(0)
+	s.updateSizeTelemetry()
+	return err
+}
+
+// makeRoomFor removes the oldest files until a payload of size bytes can be stored.
+func (s *DiskSpool) makeRoomFor(size int64) error {
+	if size > s.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big. Current:%v Maximum:%v", size, s.maxSizeInBytes)
+	}
+	for s.currentSizeInBytes+size > s.maxSizeInBytes {
+		if s.removalPolicy == SpoolBlock || s.IsEmpty() {
+			return errSpoolFull
+		}
+		log.Warnf("Maximum disk space for the logs spool of pipeline %s is reached. Removing %s", s.pipelineName, s.filenames[0])
+		if err := s.removeFileAt(0); err != nil {
+			return s.error(err)
+		}
+		tlmSpoolFilesRemoved.Inc(s.pipelineName)
+		metrics.DiskSpoolExpVars.Add("FilesRemoved", 1)
+	}
+	return nil
+}
+
+func (s *DiskSpool) removeFileAt(index int) error {
+	filename := s.filenames[index]
+
+	// Forget the file and its size also in case of error to not fail on
+	// the next call, the size is the one accounted when the file was added.
+	s.filenames = append(s.filenames[:index], s.filenames[index+1:]...)
+	s.currentSizeInBytes -= s.fileSizes[filename]
+	delete(s.fileSizes, filename)
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *DiskSpool) reloadExistingFiles() error {
+	entries, err := ioutil.ReadDir(s.storagePath)
+	if err != nil {
+		return err
+	}
+	var filenames []string
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && filepath.Ext(entry.Name()) == spoolFileExtension {
+			filename := filepath.Join(s.storagePath, entry.Name())
+			s.currentSizeInBytes += entry.Size()
+			s.fileSizes[filename] = entry.Size()
+			filenames = append(filenames, filename)
+		}
+	}
+	// file names start with their creation time, sorting them gives the replay order.
+	sort.Strings(filenames)
+	s.filenames = filenames
+	tlmSpoolReloadedFilesCount.Set(float64(len(filenames)), s.pipelineName)
+	s.updateSizeTelemetry()
+	return nil
+}
+
+func (s *DiskSpool) updateSizeTelemetry() {
+	tlmSpoolCurrentSizeInBytes.Set(float64(s.currentSizeInBytes), s.pipelineName)
+	tlmSpoolFilesCount.Set(float64(len(s.filenames)), s.pipelineName)
+}
+
+func (s *DiskSpool) error(err error) error {
+	tlmSpoolErrors.Inc(s.pipelineName)
+	metrics.DiskSpoolExpVars.Add("Errors", 1)
+	return err
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newSpoolPayload(content string) *message.Payload {
+	return &message.Payload{
+		Encoded:       []byte(content),
+		Encoding:      "gzip",
+		UnencodedSize: 2 * len(content),
+	}
+}
+
+func TestDiskSpoolStoreAndReplayInOrder(t *testing.T) {
+	spool, err := NewDiskSpool(t.TempDir(), 1000, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	assert.True(t, spool.IsEmpty())
+
+	for _, content := range []string{"foo", "bar", "baz"} {
+		assert.NoError(t, spool.Store(newSpoolPayload(content)))
+	}
+	assert.False(t, spool.IsEmpty())
+
+	for _, content := range []string{"foo", "bar", "baz"} {
+		payload, err := spool.Peek()
+		assert.NoError(t, err)
+		assert.Equal(t, newSpoolPayload(content), payload)
+		assert.NoError(t, spool.Remove())
+	}
+	assert.True(t, spool.IsEmpty())
+	assert.Equal(t, int64(0), spool.currentSizeInBytes)
+
+	payload, err := spool.Peek()
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+}
+
+func TestDiskSpoolReloadExistingFiles(t *testing.T) {
+	path := t.TempDir()
+	spool, err := NewDiskSpool(path, 1000, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	assert.NoError(t, spool.Store(newSpoolPayload("foo")))
+	assert.NoError(t, spool.Store(newSpoolPayload("bar")))
+	// files with another extension are ignored
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(path, "other.txt"), []byte("baz"), 0600))
+
+	spool, err = NewDiskSpool(path, 1000, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	assert.Len(t, spool.filenames, 2)
+
+	payload, err := spool.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, newSpoolPayload("foo"), payload)
+}
+
+func TestDiskSpoolRemoveFileDeletedExternally(t *testing.T) {
+	spool, err := NewDiskSpool(t.TempDir(), 1000, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	fileSize := spoolFileSize(t, spool)
+	assert.NoError(t, spool.Store(newSpoolPayload("foo")))
+	assert.NoError(t, spool.Store(newSpoolPayload("bar")))
+	require.NoError(t, os.Remove(spool.filenames[0]))
+
+	assert.NoError(t, spool.Remove())
+	assert.Len(t, spool.filenames, 1)
+	assert.Equal(t, fileSize, spool.currentSizeInBytes)
+
+	payload, err := spool.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, newSpoolPayload("bar"), payload)
+}
+
+func TestDiskSpoolDropOldestWhenFull(t *testing.T) {
+	spool, err := NewDiskSpool(t.TempDir(), 1000, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	// each file holds the header and the content
+	spool.maxSizeInBytes = 2 * spoolFileSize(t, spool)
+
+	assert.NoError(t, spool.Store(newSpoolPayload("foo")))
+	assert.NoError(t, spool.Store(newSpoolPayload("bar")))
+	assert.NoError(t, spool.Store(newSpoolPayload("baz")))
+	assert.Len(t, spool.filenames, 2)
+
+	payload, err := spool.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, newSpoolPayload("bar"), payload)
+}
+
+func TestDiskSpoolBlockWhenFull(t *testing.T) {
+	spool, err := NewDiskSpool(t.TempDir(), 1000, SpoolBlock, "test")
+	require.NoError(t, err)
+	spool.maxSizeInBytes = 2 * spoolFileSize(t, spool)
+
+	assert.NoError(t, spool.Store(newSpoolPayload("foo")))
+	assert.NoError(t, spool.Store(newSpoolPayload("bar")))
+	assert.Equal(t, errSpoolFull, spool.Store(newSpoolPayload("baz")))
+	assert.Len(t, spool.filenames, 2)
+
+	payload, err := spool.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, newSpoolPayload("foo"), payload)
+}
+
+func TestDiskSpoolRejectsPayloadsBiggerThanMaxSize(t *testing.T) {
+	spool, err := NewDiskSpool(t.TempDir(), 10, SpoolDropOldest, "test")
+	require.NoError(t, err)
+	assert.Error(t, spool.Store(newSpoolPayload("a payload bigger than the spool")))
+	assert.True(t, spool.IsEmpty())
+}
+
+func TestDiskSpoolInvalidRemovalPolicy(t *testing.T) {
+	_, err := NewDiskSpool(t.TempDir(), 1000, "foo", "test")
+	assert.Error(t, err)
+}
+
+// spoolFileSize returns the size of the file storing a 3 bytes payload.
+func spoolFileSize(t *testing.T, spool *DiskSpool) int64 {
+	require.NoError(t, spool.Store(newSpoolPayload("abc")))
+	info, err := os.Stat(spool.filenames[0])
+	require.NoError(t, err)
+	require.NoError(t, spool.Remove())
+	return info.Size()
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Sender sends logs to different destinations. Destinations can be either
@@ -19,20 +21,35 @@ import (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+//
+// When a disk spool is provided, payloads are written to the disk instead of
+// blocking the pipeline while all reliable destinations are in an error state,
+// and are replayed in order once a reliable destination recovers.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
+	spool        *DiskSpool
 	done         chan struct{}
 	bufferSize   int
 }
 
+// spoolReplayInterval is the interval at which the sender tries to replay spooled payloads.
+const spoolReplayInterval = 100 * time.Millisecond
+
 // NewSender returns a new sender.
 func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int) *Sender {
+	return NewSenderWithSpool(inputChan, outputChan, destinations, bufferSize, nil)
+}
+
+// NewSenderWithSpool returns a new sender buffering payloads in spool when no reliable destination
+// can accept them, spool can be nil to always block the pipeline instead.
+func NewSenderWithSpool(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, spool *DiskSpool) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
+		spool:        spool,
 		done:         make(chan struct{}),
 		bufferSize:   bufferSize,
 	}
@@ -56,47 +73,134 @@ func (s *Sender) run() {
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
+	if s.spool != nil {
+		s.runWithSpool(reliableDestinations, unreliableDestinations)
+	} else {
+		for payload := range s.inputChan {
+			s.send(payload, reliableDestinations)
+			s.sendToUnreliable(payload, unreliableDestinations)
+		}
+	}
 
-		sent := false
-		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					sent = true
-				}
-			}
+	// Cleanup the destinations
+	for _, destSender := range reliableDestinations {
+		destSender.Stop()
+	}
+	for _, destSender := range unreliableDestinations {
+		destSender.Stop()
+	}
+	close(sink)
+	s.done <- struct{}{}
+}
 
-			if !sent {
-				// Throttle the poll loop while waiting for a send to succeed
-				// This will only happen when all reliable destinations
-				// are blocked so logs have no where to go.
-				time.Sleep(100 * time.Millisecond)
+// runWithSpool sends the payloads to the destinations, spooling them on the disk
+// while no reliable destination is able to accept them.
+func (s *Sender) runWithSpool(reliableDestinations []*DestinationSender, unreliableDestinations []*DestinationSender) {
+	replayTicker := time.NewTicker(spoolReplayInterval)
+	defer replayTicker.Stop()
+	for {
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				// spooled payloads are kept on the disk and replayed on the next run.
+				return
 			}
-		}
-
-		for _, destSender := range reliableDestinations {
-			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
-				destSender.NonBlockingSend(payload)
+			// payloads must not be sent before the spooled ones to preserve the ordering.
+			if !s.spool.IsEmpty() || !s.trySend(payload, reliableDestinations) {
+				s.store(payload, reliableDestinations)
 			}
+			s.sendToUnreliable(payload, unreliableDestinations)
+		case <-replayTicker.C:
+			s.replay(reliableDestinations)
 		}
+	}
+}
 
-		// Attempt to send to unreliable destinations
-		for _, destSender := range unreliableDestinations {
-			destSender.NonBlockingSend(payload)
+// send sends the payload to the reliable destinations, blocking until at least one accepts it.
+func (s *Sender) send(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	for !s.trySend(payload, reliableDestinations) {
+		// Throttle the poll loop while waiting for a send to succeed
+		// This will only happen when all reliable destinations
+		// are blocked so logs have no where to go.
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// trySend sends the payload to the reliable destinations,
+// returns false if all of them are retrying.
+func (s *Sender) trySend(payload *message.Payload, reliableDestinations []*DestinationSender) bool {
+	sent := false
+	for _, destSender := range reliableDestinations {
+		if destSender.Send(payload) {
+			sent = true
 		}
 	}
+	if !sent {
+		return false
+	}
 
-	// Cleanup the destinations
 	for _, destSender := range reliableDestinations {
-		destSender.Stop()
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !destSender.lastSendSucceeded {
+			destSender.NonBlockingSend(payload)
+		}
 	}
+	return true
+}
+
+// sendToUnreliable attempts to send the payload to unreliable destinations.
+func (s *Sender) sendToUnreliable(payload *message.Payload, unreliableDestinations []*DestinationSender) {
 	for _, destSender := range unreliableDestinations {
-		destSender.Stop()
+		destSender.NonBlockingSend(payload)
 	}
-	close(sink)
-	s.done <- struct{}{}
+}
+
+// store writes the payload to the spool and forwards it to the output as it is now persisted,
+// falls back on blocking until a destination accepts it if the spool can't store it.
+func (s *Sender) store(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	err := s.spool.Store(payload)
+	if err == nil {
+		s.outputChan <- payload
+		return
+	}
+	if err != errSpoolFull {
+		log.Warnf("Could not store payload on the disk: %v", err)
+	}
+	// the spool can't take the payload, replay what we can before blocking on it to keep the ordering.
+	for !s.spool.IsEmpty() {
+		if !s.replay(reliableDestinations) {
+			time.Sleep(spoolReplayInterval)
+		}
+	}
+	s.send(payload, reliableDestinations)
+}
+
+// replay sends the spooled payloads to the reliable destinations, oldest first,
+// until the spool is empty or all destinations are retrying.
+// Returns false if no payload could be replayed.
+func (s *Sender) replay(reliableDestinations []*DestinationSender) bool {
+	replayed := false
+	for !s.spool.IsEmpty() {
+		payload, err := s.spool.Peek()
+		if err != nil {
+			log.Warnf("Dropping spooled payload: %v", err)
+			if err := s.spool.Remove(); err != nil {
+				log.Warnf("Could not remove spooled payload: %v", err)
+			}
+			continue
+		}
+		if !s.trySend(payload, reliableDestinations) {
+			return replayed
+		}
+		if err := s.spool.Remove(); err != nil {
+			log.Warnf("Could not remove spooled payload: %v", err)
+		}
+		tlmSpoolPayloadsReplayed.Inc(s.spool.pipelineName)
+		metrics.DiskSpoolExpVars.Add("PayloadsReplayed", 1)
+		replayed = true
+	}
+	return replayed
 }
 
 // Drains the output channel from destinations that don't update the auditor.
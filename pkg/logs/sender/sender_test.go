@@ -6,6 +6,7 @@
 package sender
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
@@ -299,3 +300,66 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+// failingDestination retries the first payload it receives until recover is closed.
+type failingDestination struct {
+	recover chan struct{}
+}
+
+func (d *failingDestination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) <-chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		failing := true
+		for p := range input {
+			if failing {
+				isRetrying <- true
+				<-d.recover
+				isRetrying <- false
+				failing = false
+			}
+			output <- p
+		}
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+func TestSenderSpoolsPayloadsWhenDestinationFailsAndReplaysThem(t *testing.T) {
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 10)
+
+	destination := &failingDestination{recover: make(chan struct{})}
+	destinations := client.NewDestinations([]client.Destination{destination}, nil)
+
+	storagePath := t.TempDir()
+	spool, err := NewDiskSpool(storagePath, 1000, SpoolDropOldest, "test")
+	assert.Nil(t, err)
+
+	sender := NewSenderWithSpool(input, output, destinations, 0, spool)
+	sender.Start()
+
+	stuck := &message.Payload{Encoded: []byte("stuck")}
+	spooled := &message.Payload{Encoded: []byte("spooled"), Encoding: "identity", UnencodedSize: 7}
+
+	// the first payload is retried in memory by the destination.
+	input <- stuck
+	// the destination is retrying, the second payload goes to the disk and is committed right away.
+	input <- spooled
+	assert.Equal(t, spooled, <-output)
+	// the spool is owned by the sender goroutine, look at its storage instead.
+	files, err := filepath.Glob(filepath.Join(storagePath, "*"+spoolFileExtension))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	close(destination.recover)
+	assert.Equal(t, stuck, <-output)
+
+	replayed := <-output
+	assert.Nil(t, replayed.Messages)
+	assert.Equal(t, spooled.Encoded, replayed.Encoded)
+	assert.Equal(t, spooled.Encoding, replayed.Encoding)
+	assert.Equal(t, spooled.UnencodedSize, replayed.UnencodedSize)
+
+	sender.Stop()
+	assert.True(t, spool.IsEmpty())
+}
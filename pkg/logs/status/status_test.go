@@ -92,13 +92,13 @@ func TestStatusDeduplicateErrorsAndWarnings(t *testing.T) {
 func TestMetrics(t *testing.T) {
 	defer Clear()
 	Clear()
-	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": ""}`
+	var expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskSpool": {}, "EncodedBytesSent": 0, "Errors": "", "HttpDestinationStats": {}, "IsRunning": false, "LogMetricSamples": 0, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": ""}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 
 	initStatus()
 	AddGlobalWarning("bar", "Unique Warning")
 	AddGlobalError("bar", "I am an error")
-	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
+	expected = `{"BytesSent": 0, "DestinationErrors": 0, "DestinationLogsDropped": {}, "DiskSpool": {}, "EncodedBytesSent": 0, "Errors": "I am an error", "HttpDestinationStats": {}, "IsRunning": true, "LogMetricSamples": 0, "LogsDecoded": 0, "LogsProcessed": 0, "LogsSampledOut": 0, "LogsSent": 0, "SenderLatency": 0, "Warnings": "Unique Warning"}`
 	assert.Equal(t, expected, metrics.LogsExpvars.String())
 }
 
@@ -111,17 +111,20 @@ func TestStatusMetrics(t *testing.T) {
 	assert.Equal(t, int64(0), status.StatusMetrics["LogsSent"])
 	assert.Equal(t, int64(0), status.StatusMetrics["BytesSent"])
 	assert.Equal(t, int64(0), status.StatusMetrics["EncodedBytesSent"])
+	assert.Equal(t, int64(0), status.StatusMetrics["LogsSampledOut"])
 
 	metrics.LogsProcessed.Set(5)
 	metrics.LogsSent.Set(3)
 	metrics.BytesSent.Set(42)
 	metrics.EncodedBytesSent.Set(21)
+	metrics.LogsSampledOut.Set(7)
 	status = Get()
 
 	assert.Equal(t, int64(5), status.StatusMetrics["LogsProcessed"])
 	assert.Equal(t, int64(3), status.StatusMetrics["LogsSent"])
 	assert.Equal(t, int64(42), status.StatusMetrics["BytesSent"])
 	assert.Equal(t, int64(21), status.StatusMetrics["EncodedBytesSent"])
+	assert.Equal(t, int64(7), status.StatusMetrics["LogsSampledOut"])
 
 	metrics.LogsProcessed.Set(math.MaxInt64)
 	metrics.LogsProcessed.Add(1)
@@ -57,9 +57,9 @@ func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSamp
 		case MonotonicCountType:
 			m[contextKey] = &MonotonicCount{}
 		case HistogramType:
-			m[contextKey] = NewHistogram(interval) // default histogram configuration (no call to `configure`) for now
+			m[contextKey] = newHistogramForMetric(sample.Name, interval)
 		case HistorateType:
-			m[contextKey] = NewHistorate(interval) // internal histogram has the configuration for now
+			m[contextKey] = newHistorateForMetric(sample.Name, interval)
 		case SetType:
 			m[contextKey] = NewSet()
 		case CounterType:
@@ -6,9 +6,10 @@
 package metrics
 
 import (
-	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -28,9 +29,9 @@ func (w weightSamples) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
 
 // Histogram tracks the distribution of samples added over one flush period
 type Histogram struct {
-	aggregates  []string // aggregates configured on this histogram
-	percentiles []int    // percentiles configured on this histogram, each in the 1-100 range
-	interval    int64    // interval over which the `count` value is normalized (bucket interval for Dogstatsd, 1 otherwise)
+	aggregates  []string  // aggregates configured on this histogram
+	percentiles []float64 // percentiles configured on this histogram, each in the 0-100 range with a 0.1 precision
+	interval    int64     // interval over which the `count` value is normalized (bucket interval for Dogstatsd, 1 otherwise)
 	samples     weightSamples
 	sum         float64
 	count       int64
@@ -47,36 +48,53 @@ const (
 
 var (
 	defaultAggregates  = []string(nil)
-	defaultPercentiles = []int(nil)
+	defaultPercentiles = []float64(nil)
 )
 
 type histogramPercentilesConfig struct {
 	Percentiles []string `mapstructure:"histogram_percentiles"`
 }
 
-func (h *histogramPercentilesConfig) percentiles() []int {
-	res := []int{}
-	for _, p := range h.Percentiles {
+func (h *histogramPercentilesConfig) percentiles() []float64 {
+	return parsePercentiles(h.Percentiles, "histogram_percentiles", 1)
+}
+
+// parsePercentiles parses percentiles in the 0-1 range, they are rounded to 1/scale
+// once converted to the 0-100 range (e.g. 0.999 is 100 with a scale of 1, 99.9 with 10).
+func parsePercentiles(percentiles []string, configKey string, scale float64) []float64 {
+	res := []float64{}
+	for _, p := range percentiles {
 		i, err := strconv.ParseFloat(p, 64)
 		if err != nil {
-			log.Errorf("Could not parse '%s' from 'histogram_percentiles' (skipping): %s", p, err)
+			log.Errorf("Could not parse '%s' from '%s' (skipping): %s", p, configKey, err)
 			continue
 		}
 		if i < 0 || i > 1 {
-			log.Errorf("histogram_percentiles must be between 0 and 1: skipping %f", i)
+			log.Errorf("%s must be between 0 and 1: skipping %f", configKey, i)
 			continue
 		}
 		// in some cases the '*100' will lower the number resulting in
-		// an int lower by 1 from what is expected (ex: 0.29 would
-		// become 28). As a workaround we add 0.5 before casting.
-		res = append(res, int(i*100+0.5))
+		// a value lower by 1/scale from what is expected (ex: 0.29 would
+		// become 28). As a workaround we add 0.5 before truncating.
+		res = append(res, math.Floor(i*100*scale+0.5)/scale)
 	}
 	return res
 }
 
 // NewHistogram returns a newly initialized histogram
 func NewHistogram(interval int64) *Histogram {
-	// we initialize default value on the first histogram creation
+	loadDefaultHistogramConfig()
+
+	return &Histogram{
+		interval:    interval,
+		aggregates:  defaultAggregates,
+		percentiles: defaultPercentiles,
+	}
+}
+
+// loadDefaultHistogramConfig initializes the default aggregates and percentiles,
+// it is called on the first histogram creation.
+func loadDefaultHistogramConfig() {
 	if defaultAggregates == nil {
 		defaultAggregates = config.Datadog.GetStringSlice("histogram_aggregates")
 	}
@@ -87,20 +105,14 @@ func NewHistogram(interval int64) *Histogram {
 			log.Errorf("Could not Unmarshal histogram configuration: %s", err)
 		} else {
 			defaultPercentiles = c.percentiles()
-			sort.Ints(defaultPercentiles)
+			sort.Float64s(defaultPercentiles)
 		}
 	}
-
-	return &Histogram{
-		interval:    interval,
-		aggregates:  defaultAggregates,
-		percentiles: defaultPercentiles,
-	}
 }
 
-func (h *Histogram) configure(aggregates []string, percentiles []int) {
+func (h *Histogram) configure(aggregates []string, percentiles []float64) {
 	h.aggregates = aggregates
-	sort.Ints(percentiles)
+	sort.Float64s(percentiles)
 	h.percentiles = percentiles
 }
 
@@ -165,7 +177,8 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 	// Compute percentiles
 	var target []int64
 	for _, percentile := range h.percentiles {
-		target = append(target, (int64(percentile)*h.count-1)/100)
+		// computed in tenths of percent to stay exact with the 0.1 precision
+		target = append(target, (int64(math.Round(percentile*10))*h.count-10)/1000)
 	}
 
 	if len(target) > 0 {
@@ -177,7 +190,7 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 				series = append(series, &Serie{
 					Points:     []Point{{Ts: timestamp, Value: s.value}},
 					MType:      APIGaugeType,
-					NameSuffix: fmt.Sprintf(".%dpercentile", h.percentiles[idx]),
+					NameSuffix: "." + formatPercentile(h.percentiles[idx]) + "percentile",
 				})
 				idx++
 			}
@@ -195,6 +208,12 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 	return series, nil
 }
 
+// formatPercentile formats a percentile for the metric names, e.g. 95 is `95`
+// and 99.9 is `99_9`.
+func formatPercentile(percentile float64) string {
+	return strings.Replace(strconv.FormatFloat(percentile, 'f', -1, 64), ".", "_", 1)
+}
+
 func (h *Histogram) isStateful() bool {
 	return false
 }
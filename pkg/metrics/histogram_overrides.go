@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// histogramOverride holds the aggregates and percentiles of the histograms of the
+// metrics named `name`, or starting with `prefix`.
+type histogramOverride struct {
+	name        string
+	prefix      string
+	aggregates  []string
+	percentiles []float64
+}
+
+var (
+	// histogramOverrides is initialized on the first histogram creation, like the
+	// default aggregates and percentiles.
+	histogramOverrides   = []histogramOverride(nil)
+	histogramOverridesMu sync.Mutex
+)
+
+func loadHistogramOverrides() []histogramOverride {
+	loadDefaultHistogramConfig()
+
+	overrides := []histogramOverride{}
+	configOverrides, err := config.GetHistogramOverrides()
+	if err != nil {
+		log.Errorf("Could not load the histogram overrides: %s", err)
+		return overrides
+	}
+	for i, configOverride := range configOverrides {
+		if (configOverride.Name == "") == (configOverride.Prefix == "") {
+			log.Errorf("histogram_overrides: exactly one of name and prefix must be set in override %d (skipping)", i)
+			continue
+		}
+		override := histogramOverride{
+			name:        configOverride.Name,
+			prefix:      configOverride.Prefix,
+			aggregates:  configOverride.Aggregates,
+			percentiles: defaultPercentiles,
+		}
+		if override.aggregates == nil {
+			override.aggregates = defaultAggregates
+		}
+		if configOverride.Percentiles != nil {
+			override.percentiles = parsePercentiles(configOverride.Percentiles, "histogram_overrides", 10)
+			sort.Float64s(override.percentiles)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides
+}
+
+// ResetHistogramOverrides drops the loaded histogram overrides, they are
+// loaded again from the configuration by the next histogram creation.
+func ResetHistogramOverrides() {
+	histogramOverridesMu.Lock()
+	defer histogramOverridesMu.Unlock()
+	histogramOverrides = nil
+}
+
+// getHistogramOverride returns the first override matching the metric name, or nil.
+func getHistogramOverride(name string) *histogramOverride {
+	histogramOverridesMu.Lock()
+	defer histogramOverridesMu.Unlock()
+
+	if histogramOverrides == nil {
+		histogramOverrides = loadHistogramOverrides()
+	}
+	for i := range histogramOverrides {
+		override := &histogramOverrides[i]
+		if override.name != "" && name == override.name || override.prefix != "" && strings.HasPrefix(name, override.prefix) {
+			return override
+		}
+	}
+	return nil
+}
+
+// newHistogramForMetric returns a newly initialized histogram configured with
+// the override matching the metric name, if any.
+func newHistogramForMetric(name string, interval int64) *Histogram {
+	h := NewHistogram(interval)
+	if override := getHistogramOverride(name); override != nil {
+		h.aggregates = override.aggregates
+		h.percentiles = override.percentiles
+	}
+	return h
+}
+
+// newHistorateForMetric returns a newly initialized historate configured with
+// the override matching the metric name, if any.
+func newHistorateForMetric(name string, interval int64) *Historate {
+	h := NewHistorate(interval)
+	if override := getHistogramOverride(name); override != nil {
+		h.histogram.aggregates = override.aggregates
+		h.histogram.percentiles = override.percentiles
+	}
+	return h
+}
+
+// DescribeHistogramOverride returns a description of the aggregates and percentiles
+// overriding the defaults for the histograms of a metric, or an empty string.
+func DescribeHistogramOverride(name string) string {
+	override := getHistogramOverride(name)
+	if override == nil {
+		return ""
+	}
+	percentiles := make([]string, 0, len(override.percentiles))
+	for _, p := range override.percentiles {
+		percentiles = append(percentiles, "p"+strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return fmt.Sprintf("aggregates: %s; percentiles: %s", strings.Join(override.aggregates, ","), strings.Join(percentiles, ","))
+}
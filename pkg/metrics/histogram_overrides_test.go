@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func setupHistogramOverrides(t *testing.T, overrides []config.HistogramOverride) {
+	mockConfig := config.Mock()
+	mockConfig.Set("histogram_overrides", overrides)
+	ResetHistogramOverrides()
+	t.Cleanup(func() {
+		mockConfig.Set("histogram_overrides", nil)
+		ResetHistogramOverrides()
+	})
+}
+
+func TestHistogramOverrides(t *testing.T) {
+	setupHistogramOverrides(t, []config.HistogramOverride{
+		{Name: "api.latency", Percentiles: []string{"0.5", "0.99", "0.999"}},
+		{Prefix: "api.", Aggregates: []string{"max", "count"}},
+		{Name: "invalid", Prefix: "invalid."},
+		{Prefix: "db.", Aggregates: []string{}, Percentiles: []string{"0.999", "invalid"}},
+	})
+
+	h := newHistogramForMetric("api.latency", 10)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+	assert.Equal(t, []float64{50, 99, 99.9}, h.percentiles)
+
+	h = newHistogramForMetric("api.size", 10)
+	assert.Equal(t, []string{"max", "count"}, h.aggregates)
+	assert.Equal(t, []float64{95}, h.percentiles)
+
+	h = newHistogramForMetric("invalid.size", 10)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+	assert.Equal(t, []float64{95}, h.percentiles)
+
+	hr := newHistorateForMetric("db.latency", 10)
+	assert.Equal(t, []string{}, hr.histogram.aggregates)
+	assert.Equal(t, []float64{99.9}, hr.histogram.percentiles)
+
+	assert.Equal(t, "aggregates: max,median,avg,count; percentiles: p50,p99,p99.9", DescribeHistogramOverride("api.latency"))
+	assert.Equal(t, "aggregates: max,count; percentiles: p95", DescribeHistogramOverride("api.size"))
+	assert.Equal(t, "", DescribeHistogramOverride("other.latency"))
+}
+
+func TestContextMetricsHistogramOverrides(t *testing.T) {
+	setupHistogramOverrides(t, []config.HistogramOverride{
+		{Prefix: "api.", Aggregates: []string{"max"}, Percentiles: []string{"0.999"}},
+	})
+
+	metrics := MakeContextMetrics()
+	contextKey1 := ckey.ContextKey(0xaaffffffffffffff)
+	contextKey2 := ckey.ContextKey(0xbbffffffffffffff)
+	for i := 1; i <= 1000; i++ {
+		metrics.AddSample(contextKey1, &MetricSample{Name: "api.latency", Value: float64(i), Mtype: HistogramType}, 1, 10, nil)
+		metrics.AddSample(contextKey2, &MetricSample{Name: "other.latency", Value: float64(i), Mtype: HistogramType}, 1, 10, nil)
+	}
+
+	series, errs := metrics.Flush(10)
+	require.Len(t, errs, 0)
+	suffixes := map[ckey.ContextKey][]string{}
+	for _, serie := range series {
+		suffixes[serie.ContextKey] = append(suffixes[serie.ContextKey], serie.NameSuffix)
+	}
+	assert.Equal(t, []string{".max", ".99_9percentile"}, suffixes[contextKey1])
+	assert.Equal(t, []string{".max", ".median", ".avg", ".count", ".95percentile"}, suffixes[contextKey2])
+}
@@ -20,12 +20,21 @@ import (
 
 func TestHistogramConf(t *testing.T) {
 	h := histogramPercentilesConfig{Percentiles: []string{"0.95", "0.96", "0.28", "0.57", "0.58"}}
-	assert.Equal(t, []int{95, 96, 28, 57, 58}, h.percentiles())
+	assert.Equal(t, []float64{95, 96, 28, 57, 58}, h.percentiles())
 }
 
 func TestHistogramConfError(t *testing.T) {
 	h := histogramPercentilesConfig{Percentiles: []string{"0.95", "test", "0.12test", "0.22", "200", "-50"}}
-	assert.Equal(t, []int{95, 22}, h.percentiles())
+	assert.Equal(t, []float64{95, 22}, h.percentiles())
+}
+
+func TestHistogramConfFractional(t *testing.T) {
+	// the default percentiles are rounded to an integer, like before the overrides
+	h := histogramPercentilesConfig{Percentiles: []string{"0.999", "0.29", "0.5", "0.955", "0.9995"}}
+	assert.Equal(t, []float64{100, 29, 50, 96, 100}, h.percentiles())
+
+	// the percentiles of the overrides are kept with a 0.1 precision
+	assert.Equal(t, []float64{99.9, 29, 50, 95.5, 100}, parsePercentiles(h.Percentiles, "histogram_overrides", 10))
 }
 
 func TestConfigureDefault(t *testing.T) {
@@ -36,7 +45,7 @@ func TestConfigureDefault(t *testing.T) {
 	_, err := hist.flush(60)
 	require.Nil(t, err)
 	assert.Equal(t, []string{"max", "median", "avg", "count"}, hist.aggregates)
-	assert.Equal(t, []int{95}, hist.percentiles)
+	assert.Equal(t, []float64{95}, hist.percentiles)
 }
 
 func TestConfigure(t *testing.T) {
@@ -59,7 +68,29 @@ func TestConfigure(t *testing.T) {
 
 	hist := NewHistogram(10)
 	assert.Equal(t, aggregates, hist.aggregates)
-	assert.Equal(t, []int{30, 50, 98}, hist.percentiles)
+	assert.Equal(t, []float64{30, 50, 98}, hist.percentiles)
+}
+
+func TestConfigureKeepsIntegerPercentileNames(t *testing.T) {
+	mockConfig := config.Mock()
+
+	percentilesBk := config.Datadog.GetStringSlice("histogram_percentiles")
+	defer func() {
+		mockConfig.Set("histogram_percentiles", percentilesBk)
+		defaultPercentiles = nil
+	}()
+
+	defaultPercentiles = nil
+	mockConfig.Set("histogram_percentiles", []string{"0.955"})
+
+	hist := NewHistogram(10)
+	hist.configure([]string{}, hist.percentiles)
+	hist.addSample(&MetricSample{Value: 1}, 50)
+
+	series, err := hist.flush(60)
+	require.Nil(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, ".96percentile", series[0].NameSuffix)
 }
 
 func TestDefaultHistogramSampling(t *testing.T) {
@@ -104,7 +135,7 @@ func TestDefaultHistogramSampling(t *testing.T) {
 func TestCustomHistogramSampling(t *testing.T) {
 	// Initialize custom histogram, with an invalid aggregate
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"min", "sum", "invalid"}, []int{})
+	mHistogram.configure([]string{"min", "sum", "invalid"}, []float64{})
 
 	// Empty flush
 	_, err := mHistogram.flush(50)
@@ -149,7 +180,7 @@ func shuffle(slice []float64) {
 func TestHistogramPercentiles(t *testing.T) {
 	// Initialize custom histogram
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "median", "avg", "count", "min"}, []int{95, 80})
+	mHistogram.configure([]string{"max", "median", "avg", "count", "min"}, []float64{95, 80})
 
 	// Empty flush
 	_, err := mHistogram.flush(50)
@@ -195,9 +226,28 @@ func TestHistogramPercentiles(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestHistogramFractionalPercentiles(t *testing.T) {
+	mHistogram := NewHistogram(10)
+	mHistogram.configure([]string{}, []float64{99.9, 99, 50})
+
+	for i := 1; i <= 1000; i++ {
+		mHistogram.addSample(&MetricSample{Value: float64(i)}, 50)
+	}
+
+	series, err := mHistogram.flush(60)
+	require.Nil(t, err)
+	require.Len(t, series, 3)
+	assert.Equal(t, ".50percentile", series[0].NameSuffix)
+	assert.EqualValues(t, 500, series[0].Points[0].Value)
+	assert.Equal(t, ".99percentile", series[1].NameSuffix)
+	assert.EqualValues(t, 990, series[1].Points[0].Value)
+	assert.Equal(t, ".99_9percentile", series[2].NameSuffix)
+	assert.EqualValues(t, 999, series[2].Points[0].Value)
+}
+
 func TestHistogramSampleRate(t *testing.T) {
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 
 	mHistogram.addSample(&MetricSample{Value: 1}, 50)
 	mHistogram.addSample(&MetricSample{Value: 2, SampleRate: 0.5}, 50)
@@ -237,7 +287,7 @@ func TestHistogramSampleRate(t *testing.T) {
 
 func TestHistogramReset(t *testing.T) {
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 
 	mHistogram.addSample(&MetricSample{Value: 1}, 50)
 	mHistogram.addSample(&MetricSample{Value: 2, SampleRate: 0.5}, 50)
@@ -283,7 +333,7 @@ func TestHistogramReset(t *testing.T) {
 func benchHistogram(b *testing.B, number int, sampleRate float64) {
 	for n := 0; n < b.N; n++ {
 		h := NewHistogram(1)
-		h.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+		h.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 		m := MetricSample{Value: 21, SampleRate: sampleRate}
 
 		for i := 0; i < number; i++ {
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+// dynamoDBKeepValues holds the keys of DynamoDB requests whose values are never
+// obfuscated. They hold table, index and attribute names and the expressions,
+// which refer to the actual values using placeholders (e.g. ":id").
+var dynamoDBKeepValues = []string{
+	"TableName",
+	"IndexName",
+	"Select",
+	"Limit",
+	"ConsistentRead",
+	"ScanIndexForward",
+	"AttributesToGet",
+	"ProjectionExpression",
+	"KeyConditionExpression",
+	"FilterExpression",
+	"ConditionExpression",
+	"UpdateExpression",
+	"ExpressionAttributeNames",
+	"ReturnValues",
+	"ReturnConsumedCapacity",
+	"ReturnItemCollectionMetrics",
+}
+
+// ObfuscateDynamoDBString obfuscates the given DynamoDB JSON request. The values of
+// the items, keys and expression attributes are obfuscated, while the table names
+// and the expressions are kept.
+func (o *Obfuscator) ObfuscateDynamoDBString(cmd string) string {
+	return obfuscateJSONString(cmd, o.dynamodb)
+}
+
+// newDynamoDBObfuscator returns a JSON obfuscator keeping the values of the
+// dynamoDBKeepValues keys, in addition to the ones set in cfg.
+func newDynamoDBObfuscator(cfg JSONConfig, o *Obfuscator) *jsonObfuscator {
+	keep := make([]string, 0, len(dynamoDBKeepValues)+len(cfg.KeepValues))
+	keep = append(keep, dynamoDBKeepValues...)
+	cfg.KeepValues = append(keep, cfg.KeepValues...)
+	return newJSONObfuscator(&cfg, o)
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateDynamoDB(t *testing.T) {
+	for _, tt := range []struct {
+		keep    []string
+		in, out string
+	}{
+		{
+			in:  `{"TableName": "users", "Key": {"id": {"S": "1234"}}}`,
+			out: `{"TableName":"users","Key":{"id":{"S":"?"}}}`,
+		},
+		{
+			in:  `{"TableName": "users", "Item": {"id": {"S": "1234"}, "age": {"N": "42"}, "tags": {"SS": ["a", "b"]}}}`,
+			out: `{"TableName":"users","Item":{"id":{"S":"?"},"age":{"N":"?"},"tags":{"SS":["?","?"]}}}`,
+		},
+		{
+			in:  `{"TableName": "orders", "IndexName": "by-user", "KeyConditionExpression": "#u = :u", "ExpressionAttributeNames": {"#u": "user_id"}, "ExpressionAttributeValues": {":u": {"S": "john"}}, "Limit": 10}`,
+			out: `{"TableName":"orders","IndexName":"by-user","KeyConditionExpression":"#u = :u","ExpressionAttributeNames":{"#u":"user_id"},"ExpressionAttributeValues":{":u":{"S":"?"}},"Limit":10}`,
+		},
+		{
+			keep: []string{"ExclusiveStartKey"},
+			in:   `{"TableName": "users", "ExclusiveStartKey": {"id": {"S": "1234"}}, "FilterExpression": "age > :a", "ExpressionAttributeValues": {":a": {"N": "18"}}}`,
+			out:  `{"TableName":"users","ExclusiveStartKey":{"id":{"S":"1234"}},"FilterExpression":"age > :a","ExpressionAttributeValues":{":a":{"N":"?"}}}`,
+		},
+		{
+			// invalid JSON is obfuscated as much as possible
+			in:  `{"TableName": "users", "Key": {"id": {"S": "1234"`,
+			out: `{"TableName":"users","Key":{"id":{"S":"?"...`,
+		},
+	} {
+		o := NewObfuscator(Config{DynamoDB: JSONConfig{Enabled: true, KeepValues: tt.keep}})
+		assert.Equal(t, tt.out, o.ObfuscateDynamoDBString(tt.in))
+	}
+
+	// disabled
+	in := `{"TableName": "users", "Key": {"id": {"S": "1234"}}}`
+	assert.Equal(t, in, NewObfuscator(Config{}).ObfuscateDynamoDBString(in))
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+// ObfuscateKafkaKey obfuscates the given Kafka message key. Message keys are
+// arbitrary bytes, usually identifying a user or an entity, so they are
+// replaced entirely.
+func (*Obfuscator) ObfuscateKafkaKey(key string) string {
+	if key == "" {
+		return key
+	}
+	return "?"
+}
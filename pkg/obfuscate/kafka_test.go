@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateKafkaKey(t *testing.T) {
+	o := NewObfuscator(Config{})
+	assert.Equal(t, "?", o.ObfuscateKafkaKey("user-1234"))
+	assert.Equal(t, "?", o.ObfuscateKafkaKey(`{"id": 1234}`))
+	assert.Equal(t, "", o.ObfuscateKafkaKey(""))
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import "strings"
+
+// ObfuscateKeyValueString obfuscates the given commands of a generic key/value
+// store, written one per line. Unlike ObfuscateRedisString, it doesn't know the
+// commands: it keeps the command and its first argument, the key, and replaces
+// all the other arguments by a single "?". For example:
+//
+//	SET user:1 "John Doe" EX 60  =>  SET user:1 ?
+func (*Obfuscator) ObfuscateKeyValueString(cmd string) string {
+	t := newRedisTokenizer([]byte(cmd))
+	var (
+		str   strings.Builder
+		nargs int
+	)
+	for {
+		tok, typ, done := t.scan()
+		switch typ {
+		case redisTokenCommand:
+			// new command starting
+			if str.Len() > 0 {
+				str.WriteByte('\n')
+			}
+			str.WriteString(tok)
+			nargs = 0
+		case redisTokenArgument:
+			nargs++
+			switch nargs {
+			case 1:
+				// the key
+				str.WriteByte(' ')
+				str.WriteString(tok)
+			case 2:
+				str.WriteString(" ?")
+			}
+		}
+		if done {
+			break
+		}
+	}
+	return str.String()
+}
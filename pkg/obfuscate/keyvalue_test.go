@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateKeyValue(t *testing.T) {
+	o := NewObfuscator(Config{})
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			"GET user:1",
+			"GET user:1",
+		},
+		{
+			"SET user:1 value",
+			"SET user:1 ?",
+		},
+		{
+			`SET user:1 "John Doe" EX 60`,
+			"SET user:1 ?",
+		},
+		{
+			"PUT /config/db/password s3cr3t",
+			"PUT /config/db/password ?",
+		},
+		{
+			"HSET user:1 name john\nGET user:2\nDEL user:3",
+			"HSET user:1 ?\nGET user:2\nDEL user:3",
+		},
+		{
+			"PING",
+			"PING",
+		},
+		{
+			"",
+			"",
+		},
+	} {
+		assert.Equal(t, tt.out, o.ObfuscateKeyValueString(tt.in))
+	}
+}
@@ -25,6 +25,7 @@ type Obfuscator struct {
 	opts                 *Config
 	es                   *jsonObfuscator // nil if disabled
 	mongo                *jsonObfuscator // nil if disabled
+	dynamodb             *jsonObfuscator // nil if disabled
 	sqlExecPlan          *jsonObfuscator // nil if disabled
 	sqlExecPlanNormalize *jsonObfuscator // nil if disabled
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
@@ -74,6 +75,9 @@ type Config struct {
 	// Mongo holds the obfuscation configuration for MongoDB queries.
 	Mongo JSONConfig
 
+	// DynamoDB holds the obfuscation configuration for DynamoDB JSON requests.
+	DynamoDB JSONConfig
+
 	// SQLExecPlan holds the obfuscation configuration for SQL Exec Plans. This is strictly for safety related obfuscation,
 	// not normalization. Normalization of exec plans is configured in SQLExecPlanNormalize.
 	SQLExecPlan JSONConfig
@@ -102,8 +106,14 @@ type StatsClient interface {
 type SQLConfig struct {
 	// DBMS identifies the type of database management system (e.g. MySQL, Postgres, and SQL Server).
 	// Valid values for this can be found at https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/trace/semantic_conventions/database.md#connection-level-attributes
+	// The tokenizer applies the quoting rules of the dialects defined by the DBMS* constants.
 	DBMS string `json:"dbms"`
 
+	// KeepIdentifierQuotation specifies whether the quoted identifiers of the DBMS dialect, such as
+	// the MySQL `name` or the SQL Server [name], should be kept with their quotes. By default, they
+	// are unquoted like with the generic rules.
+	KeepIdentifierQuotation bool `json:"keep_identifier_quotation"`
+
 	// TableNames specifies whether the obfuscator should also extract the table names that a query addresses,
 	// in addition to obfuscating.
 	TableNames bool `json:"table_names"`
@@ -184,6 +194,9 @@ func NewObfuscator(cfg Config) *Obfuscator {
 	if cfg.Mongo.Enabled {
 		o.mongo = newJSONObfuscator(&cfg.Mongo, &o)
 	}
+	if cfg.DynamoDB.Enabled {
+		o.dynamodb = newDynamoDBObfuscator(cfg.DynamoDB, &o)
+	}
 	if cfg.SQLExecPlan.Enabled {
 		o.sqlExecPlan = newJSONObfuscator(&cfg.SQLExecPlan, &o)
 	}
@@ -46,12 +46,14 @@ func TestNewObfuscator(t *testing.T) {
 	assert.Nil(o.mongo)
 
 	o = NewObfuscator(Config{
-		ES:    JSONConfig{Enabled: true},
-		Mongo: JSONConfig{Enabled: true},
+		ES:       JSONConfig{Enabled: true},
+		Mongo:    JSONConfig{Enabled: true},
+		DynamoDB: JSONConfig{Enabled: true},
 	})
 	defer o.Stop()
 	assert.NotNil(o.es)
 	assert.NotNil(o.mongo)
+	assert.NotNil(o.dynamodb)
 }
 
 func TestCompactWhitespaces(t *testing.T) {
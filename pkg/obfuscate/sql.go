@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -23,6 +24,8 @@ type metadataFinderFilter struct {
 	collectCommands   bool
 	collectComments   bool
 	replaceDigits     bool
+	// keepIdentifierQuotation reports whether table names may start with an identifier quote.
+	keepIdentifierQuotation bool
 
 	// size holds the byte size of the metadata collected by the filter.
 	size int64
@@ -57,7 +60,7 @@ func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte)
 			// SELECT ... FROM [tableName]
 			// DELETE FROM [tableName]
 			// ... JOIN [tableName]
-			if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) {
+			if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) && !(f.keepIdentifierQuotation && isIdentifierQuote(r)) {
 				// first character in buffer is not a letter; we might have a nested
 				// query like SELECT * FROM (SELECT ...)
 				break
@@ -296,17 +299,44 @@ func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
 // to quantize and obfuscate the given input SQL query string. Quantization removes some elements such as comments
 // and aliases and obfuscation attempts to hide sensitive information in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLStringWithOptions(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
-	if v, ok := o.queryCache.Get(in); ok {
+	key := queryCacheKey(in, opts)
+	if v, ok := o.queryCache.Get(key); ok {
 		return v.(*ObfuscatedQuery), nil
 	}
 	oq, err := o.obfuscateSQLString(in, opts)
 	if err != nil {
 		return oq, err
 	}
-	o.queryCache.Set(in, oq, oq.Cost())
+	o.queryCache.Set(key, oq, oq.Cost())
 	return oq, nil
 }
 
+// queryCacheKey returns the key of the query in the cache. The same query is tokenized differently
+// depending on the dialect and on the quotation of its identifiers, so the keys of the queries using
+// either start with a NUL character followed by the length-prefixed DBMS and the quotation flag. The
+// queries starting with a NUL character are prefixed the same way, so that no two keys can collide.
+func queryCacheKey(in string, opts *SQLConfig) string {
+	if opts.DBMS == "" && !opts.KeepIdentifierQuotation && !strings.HasPrefix(in, "\x00") {
+		return in
+	}
+	quotation := "0"
+	if opts.KeepIdentifierQuotation {
+		quotation = "1"
+	}
+	return "\x00" + strconv.Itoa(len(opts.DBMS)) + ":" + opts.DBMS + quotation + in
+}
+
+// ObfuscateSQLStringForDBMS quantizes and obfuscates the given input SQL query string like ObfuscateSQLString,
+// using the dialect of the given database management system. See the DBMS* constants for the supported ones.
+func (o *Obfuscator) ObfuscateSQLStringForDBMS(in, dbms string) (*ObfuscatedQuery, error) {
+	if dbms == o.opts.SQL.DBMS {
+		return o.ObfuscateSQLString(in)
+	}
+	opts := o.opts.SQL
+	opts.DBMS = dbms
+	return o.ObfuscateSQLStringWithOptions(in, &opts)
+}
+
 func (o *Obfuscator) obfuscateSQLString(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
 	lesc := o.useSQLLiteralEscapes()
 	tok := NewSQLTokenizer(in, lesc, opts)
@@ -348,6 +378,8 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 			collectCommands:   tokenizer.cfg.CollectCommands,
 			collectComments:   tokenizer.cfg.CollectComments,
 			replaceDigits:     tokenizer.cfg.ReplaceDigits,
+
+			keepIdentifierQuotation: tokenizer.cfg.KeepIdentifierQuotation,
 		}
 		discard  = discardFilter{keepSQLAlias: tokenizer.cfg.KeepSQLAlias}
 		replace  = replaceFilter{replaceDigits: tokenizer.cfg.ReplaceDigits}
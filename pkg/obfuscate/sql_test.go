@@ -7,8 +7,11 @@ package obfuscate
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync/atomic"
 	"testing"
@@ -1188,6 +1191,108 @@ func TestObfuscatorDBMSBehavior(t *testing.T) {
 	}
 }
 
+// sqlDBMSTests is the folder holding the corpus of queries of each supported DBMS.
+const sqlDBMSTests = "./testdata/dbms"
+
+type xmlSQLDBMSTests struct {
+	XMLName xml.Name          `xml:"ObfuscateTests"`
+	Tests   []*xmlSQLDBMSTest `xml:"Test"`
+}
+
+type xmlSQLDBMSTest struct {
+	Tag                     string
+	KeepIdentifierQuotation bool
+	SameAsGeneric           bool
+	In                      string
+	Out                     string
+	Tables                  string
+	Error                   string
+}
+
+func TestSQLDBMSCorpus(t *testing.T) {
+	for _, dbms := range []string{DBMSMySQL, DBMSPostgres, DBMSSQLServer, DBMSOracle} {
+		t.Run(dbms, func(t *testing.T) {
+			f, err := os.Open(filepath.Join(sqlDBMSTests, dbms+".xml"))
+			require.NoError(t, err)
+			defer f.Close()
+			var suite xmlSQLDBMSTests
+			require.NoError(t, xml.NewDecoder(f).Decode(&suite))
+			require.NotEmpty(t, suite.Tests)
+
+			for _, tt := range suite.Tests {
+				t.Run(tt.Tag, func(t *testing.T) {
+					check := func(cfg SQLConfig) {
+						o := NewObfuscator(Config{SQL: cfg})
+						defer o.Stop()
+						oq, err := o.ObfuscateSQLString(tt.In)
+						if tt.Error != "" {
+							require.Error(t, err)
+							assert.Contains(t, err.Error(), tt.Error)
+							return
+						}
+						require.NoError(t, err)
+						assert.Equal(t, tt.Out, oq.Query)
+						assert.Equal(t, tt.Tables, oq.Metadata.TablesCSV)
+					}
+					check(SQLConfig{DBMS: dbms, TableNames: true, KeepIdentifierQuotation: tt.KeepIdentifierQuotation})
+					if tt.SameAsGeneric {
+						// the dialect must not change the output of the generic rules
+						check(SQLConfig{TableNames: true})
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestObfuscateSQLStringForDBMS(t *testing.T) {
+	assert := assert.New(t)
+	o := NewObfuscator(Config{SQL: SQLConfig{Cache: true}})
+	defer o.Stop()
+	query := `SELECT * FROM users WHERE name IN ("alice", "bob")`
+
+	oq, err := o.ObfuscateSQLStringForDBMS(query, DBMSMySQL)
+	assert.NoError(err)
+	assert.Equal("SELECT * FROM users WHERE name IN ( ? )", oq.Query)
+	o.queryCache.Wait()
+
+	// the result of another dialect is not taken from the cache
+	oq, err = o.ObfuscateSQLStringForDBMS(query, DBMSPostgres)
+	assert.NoError(err)
+	assert.Equal("SELECT * FROM users WHERE name IN ( alice, bob )", oq.Query)
+	o.queryCache.Wait()
+
+	oq, err = o.ObfuscateSQLStringForDBMS(query, "")
+	assert.NoError(err)
+	assert.Equal("SELECT * FROM users WHERE name IN ( alice, bob )", oq.Query)
+
+	// nor is the result of the same dialect keeping the identifier quotation
+	oq, err = o.ObfuscateSQLStringWithOptions(query, &SQLConfig{DBMS: DBMSPostgres, KeepIdentifierQuotation: true})
+	assert.NoError(err)
+	assert.Equal(`SELECT * FROM users WHERE name IN ( "alice", "bob" )`, oq.Query)
+}
+
+func TestQueryCacheKey(t *testing.T) {
+	keys := make(map[string]string)
+	for name, tt := range map[string]struct {
+		in   string
+		opts SQLConfig
+	}{
+		"generic":           {"mysql:SELECT 1", SQLConfig{}},
+		"mysql":             {"SELECT 1", SQLConfig{DBMS: DBMSMySQL}},
+		"mysql-quotation":   {"SELECT 1", SQLConfig{DBMS: DBMSMySQL, KeepIdentifierQuotation: true}},
+		"generic-quotation": {"SELECT 1", SQLConfig{KeepIdentifierQuotation: true}},
+		"generic-nul":       {"\x005:mysql0SELECT 1", SQLConfig{}},
+		"nul-dbms":          {"SELECT 1", SQLConfig{DBMS: "\x005:mysql0"}},
+	} {
+		key := queryCacheKey(tt.in, &tt.opts)
+		other, ok := keys[key]
+		assert.False(t, ok, "%s and %s share the key %q", name, other, key)
+		keys[key] = name
+	}
+	assert.Equal(t, "SELECT 1", queryCacheKey("SELECT 1", &SQLConfig{}))
+}
+
 func TestSQLTokenizerIgnoreEscapeFalse(t *testing.T) {
 	cases := []sqlTokenizerTestCase{
 		{
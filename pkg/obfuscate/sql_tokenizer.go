@@ -150,9 +150,19 @@ func (k TokenKind) String() string {
 	return str
 }
 
+// The database management systems whose dialects are supported by the tokenizer. When
+// SQLConfig.DBMS is set to one of them, the tokenizer applies the quoting rules of the
+// dialect to strings, such as the MySQL double quoted strings, and to identifiers when
+// SQLConfig.KeepIdentifierQuotation is set. Otherwise, generic rules are used.
 const (
 	// DBMSSQLServer is a MS SQL Server
 	DBMSSQLServer = "mssql"
+	// DBMSPostgres is a PostgreSQL Server
+	DBMSPostgres = "postgresql"
+	// DBMSMySQL is a MySQL Server
+	DBMSMySQL = "mysql"
+	// DBMSOracle is an Oracle Server
+	DBMSOracle = "oracle"
 )
 
 const escapeCharacter = '\\'
@@ -244,6 +254,9 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 
 	switch ch := tkn.lastChar; {
 	case isLeadingLetter(ch):
+		if tkn.isStringPrefix() {
+			return tkn.scanPrefixedString()
+		}
 		return tkn.scanIdentifier()
 	case isDigit(ch):
 		return tkn.scanNumber(false)
@@ -280,7 +293,12 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			default:
 				return TokenKind(ch), tkn.bytes()
 			}
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '[', ']', '?':
+		case '[':
+			if tkn.startsQuotedIdentifier(ch) {
+				return tkn.scanQuotedIdentifier(ch)
+			}
+			return TokenKind(ch), tkn.bytes()
+		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', ']', '?':
 			return TokenKind(ch), tkn.bytes()
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -311,8 +329,15 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				return TokenKind(ch), tkn.bytes()
 			}
 		case '#':
-			if tkn.cfg.DBMS == DBMSSQLServer {
+			switch tkn.cfg.DBMS {
+			case DBMSSQLServer:
 				return tkn.scanIdentifier()
+			case DBMSPostgres, DBMSOracle:
+				// an operator, such as the Postgres JSON operators #> and #>>
+				for tkn.lastChar == '>' || tkn.lastChar == '-' {
+					tkn.advance()
+				}
+				return TokenKind(ch), tkn.bytes()
 			}
 			tkn.advance()
 			return tkn.scanCommentType1("#")
@@ -363,8 +388,18 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 		case '\'':
 			return tkn.scanString(ch, String)
 		case '"':
+			if tkn.startsQuotedIdentifier(ch) {
+				return tkn.scanQuotedIdentifier(ch)
+			}
+			if tkn.cfg.DBMS == DBMSMySQL {
+				// double quotes delimit strings in MySQL
+				return tkn.scanString(ch, String)
+			}
 			return tkn.scanString(ch, DoubleQuotedString)
 		case '`':
+			if tkn.startsQuotedIdentifier(ch) {
+				return tkn.scanQuotedIdentifier(ch)
+			}
 			return tkn.scanString(ch, ID)
 		case '%':
 			if tkn.lastChar == '(' {
@@ -471,8 +506,26 @@ func toUpper(src, dst []byte) []byte {
 
 func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	tkn.advance()
-	for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
+	return tkn.scanQualifiedName()
+}
+
+// scanQualifiedName scans the rest of an identifier. Using a dialect, the identifier
+// may be a qualified name made of quoted parts, such as "[dbo].[users]", which are
+// kept as they are.
+func (tkn *SQLTokenizer) scanQualifiedName() (TokenKind, []byte) {
+	for {
+		for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' ||
+			tkn.lastChar == '$' && tkn.isDialect() {
+			tkn.advance()
+		}
+		if !tkn.startsQuotedIdentifier(tkn.lastChar) || !tkn.followsDot() {
+			break
+		}
+		quote := tkn.lastChar
 		tkn.advance()
+		if !tkn.scanQuotedIdentifierPart(quote) {
+			return LexError, tkn.bytes()
+		}
 	}
 
 	t := tkn.bytes()
@@ -487,6 +540,168 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	return ID, t
 }
 
+// scanQuotedIdentifier scans an identifier starting with the given quote, which
+// was already consumed.
+func (tkn *SQLTokenizer) scanQuotedIdentifier(quote rune) (TokenKind, []byte) {
+	if !tkn.scanQuotedIdentifierPart(quote) {
+		return LexError, tkn.bytes()
+	}
+	return tkn.scanQualifiedName()
+}
+
+// scanQuotedIdentifierPart scans a quoted identifier up to its closing quote. A closing
+// quote is escaped by doubling it. It returns false if the identifier is not terminated.
+func (tkn *SQLTokenizer) scanQuotedIdentifierPart(quote rune) bool {
+	closing := quote
+	if quote == '[' {
+		closing = ']'
+	}
+	for {
+		ch := tkn.lastChar
+		if ch == EndChar {
+			tkn.setErr("unexpected EOF in quoted identifier")
+			return false
+		}
+		tkn.advance()
+		if ch == closing {
+			if tkn.lastChar != closing {
+				return true
+			}
+			tkn.advance()
+		}
+	}
+}
+
+// isDialect reports whether the tokenizer uses the rules of a specific dialect.
+func (tkn *SQLTokenizer) isDialect() bool {
+	switch tkn.cfg.DBMS {
+	case DBMSSQLServer, DBMSPostgres, DBMSMySQL, DBMSOracle:
+		return true
+	}
+	return false
+}
+
+// startsQuotedIdentifier reports whether ch starts a quoted identifier in the dialect,
+// which is kept with its quotes.
+func (tkn *SQLTokenizer) startsQuotedIdentifier(ch rune) bool {
+	if !tkn.cfg.KeepIdentifierQuotation {
+		return false
+	}
+	switch tkn.cfg.DBMS {
+	case DBMSSQLServer:
+		return ch == '[' || ch == '"'
+	case DBMSPostgres, DBMSOracle:
+		return ch == '"'
+	case DBMSMySQL:
+		return ch == '`'
+	}
+	return false
+}
+
+// followsDot reports whether the last read character follows a dot in the current token.
+func (tkn *SQLTokenizer) followsDot() bool {
+	if tkn.lastChar == EndChar {
+		return false
+	}
+	i := tkn.off - utf8.RuneLen(tkn.lastChar) - 1
+	return i >= 0 && tkn.buf[i] == '.'
+}
+
+// peek returns the character following the last read one, without advancing.
+func (tkn *SQLTokenizer) peek(n int) rune {
+	off := tkn.off
+	for ; n > 1; n-- {
+		_, size := utf8.DecodeRune(tkn.buf[off:])
+		if size == 0 {
+			return EndChar
+		}
+		off += size
+	}
+	ch, size := utf8.DecodeRune(tkn.buf[off:])
+	if size == 0 {
+		return EndChar
+	}
+	return ch
+}
+
+// isStringPrefix reports whether the last read letter starts a prefixed string
+// of the dialect, such as the SQL Server N'text' or the Oracle q'[text]'.
+func (tkn *SQLTokenizer) isStringPrefix() bool {
+	switch unicode.ToUpper(tkn.lastChar) {
+	case 'E':
+		return tkn.cfg.DBMS == DBMSPostgres && tkn.peek(1) == '\''
+	case 'N':
+		if tkn.cfg.DBMS != DBMSSQLServer && tkn.cfg.DBMS != DBMSOracle {
+			return false
+		}
+		if next := tkn.peek(1); next == '\'' {
+			return true
+		} else if tkn.cfg.DBMS == DBMSOracle && unicode.ToUpper(next) == 'Q' {
+			return tkn.peek(2) == '\''
+		}
+	case 'Q':
+		return tkn.cfg.DBMS == DBMSOracle && tkn.peek(1) == '\''
+	}
+	return false
+}
+
+// scanPrefixedString scans a string starting with a prefix reported by isStringPrefix.
+func (tkn *SQLTokenizer) scanPrefixedString() (TokenKind, []byte) {
+	prefix := unicode.ToUpper(tkn.lastChar)
+	tkn.advance()
+	if prefix == 'N' && tkn.lastChar != '\'' {
+		// Oracle NQ'[text]'
+		prefix = 'Q'
+		tkn.advance()
+	}
+	tkn.advance()
+	switch prefix {
+	case 'E':
+		// Postgres escape strings always use backslash escapes
+		return tkn.scanQuotedString('\'', String, true)
+	case 'Q':
+		return tkn.scanAlternativeQuotedString()
+	default:
+		return tkn.scanString('\'', String)
+	}
+}
+
+// scanAlternativeQuotedString scans an Oracle alternative quoted string, such as
+// q'[text]', whose opening quote was already consumed. The text ends with the
+// closing counter-part of the delimiter, followed by a quote.
+// See: https://docs.oracle.com/en/database/oracle/oracle-database/19/sqlrf/Literals.html#GUID-1824CBAA-6E16-4921-B2A6-112FB02248DA
+func (tkn *SQLTokenizer) scanAlternativeQuotedString() (TokenKind, []byte) {
+	delim := tkn.lastChar
+	if delim == EndChar || unicode.IsSpace(delim) {
+		tkn.setErr("invalid delimiter in quoted string")
+		return LexError, tkn.bytes()
+	}
+	closing := delim
+	switch delim {
+	case '[':
+		closing = ']'
+	case '{':
+		closing = '}'
+	case '(':
+		closing = ')'
+	case '<':
+		closing = '>'
+	}
+	tkn.advance()
+	for {
+		ch := tkn.lastChar
+		if ch == EndChar {
+			tkn.setErr("unexpected EOF in quoted string")
+			return LexError, tkn.bytes()
+		}
+		tkn.advance()
+		if ch == closing && tkn.lastChar == '\'' {
+			tkn.advance()
+			return String, tkn.bytes()
+		}
+	}
+}
+
 func (tkn *SQLTokenizer) scanVariableIdentifier(prefix rune) (TokenKind, []byte) {
 	for tkn.advance(); tkn.lastChar != ')' && tkn.lastChar != EndChar; tkn.advance() {
 	}
@@ -667,7 +882,20 @@ exit:
 	return Number, t
 }
 
+// scanString scans a string delimited by delim, whose opening delimiter was already consumed.
+// Backslashes are escape characters unless the dialect or the tokenizer treats them literally.
 func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []byte) {
+	escapes := !tkn.literalEscapes
+	switch tkn.cfg.DBMS {
+	case DBMSMySQL:
+		escapes = true
+	case DBMSSQLServer, DBMSOracle:
+		escapes = false
+	}
+	return tkn.scanQuotedString(delim, kind, escapes)
+}
+
+func (tkn *SQLTokenizer) scanQuotedString(delim rune, kind TokenKind, escapes bool) (TokenKind, []byte) {
 	buf := bytes.NewBuffer(tkn.buf[:0])
 	for {
 		ch := tkn.lastChar
@@ -683,7 +911,7 @@ func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []by
 		} else if ch == escapeCharacter {
 			tkn.seenEscape = true
 
-			if !tkn.literalEscapes {
+			if escapes {
 				// treat as an escape character
 				ch = tkn.lastChar
 				tkn.advance()
@@ -785,6 +1013,12 @@ func isLetter(ch rune) bool {
 	return isLeadingLetter(ch) || ch == '#'
 }
 
+// isIdentifierQuote reports whether ch may start a quoted identifier kept with its quotes,
+// in any dialect.
+func isIdentifierQuote(ch rune) bool {
+	return ch == '[' || ch == '`' || ch == '"'
+}
+
 func digitVal(ch rune) int {
 	switch {
 	case '0' <= ch && ch <= '9':
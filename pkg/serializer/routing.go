@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// metricRoute selects the metrics starting with one of the prefixes, when
+// there are prefixes, and having one of matchTags, when there are matchTags.
+type metricRoute struct {
+	prefixes []string
+	// matchTags are either complete tags (`key:value`), or tag keys matching
+	// any value of the tag.
+	matchTags []string
+}
+
+// metricRouter restricts the series and sketches sent to the domains having
+// routing rules to the ones matching at least one of the rules of the domain.
+// The other domains receive all the series and sketches.
+type metricRouter struct {
+	forwarder forwarder.DomainFilteringForwarder
+	routes    map[string][]metricRoute
+	// domains are the keys of routes, sorted
+	domains []string
+}
+
+// newMetricRouterFromConfig returns the router of the `metric_routing_rules`,
+// or nil when there is no rule.
+func newMetricRouterFromConfig(fwd forwarder.Forwarder) *metricRouter {
+	rules, err := config.GetMetricRoutingRules()
+	if err != nil || len(rules) == 0 {
+		return nil
+	}
+	filteringForwarder, ok := fwd.(forwarder.DomainFilteringForwarder)
+	if !ok {
+		log.Errorf("metric_routing_rules are not supported by this forwarder: the series and sketches are sent to all the domains")
+		return nil
+	}
+	return newMetricRouter(filteringForwarder, rules)
+}
+
+// newMetricRouter validates the rules. A domain with only invalid rules
+// receives none of the series and sketches.
+func newMetricRouter(fwd forwarder.DomainFilteringForwarder, rules []config.MetricRoutingRule) *metricRouter {
+	r := &metricRouter{
+		forwarder: fwd,
+		routes:    make(map[string][]metricRoute),
+	}
+	for i, rule := range rules {
+		if rule.Domain == "" {
+			log.Errorf("metric_routing_rules: the domain of rule %d is not set (skipping)", i)
+			continue
+		}
+		domain := normalizeRoutingDomain(rule.Domain)
+		if _, found := r.routes[domain]; !found {
+			r.routes[domain] = nil
+			r.domains = append(r.domains, domain)
+		}
+		if len(rule.MetricPrefixes) == 0 && len(rule.MatchTags) == 0 {
+			log.Errorf("metric_routing_rules: at least one of metric_prefixes and match_tags must be set in rule %d (skipping)", i)
+			continue
+		}
+		r.routes[domain] = append(r.routes[domain], metricRoute{
+			prefixes:  rule.MetricPrefixes,
+			matchTags: rule.MatchTags,
+		})
+	}
+	sort.Strings(r.domains)
+	return r
+}
+
+func normalizeRoutingDomain(domain string) string {
+	return strings.TrimSuffix(domain, "/")
+}
+
+func (r *metricRoute) matches(name string, tags []string) bool {
+	if len(r.prefixes) > 0 {
+		found := false
+		for _, prefix := range r.prefixes {
+			if strings.HasPrefix(name, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.matchTags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		key := tag
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key = tag[:i]
+		}
+		for _, matchTag := range r.matchTags {
+			if tag == matchTag || key == matchTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *metricRouter) matches(domain string, name string, tags []string) bool {
+	for i := range r.routes[domain] {
+		if r.routes[domain][i].matches(name, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// unroutedDomains selects the domains without routing rules.
+func (r *metricRouter) unroutedDomains(domain string) bool {
+	_, found := r.routes[normalizeRoutingDomain(domain)]
+	return !found
+}
+
+// routedDomain returns a filter selecting only domain.
+func routedDomain(domain string) forwarder.DomainFilter {
+	return func(d string) bool {
+		return normalizeRoutingDomain(d) == domain
+	}
+}
+
+// routeSeries returns the series sent to each domain having routing rules.
+// The domains receiving no serie are omitted.
+func (r *metricRouter) routeSeries(series metrics.Series) map[string]metrics.Series {
+	routed := make(map[string]metrics.Series, len(r.domains))
+	for _, serie := range series {
+		for _, domain := range r.domains {
+			if r.matches(domain, serie.Name, serie.Tags) {
+				routed[domain] = append(routed[domain], serie)
+			}
+		}
+	}
+	return routed
+}
+
+// routeSketches returns the sketches sent to each domain having routing rules.
+// The domains receiving no sketch are omitted.
+func (r *metricRouter) routeSketches(sketches metrics.SketchSeriesList) map[string]metrics.SketchSeriesList {
+	routed := make(map[string]metrics.SketchSeriesList, len(r.domains))
+	for _, sketch := range sketches {
+		for _, domain := range r.domains {
+			if r.matches(domain, sketch.Name, sketch.Tags) {
+				routed[domain] = append(routed[domain], sketch)
+			}
+		}
+	}
+	return routed
+}
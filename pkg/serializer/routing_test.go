@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package serializer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestMetricRouterRules(t *testing.T) {
+	r := newMetricRouter(nil, []config.MetricRoutingRule{
+		{Domain: "https://first.org", MetricPrefixes: []string{"payments.", "billing."}},
+		{Domain: "https://first.org", MatchTags: []string{"team:payments"}},
+		{Domain: "https://second.org/", MetricPrefixes: []string{"api."}, MatchTags: []string{"env"}},
+		{Domain: "https://invalid.org"},
+		{MetricPrefixes: []string{"no.domain."}},
+	})
+
+	assert.Equal(t, []string{"https://first.org", "https://invalid.org", "https://second.org"}, r.domains)
+
+	assert.True(t, r.matches("https://first.org", "billing.count", nil))
+	assert.True(t, r.matches("https://first.org", "api.count", []string{"team:payments"}))
+	assert.False(t, r.matches("https://first.org", "api.count", []string{"team:other"}))
+
+	// both the prefix and the tag must match
+	assert.True(t, r.matches("https://second.org", "api.count", []string{"env:prod"}))
+	assert.False(t, r.matches("https://second.org", "api.count", []string{"team:payments"}))
+	assert.False(t, r.matches("https://second.org", "web.count", []string{"env:prod"}))
+
+	// the domains with only invalid rules receive nothing
+	assert.False(t, r.matches("https://invalid.org", "api.count", nil))
+
+	assert.True(t, r.unroutedDomains("https://app.datadoghq.com"))
+	assert.False(t, r.unroutedDomains("https://second.org/"))
+	assert.False(t, r.unroutedDomains("https://invalid.org"))
+
+	assert.True(t, routedDomain("https://second.org")("https://second.org/"))
+	assert.False(t, routedDomain("https://second.org")("https://first.org"))
+}
+
+func TestMetricRouterRouteSeries(t *testing.T) {
+	r := newMetricRouter(nil, []config.MetricRoutingRule{
+		{Domain: "https://first.org", MatchTags: []string{"team:payments"}},
+		{Domain: "https://second.org", MetricPrefixes: []string{"api."}},
+		{Domain: "https://third.org", MetricPrefixes: []string{"none."}},
+	})
+
+	payments := &metrics.Serie{Name: "api.count", Tags: []string{"team:payments"}}
+	api := &metrics.Serie{Name: "api.errors", Tags: []string{"team:api"}}
+	other := &metrics.Serie{Name: "web.count", Tags: []string{"team:web"}}
+
+	routed := r.routeSeries(metrics.Series{payments, api, other})
+	require.Len(t, routed, 2)
+	assert.Equal(t, metrics.Series{payments}, routed["https://first.org"])
+	assert.Equal(t, metrics.Series{payments, api}, routed["https://second.org"])
+
+	routedSketches := r.routeSketches(metrics.SketchSeriesList{
+		{Name: "web.latency", Tags: []string{"team:payments"}},
+		{Name: "web.latency", Tags: []string{"team:web"}},
+	})
+	require.Len(t, routedSketches, 1)
+	require.Len(t, routedSketches["https://first.org"], 1)
+	assert.Equal(t, []string{"team:payments"}, routedSketches["https://first.org"][0].Tags)
+}
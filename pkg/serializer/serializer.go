@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
@@ -130,6 +131,10 @@ type Serializer struct {
 	enableServiceChecksJSONStream bool
 	enableEventsJSONStream        bool
 	enableSketchProtobufStream    bool
+
+	// router restricts the series and sketches sent to the domains having
+	// `metric_routing_rules`, it is nil when there is no rule.
+	router *metricRouter
 }
 
 // NewSerializer returns a new Serializer initialized
@@ -148,6 +153,7 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcy
 		enableServiceChecksJSONStream: stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
 		enableEventsJSONStream:        stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
 		enableSketchProtobufStream:    stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
+		router:                        newMetricRouterFromConfig(forwarder),
 	}
 
 	if !s.enableEvents {
@@ -332,8 +338,9 @@ func (s *Serializer) SendIterableSeries(series marshaler.IterableMarshaler) erro
 
 // IsIterableSeriesSupported returns whether `SendIterableSeries` is supported.
 // Should be removed when `serializePayloadJSON` (useV1API && !s.enableJSONStream) will be removed
+// The series can't be routed while they are serialized, so it isn't supported with `metric_routing_rules`.
 func (s *Serializer) IsIterableSeriesSupported() bool {
-	return config.Datadog.GetBool("use_v2_api.series") || s.enableJSONStream
+	return s.router == nil && (config.Datadog.GetBool("use_v2_api.series") || s.enableJSONStream)
 }
 
 // SendSeries serializes a list of serviceChecks and sends the payload to the forwarder
@@ -345,29 +352,57 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 
 	useV1API := !config.Datadog.GetBool("use_v2_api.series")
 
-	var seriesPayloads forwarder.Payloads
-	var extraHeaders http.Header
-	var err error
-
-	if useV1API && s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, stream.DropItemOnErrItemTooBig)
-	} else if useV1API && !s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializePayloadJSON(series, true)
-	} else {
-		seriesPayloads, err = series.MarshalSplitCompress(marshaler.DefaultBufferContext())
-		extraHeaders = protobufExtraHeadersWithCompression
-	}
-
+	seriesPayloads, extraHeaders, err := s.serializeSeries(series, useV1API)
 	if err != nil {
 		return fmt.Errorf("dropping series payload: %s", err)
 	}
 
+	if s.router != nil {
+		return s.sendRoutedSeries(series, seriesPayloads, extraHeaders, useV1API)
+	}
+
 	if useV1API {
 		return s.Forwarder.SubmitV1Series(seriesPayloads, extraHeaders)
 	}
 	return s.Forwarder.SubmitSeries(seriesPayloads, extraHeaders)
 }
 
+func (s *Serializer) serializeSeries(series marshaler.StreamJSONMarshaler, useV1API bool) (forwarder.Payloads, http.Header, error) {
+	if useV1API && s.enableJSONStream {
+		return s.serializeStreamablePayload(series, stream.DropItemOnErrItemTooBig)
+	} else if useV1API && !s.enableJSONStream {
+		return s.serializePayloadJSON(series, true)
+	}
+	seriesPayloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	return seriesPayloads, protobufExtraHeadersWithCompression, err
+}
+
+// sendRoutedSeries sends all the series to the domains without routing rules, and
+// the series matching the rules of each other domain to it.
+func (s *Serializer) sendRoutedSeries(series marshaler.StreamJSONMarshaler, seriesPayloads forwarder.Payloads, extraHeaders http.Header, useV1API bool) error {
+	submit := s.router.forwarder.SubmitSeriesToDomains
+	if useV1API {
+		submit = s.router.forwarder.SubmitV1SeriesToDomains
+	}
+
+	err := submit(seriesPayloads, extraHeaders, s.router.unroutedDomains)
+
+	allSeries, ok := series.(metrics.Series)
+	if !ok {
+		return err
+	}
+	for domain, routedSeries := range s.router.routeSeries(allSeries) {
+		routedPayloads, routedHeaders, routeErr := s.serializeSeries(routedSeries, useV1API)
+		if routeErr == nil {
+			routeErr = submit(routedPayloads, routedHeaders, routedDomain(domain))
+		}
+		if routeErr != nil {
+			err = fmt.Errorf("dropping series payload for %s: %s", domain, routeErr)
+		}
+	}
+	return err
+}
+
 // SendSketch serializes a list of SketSeriesList and sends the payload to the forwarder
 func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 	if !s.enableSketches {
@@ -375,22 +410,51 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 		return nil
 	}
 
+	splitSketches, extraHeaders, err := s.serializeSketches(sketches)
+	if err != nil {
+		return fmt.Errorf("dropping sketch payload: %s", err)
+	}
+
+	if s.router != nil {
+		return s.sendRoutedSketches(sketches, splitSketches, extraHeaders)
+	}
+
+	return s.Forwarder.SubmitSketchSeries(splitSketches, extraHeaders)
+}
+
+func (s *Serializer) serializeSketches(sketches marshaler.Marshaler) (forwarder.Payloads, http.Header, error) {
 	if s.enableSketchProtobufStream {
 		payloads, err := sketches.MarshalSplitCompress(marshaler.DefaultBufferContext())
 		if err == nil {
-			return s.Forwarder.SubmitSketchSeries(payloads, protobufExtraHeadersWithCompression)
+			return payloads, protobufExtraHeadersWithCompression, nil
 		}
 		log.Warnf("Error: %v trying to stream compress SketchSeriesList - falling back to split/compress method", err)
 	}
 
 	compress := true
 	useV1API := false // Sketches only have a v2 endpoint
-	splitSketches, extraHeaders, err := s.serializePayload(sketches, compress, useV1API)
-	if err != nil {
-		return fmt.Errorf("dropping sketch payload: %s", err)
-	}
+	return s.serializePayload(sketches, compress, useV1API)
+}
 
-	return s.Forwarder.SubmitSketchSeries(splitSketches, extraHeaders)
+// sendRoutedSketches sends all the sketches to the domains without routing rules, and
+// the sketches matching the rules of each other domain to it.
+func (s *Serializer) sendRoutedSketches(sketches marshaler.Marshaler, splitSketches forwarder.Payloads, extraHeaders http.Header) error {
+	err := s.router.forwarder.SubmitSketchSeriesToDomains(splitSketches, extraHeaders, s.router.unroutedDomains)
+
+	allSketches, ok := sketches.(metrics.SketchSeriesList)
+	if !ok {
+		return err
+	}
+	for domain, routedSketches := range s.router.routeSketches(allSketches) {
+		routedPayloads, routedHeaders, routeErr := s.serializeSketches(routedSketches)
+		if routeErr == nil {
+			routeErr = s.router.forwarder.SubmitSketchSeriesToDomains(routedPayloads, routedHeaders, routedDomain(domain))
+		}
+		if routeErr != nil {
+			err = fmt.Errorf("dropping sketch payload for %s: %s", domain, routeErr)
+		}
+	}
+	return err
 }
 
 // SendMetadata serializes a metadata payload and sends it to the forwarder
@@ -20,6 +20,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
@@ -327,6 +329,48 @@ func TestSendSketch(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestSendRoutedSeriesAndSketches(t *testing.T) {
+	config.Datadog.Set("metric_routing_rules", []map[string]interface{}{
+		{"domain": "https://second.org/", "match_tags": []string{"team:payments"}},
+	})
+	defer config.Datadog.Set("metric_routing_rules", nil)
+
+	unrouted := mock.MatchedBy(func(filter forwarder.DomainFilter) bool {
+		return filter("https://app.datadoghq.com") && !filter("https://second.org")
+	})
+	routed := mock.MatchedBy(func(filter forwarder.DomainFilter) bool {
+		return !filter("https://app.datadoghq.com") && filter("https://second.org")
+	})
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1SeriesToDomains", mock.Anything, mock.Anything, unrouted).Return(nil).Times(2)
+	f.On("SubmitV1SeriesToDomains", mock.Anything, mock.Anything, routed).Return(nil).Times(1)
+	f.On("SubmitSketchSeriesToDomains", mock.Anything, mock.Anything, unrouted).Return(nil).Times(1)
+	f.On("SubmitSketchSeriesToDomains", mock.Anything, mock.Anything, routed).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil)
+	require.NotNil(t, s.router)
+	assert.False(t, s.IsIterableSeriesSupported())
+
+	series := metrics.Series{
+		{Name: "payments.count", Tags: []string{"team:payments"}, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+		{Name: "other.count", Tags: []string{"team:other"}, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+	}
+	require.NoError(t, s.SendSeries(series))
+
+	// no payload is sent to the routed domain when no serie matches its rules
+	require.NoError(t, s.SendSeries(series[1:]))
+
+	agent := &quantile.Agent{}
+	agent.Insert(1, 1)
+	sketches := metrics.SketchSeriesList{
+		{Name: "payments.latency", Tags: []string{"team:payments"}, Points: []metrics.SketchPoint{{Ts: 1, Sketch: agent.Finish()}}},
+	}
+	require.NoError(t, s.SendSketch(sketches))
+
+	f.AssertExpectations(t)
+}
+
 func TestSendMetadata(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	f.On("SubmitMetadata", jsonPayloads, jsonExtraHeadersWithCompression).Return(nil).Times(1)
@@ -43,6 +43,7 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	SpanFilter            *filters.SpanFilter
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
@@ -56,6 +57,10 @@ type Agent struct {
 	obfuscator     *obfuscate.Obfuscator
 	cardObfuscator *ccObfuscator
 
+	// tailSampler samples the traces dropped by the samplers once complete. It
+	// is nil when tail sampling is disabled.
+	tailSampler *tailSampler
+
 	// ModifySpan will be called on all spans, if non-nil.
 	ModifySpan func(*pb.Span)
 
@@ -85,6 +90,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsChan),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		SpanFilter:            filters.NewSpanFilter(conf.FilterRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(),
@@ -100,6 +106,9 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf.OTLPReceiver)
+	if conf.TailSampling.Enabled {
+		agnt.tailSampler = newTailSampler(conf, agnt.sendTailChunk)
+	}
 	return agnt
 }
 
@@ -114,6 +123,7 @@ func (a *Agent) Run() {
 		a.NoPrioritySampler,
 		a.EventProcessor,
 		a.OTLPReceiver,
+		a.tailSampler,
 	} {
 		starter.Start()
 	}
@@ -168,6 +178,7 @@ func (a *Agent) loop() {
 				log.Error(err)
 			}
 			for _, stopper := range []interface{ Stop() }{
+				a.tailSampler, // sends its last decisions to the TraceWriter
 				a.Concentrator,
 				a.ClientStatsAggregator,
 				a.TraceWriter,
@@ -221,6 +232,21 @@ func (a *Agent) Process(p *api.Payload) {
 			continue
 		}
 
+		// The filter rules run before computing the root, as they may remove it.
+		spans, dropTrace := a.SpanFilter.Filter(chunk.Spans)
+		if dropTrace || len(spans) == 0 {
+			log.Debugf("Trace rejected by filter rules.")
+			atomic.AddInt64(&ts.TracesFiltered, 1)
+			atomic.AddInt64(&ts.SpansFiltered, tracen)
+			p.RemoveChunk(i)
+			continue
+		}
+		if n := int64(len(spans)); n < tracen {
+			atomic.AddInt64(&ts.SpansFiltered, tracen-n)
+			chunk.Spans = spans
+			tracen = n
+		}
+
 		// Root span is used to carry some trace-level metadata, such as sampling rate and priority.
 		root := traceutil.GetRoot(chunk.Spans)
 		normalizeChunk(chunk, root)
@@ -299,7 +325,7 @@ func (a *Agent) Process(p *api.Payload) {
 			statsInput.Traces = append(statsInput.Traces, pt)
 		}
 
-		numEvents, keep, filteredChunk := a.sample(ts, pt)
+		numEvents, keep, filteredChunk := a.sample(ts, pt, p.TracerPayload)
 		if !keep {
 			if numEvents == 0 {
 				// the trace was dropped and no analyzed span were kept
@@ -355,7 +381,7 @@ func (a *Agent) processStats(in pb.ClientStatsPayload, lang, tracerVersion strin
 		n := 0
 		for _, b := range group.Stats {
 			normalizeStatsGroup(&b, lang)
-			if !a.Blacklister.AllowsStat(&b) {
+			if !a.Blacklister.AllowsStat(&b) || !a.SpanFilter.AllowsStat(&b) {
 				continue
 			}
 			a.obfuscateStatsGroup(&b)
@@ -392,7 +418,11 @@ func (a *Agent) ProcessStats(in pb.ClientStatsPayload, lang, tracerVersion strin
 }
 
 // sample reports the number of events found in pt and whether the chunk should be kept as a trace.
-func (a *Agent) sample(ts *info.TagStats, pt traceutil.ProcessedTrace) (numEvents int64, keep bool, filteredChunk *pb.TraceChunk) {
+// The chunks dropped by the samplers are handed to the tail sampler when it is enabled, tp being
+// the tracer payload of the chunk: they are reported as dropped without events, and sent later on.
+// Their events are extracted right away, as the spans are shared with the Concentrator once this
+// function returns and must not be modified afterwards.
+func (a *Agent) sample(ts *info.TagStats, pt traceutil.ProcessedTrace, tp *pb.TracerPayload) (numEvents int64, keep bool, filteredChunk *pb.TraceChunk) {
 	priority, hasPriority := sampler.GetSamplingPriority(pt.TraceChunk)
 
 	if hasPriority {
@@ -406,7 +436,27 @@ func (a *Agent) sample(ts *info.TagStats, pt traceutil.ProcessedTrace) (numEvent
 	}
 
 	sampled := a.runSamplers(pt, hasPriority)
+	if !sampled && a.tailSampler != nil {
+		header := *tp
+		header.Chunks = nil
+		numEvents, events := a.extractEvents(ts, pt, false)
+		a.tailSampler.add(time.Now(), tailChunk{
+			payload:   &header,
+			trace:     pt,
+			events:    events,
+			numEvents: numEvents,
+			source:    ts,
+		})
+		return 0, false, nil
+	}
 
+	numEvents, filteredChunk = a.extractEvents(ts, pt, sampled)
+	return numEvents, sampled, filteredChunk
+}
+
+// extractEvents runs the event processor on pt. When the chunk isn't sampled, the returned
+// chunk is a copy of it flagged as dropped, only holding the events.
+func (a *Agent) extractEvents(ts *info.TagStats, pt traceutil.ProcessedTrace, sampled bool) (numEvents int64, filteredChunk *pb.TraceChunk) {
 	filteredChunk = pt.TraceChunk
 	if !sampled {
 		filteredChunk = new(pb.TraceChunk)
@@ -418,7 +468,29 @@ func (a *Agent) sample(ts *info.TagStats, pt traceutil.ProcessedTrace) (numEvent
 	atomic.AddInt64(&ts.EventsExtracted, int64(numExtracted))
 	atomic.AddInt64(&ts.EventsSampled, numEvents)
 
-	return numEvents, sampled, filteredChunk
+	return numEvents, filteredChunk
+}
+
+// sendTailChunk sends a chunk to the TraceWriter once its trace was sampled by the
+// tail sampler. Only the events of the chunk are sent when the trace is dropped.
+// It runs concurrently with the Concentrator and must not modify the spans.
+func (a *Agent) sendTailChunk(c tailChunk, keep bool) {
+	numEvents, chunk := c.numEvents, c.events
+	if keep {
+		chunk = c.trace.TraceChunk
+	} else if numEvents == 0 {
+		return
+	}
+	c.payload.Chunks = []*pb.TraceChunk{chunk}
+	ss := &writer.SampledChunks{
+		TracerPayload: c.payload,
+		Size:          chunk.Msgsize(),
+		EventCount:    numEvents,
+	}
+	if !chunk.DroppedTrace {
+		ss.SpanCount = int64(len(chunk.Spans))
+	}
+	a.TraceWriter.In <- ss
 }
 
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
@@ -150,6 +150,72 @@ func TestProcess(t *testing.T) {
 		assert.EqualValues(2, want.SpansFiltered)
 	})
 
+	t.Run("FilterRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.FilterRules = []*config.FilterRule{
+			{
+				Action: config.FilterActionDropTrace,
+				TagsRe: []config.FilterRuleTag{{Key: "http.useragent", Re: regexp.MustCompile("^kube-probe")}},
+			},
+			{
+				Action:    config.FilterActionDropSpan,
+				ServiceRe: regexp.MustCompile("^cache$"),
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		span := func(spanID uint64, service string, meta map[string]string) *pb.Span {
+			return &pb.Span{
+				TraceID:  spanID / 10,
+				SpanID:   spanID,
+				ParentID: spanID / 10 * 10,
+				Service:  service,
+				Name:     "request",
+				Resource: "GET /",
+				Start:    now.Add(-time.Second).UnixNano(),
+				Duration: (500 * time.Millisecond).Nanoseconds(),
+				Meta:     meta,
+			}
+		}
+		probe := testutil.TraceChunkWithSpans([]*pb.Span{
+			span(10, "web", map[string]string{"http.useragent": "kube-probe/1.21"}),
+			span(11, "cache", nil),
+		})
+		request := testutil.TraceChunkWithSpans([]*pb.Span{
+			span(20, "web", map[string]string{"http.useragent": "curl/7.79"}),
+			span(21, "cache", nil),
+			span(22, "db", nil),
+		})
+		cacheOnly := testutil.TraceChunkWithSpans([]*pb.Span{span(30, "cache", nil)})
+		for _, c := range []*pb.TraceChunk{probe, request, cacheOnly} {
+			c.Priority = int32(sampler.PriorityUserKeep)
+		}
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunks([]*pb.TraceChunk{probe, request, cacheOnly}),
+			Source:        want,
+		})
+		assert.EqualValues(t, 2, want.TracesFiltered)
+		assert.EqualValues(t, 4, want.SpansFiltered)
+
+		require.Len(t, agnt.TraceWriter.In, 1)
+		ss := <-agnt.TraceWriter.In
+		require.Len(t, ss.TracerPayload.Chunks, 1)
+		var services []string
+		for _, s := range ss.TracerPayload.Chunks[0].Spans {
+			services = append(services, s.Service)
+		}
+		assert.Equal(t, []string{"web", "db"}, services)
+
+		in := <-agnt.Concentrator.In
+		require.Len(t, in.Traces, 1)
+		assert.Len(t, in.Traces[0].TraceChunk.Spans, 2)
+	})
+
 	t.Run("BlacklistPayload", func(t *testing.T) {
 		// Regression test for DataDog/datadog-agent#6500
 		cfg := config.New()
@@ -352,6 +418,109 @@ func TestProcess(t *testing.T) {
 		// without missing a trace
 		assert.Equal(t, gotCount, 3)
 	})
+
+	t.Run("TailSampling", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.DisableRareSampler = true
+		cfg.TailSampling.Enabled = true
+		cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Type: "latency", MinDurationMs: 200}}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		chunk := func(traceID, spanID uint64, d time.Duration) *pb.TraceChunk {
+			return testutil.TraceChunkWithSpanAndPriority(&pb.Span{
+				Service:  "web",
+				Name:     "request",
+				Resource: "GET /",
+				TraceID:  traceID,
+				SpanID:   spanID,
+				Start:    now.Add(-time.Second).UnixNano(),
+				Duration: d.Nanoseconds(),
+			}, int32(sampler.PriorityAutoDrop))
+		}
+		tp := testutil.TracerPayloadWithChunks([]*pb.TraceChunk{
+			chunk(1, 1, 100*time.Millisecond),
+			chunk(2, 1, 100*time.Millisecond),
+			chunk(1, 2, 300*time.Millisecond),
+		})
+		tp.ContainerID = "container"
+		agnt.Process(&api.Payload{
+			TracerPayload: tp,
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+		// the dropped chunks are buffered until the end of the decision window
+		assert.Len(t, agnt.TraceWriter.In, 0)
+		assert.Len(t, agnt.tailSampler.traces, 2)
+
+		agnt.tailSampler.flush(time.Now().Add(cfg.TailSampling.DecisionWait), false)
+		var spanIDs [][2]uint64
+		for len(agnt.TraceWriter.In) > 0 {
+			ss := <-agnt.TraceWriter.In
+			require.Len(t, ss.TracerPayload.Chunks, 1)
+			assert.Equal(t, "container", ss.TracerPayload.ContainerID)
+			assert.EqualValues(t, 1, ss.SpanCount)
+			span := ss.TracerPayload.Chunks[0].Spans[0]
+			spanIDs = append(spanIDs, [2]uint64{span.TraceID, span.SpanID})
+		}
+		// only the slow trace is kept
+		assert.ElementsMatch(t, [][2]uint64{{1, 1}, {1, 2}}, spanIDs)
+	})
+
+	t.Run("TailSamplingEvents", func(t *testing.T) {
+		// the spans of the buffered chunks are read by the Concentrator while the tail sampler
+		// sends them, this test is meant to be run with the race detector.
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.DisableRareSampler = true
+		cfg.TailSampling.Enabled = true
+		cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Type: "service", Services: []string{"db"}}}
+		cfg.AnalyzedSpansByService = map[string]map[string]float64{"web": {"request": 1}}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			agnt.Concentrator.Add(<-agnt.Concentrator.In)
+		}()
+
+		chunk := func(traceID uint64, service string) *pb.TraceChunk {
+			return testutil.TraceChunkWithSpanAndPriority(&pb.Span{
+				Service:  service,
+				Name:     "request",
+				Resource: "GET /",
+				TraceID:  traceID,
+				SpanID:   1,
+				Start:    time.Now().Add(-time.Second).UnixNano(),
+				Duration: (100 * time.Millisecond).Nanoseconds(),
+			}, int32(sampler.PriorityAutoDrop))
+		}
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunks([]*pb.TraceChunk{chunk(1, "web"), chunk(2, "db")}),
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+		agnt.tailSampler.flush(time.Now().Add(cfg.TailSampling.DecisionWait), false)
+		<-done
+
+		got := make(map[uint64]*writer.SampledChunks)
+		for len(agnt.TraceWriter.In) > 0 {
+			ss := <-agnt.TraceWriter.In
+			require.Len(t, ss.TracerPayload.Chunks, 1)
+			got[ss.TracerPayload.Chunks[0].Spans[0].TraceID] = ss
+		}
+		require.Len(t, got, 2)
+		// the dropped trace only sends its event
+		assert.True(t, got[1].TracerPayload.Chunks[0].DroppedTrace)
+		assert.EqualValues(t, 1, got[1].EventCount)
+		assert.True(t, sampler.IsAnalyzedSpan(got[1].TracerPayload.Chunks[0].Spans[0]))
+		// the kept trace is sent whole
+		assert.False(t, got[2].TracerPayload.Chunks[0].DroppedTrace)
+		assert.EqualValues(t, 1, got[2].SpanCount)
+		assert.EqualValues(t, 0, got[2].EventCount)
+	})
 }
 
 func spansToChunk(spans ...*pb.Span) *pb.TraceChunk {
@@ -1309,6 +1478,7 @@ func TestConvertStats(t *testing.T) {
 		Blacklister: filters.NewBlacklister([]string{"blocked_resource"}),
 		obfuscator:  obfuscate.NewObfuscator(obfuscate.Config{}),
 		Replacer:    filters.NewReplacer([]*config.ReplaceRule{{Name: "http.status_code", Pattern: "400", Re: regexp.MustCompile("400"), Repl: "200"}}),
+		SpanFilter:  filters.NewSpanFilter(nil),
 		conf:        &config.AgentConfig{DefaultEnv: "agent_env", Hostname: "agent_hostname"},
 	}
 	for _, testCase := range testCases {
@@ -1413,7 +1583,7 @@ func TestSampleWithPriorityNone(t *testing.T) {
 	numEvents, keep, _ := agnt.sample(info.NewReceiverStats().GetTagStats(info.Tags{}), traceutil.ProcessedTrace{
 		TraceChunk: testutil.TraceChunkWithSpan(span),
 		Root:       span,
-	})
+	}, &pb.TracerPayload{})
 	assert.True(t, keep) // Score Sampler should keep the trace.
 	assert.EqualValues(t, numEvents, 0)
 }
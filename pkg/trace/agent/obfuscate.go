@@ -20,7 +20,13 @@ const (
 	tagMemcachedCommand = "memcached.command"
 	tagMongoDBQuery     = "mongodb.query"
 	tagElasticBody      = "elasticsearch.body"
+	tagOpenSearchBody   = "opensearch.body"
+	tagDynamoDBQuery    = "dynamodb.query"
+	tagKafkaMessageKey  = "kafka.message_key"
+	tagMessagingKey     = "messaging.kafka.message_key"
+	tagDBStatement      = "db.statement"
 	tagSQLQuery         = "sql.query"
+	tagDBType           = "db.type"
 	tagHTTPURL          = "http.url"
 )
 
@@ -28,14 +34,62 @@ const (
 	textNonParsable = "Non-parsable SQL query"
 )
 
+// defaultSpanTypeObfuscators maps span types to the obfuscator applied to their
+// spans, unless the configuration maps them to another one.
+var defaultSpanTypeObfuscators = map[string]string{
+	"sql":           config.ObfuscatorSQL,
+	"cassandra":     config.ObfuscatorSQL,
+	"redis":         config.ObfuscatorRedis,
+	"valkey":        config.ObfuscatorRedis,
+	"memcached":     config.ObfuscatorMemcached,
+	"web":           config.ObfuscatorHTTP,
+	"http":          config.ObfuscatorHTTP,
+	"mongodb":       config.ObfuscatorMongoDB,
+	"elasticsearch": config.ObfuscatorElasticsearch,
+	"opensearch":    config.ObfuscatorElasticsearch,
+	"dynamodb":      config.ObfuscatorDynamoDB,
+	"kafka":         config.ObfuscatorKafka,
+}
+
+// dbTypeDBMS maps the values of the "db.type" tag to the DBMS whose dialect is used
+// to obfuscate the SQL queries of the span.
+var dbTypeDBMS = map[string]string{
+	"mysql":      obfuscate.DBMSMySQL,
+	"mariadb":    obfuscate.DBMSMySQL,
+	"postgres":   obfuscate.DBMSPostgres,
+	"postgresql": obfuscate.DBMSPostgres,
+	"mssql":      obfuscate.DBMSSQLServer,
+	"sqlserver":  obfuscate.DBMSSQLServer,
+	"oracle":     obfuscate.DBMSOracle,
+}
+
+// spanTypeObfuscator returns the obfuscator to apply to the spans of the given type.
+func (a *Agent) spanTypeObfuscator(typ string) string {
+	if a.conf != nil && a.conf.Obfuscation != nil {
+		if name, ok := a.conf.Obfuscation.SpanTypes[typ]; ok {
+			return name
+		}
+	}
+	return defaultSpanTypeObfuscators[typ]
+}
+
+// obfuscateTag replaces the value of the tag k, if set, with the result of fn.
+func obfuscateTag(span *pb.Span, k string, fn func(string) string) {
+	v, ok := span.Meta[k]
+	if !ok || v == "" {
+		return
+	}
+	span.Meta[k] = fn(v)
+}
+
 func (a *Agent) obfuscateSpan(span *pb.Span) {
 	o := a.obfuscator
-	switch span.Type {
-	case "sql", "cassandra":
+	switch a.spanTypeObfuscator(span.Type) {
+	case config.ObfuscatorSQL:
 		if span.Resource == "" {
 			return
 		}
-		oq, err := o.ObfuscateSQLString(span.Resource)
+		oq, err := o.ObfuscateSQLStringForDBMS(span.Resource, dbTypeDBMS[span.Meta[tagDBType]])
 		if err != nil {
 			// we have an error, discard the SQL to avoid polluting user resources.
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
@@ -59,7 +113,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			return
 		}
 		traceutil.SetMeta(span, tagSQLQuery, oq.Query)
-	case "redis":
+	case config.ObfuscatorRedis:
 		span.Resource = o.QuantizeRedisString(span.Resource)
 		if a.conf.Obfuscation.Redis.Enabled {
 			if span.Meta == nil || span.Meta[tagRedisRawCommand] == "" {
@@ -68,7 +122,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			}
 			span.Meta[tagRedisRawCommand] = o.ObfuscateRedisString(span.Meta[tagRedisRawCommand])
 		}
-	case "memcached":
+	case config.ObfuscatorMemcached:
 		if a.conf.Obfuscation.Memcached.Enabled {
 			v, ok := span.Meta[tagMemcachedCommand]
 			if span.Meta == nil || !ok {
@@ -76,7 +130,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			}
 			span.Meta[tagMemcachedCommand] = o.ObfuscateMemcachedString(v)
 		}
-	case "web", "http":
+	case config.ObfuscatorHTTP:
 		if span.Meta == nil {
 			return
 		}
@@ -85,25 +139,34 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			return
 		}
 		span.Meta[tagHTTPURL] = o.ObfuscateURLString(v)
-	case "mongodb":
+	case config.ObfuscatorMongoDB:
 		v, ok := span.Meta[tagMongoDBQuery]
 		if span.Meta == nil || !ok {
 			return
 		}
 		span.Meta[tagMongoDBQuery] = o.ObfuscateMongoDBString(v)
-	case "elasticsearch":
-		v, ok := span.Meta[tagElasticBody]
-		if span.Meta == nil || !ok {
-			return
+	case config.ObfuscatorElasticsearch:
+		obfuscateTag(span, tagElasticBody, o.ObfuscateElasticSearchString)
+		obfuscateTag(span, tagOpenSearchBody, o.ObfuscateElasticSearchString)
+	case config.ObfuscatorDynamoDB:
+		obfuscateTag(span, tagDynamoDBQuery, o.ObfuscateDynamoDBString)
+	case config.ObfuscatorKafka:
+		if a.conf.Obfuscation.Kafka.Enabled {
+			obfuscateTag(span, tagKafkaMessageKey, o.ObfuscateKafkaKey)
+			obfuscateTag(span, tagMessagingKey, o.ObfuscateKafkaKey)
+		}
+	case config.ObfuscatorKeyValue:
+		if a.conf.Obfuscation.KeyValue.Enabled {
+			span.Resource = o.ObfuscateKeyValueString(span.Resource)
+			obfuscateTag(span, tagDBStatement, o.ObfuscateKeyValueString)
 		}
-		span.Meta[tagElasticBody] = o.ObfuscateElasticSearchString(v)
 	}
 }
 
 func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 	o := a.obfuscator
-	switch b.Type {
-	case "sql", "cassandra":
+	switch a.spanTypeObfuscator(b.Type) {
+	case config.ObfuscatorSQL:
 		oq, err := o.ObfuscateSQLString(b.Resource)
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
@@ -111,8 +174,12 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 		} else {
 			b.Resource = oq.Query
 		}
-	case "redis":
+	case config.ObfuscatorRedis:
 		b.Resource = o.QuantizeRedisString(b.Resource)
+	case config.ObfuscatorKeyValue:
+		if a.conf.Obfuscation.KeyValue.Enabled {
+			b.Resource = o.ObfuscateKeyValueString(b.Resource)
+		}
 	}
 }
 
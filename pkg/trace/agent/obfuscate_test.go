@@ -7,9 +7,11 @@ package agent
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/config/features"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
 
@@ -64,6 +66,7 @@ func TestObfuscateStatsGroup(t *testing.T) {
 		{statsGroup("sql", "SELECT 1 FROM db"), "SELECT ? FROM db"},
 		{statsGroup("sql", "SELECT 1\nFROM Blogs AS [b\nORDER BY [b]"), textNonParsable},
 		{statsGroup("redis", "ADD 1, 2"), "ADD"},
+		{statsGroup("valkey", "ADD 1, 2"), "ADD"},
 		{statsGroup("other", "ADD 1, 2"), "ADD 1, 2"},
 	} {
 		agnt, stop := agentWithDefaults()
@@ -220,6 +223,120 @@ func TestObfuscateConfig(t *testing.T) {
 		"set key 0 0 0 noreply\r\nvalue",
 		&config.ObfuscationConfig{},
 	))
+
+	t.Run("opensearch/enabled", testConfig(
+		"opensearch",
+		"opensearch.body",
+		`{"role": "database"}`,
+		`{"role":"?"}`,
+		&config.ObfuscationConfig{
+			ES: config.JSONObfuscationConfig{Enabled: true},
+		},
+	))
+
+	t.Run("valkey/enabled", testConfig(
+		"valkey",
+		"redis.raw_command",
+		"SET key val",
+		"SET key ?",
+		&config.ObfuscationConfig{Redis: config.Enablable{Enabled: true}},
+	))
+
+	t.Run("dynamodb/enabled", testConfig(
+		"dynamodb",
+		"dynamodb.query",
+		`{"TableName": "users", "Key": {"id": {"S": "1234"}}}`,
+		`{"TableName":"users","Key":{"id":{"S":"?"}}}`,
+		&config.ObfuscationConfig{
+			DynamoDB: config.JSONObfuscationConfig{Enabled: true},
+		},
+	))
+
+	t.Run("dynamodb/disabled", testConfig(
+		"dynamodb",
+		"dynamodb.query",
+		`{"TableName": "users", "Key": {"id": {"S": "1234"}}}`,
+		`{"TableName": "users", "Key": {"id": {"S": "1234"}}}`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("kafka/enabled", testConfig(
+		"kafka",
+		"kafka.message_key",
+		"user-1234",
+		"?",
+		&config.ObfuscationConfig{Kafka: config.Enablable{Enabled: true}},
+	))
+
+	t.Run("kafka/messaging", testConfig(
+		"kafka",
+		"messaging.kafka.message_key",
+		"user-1234",
+		"?",
+		&config.ObfuscationConfig{Kafka: config.Enablable{Enabled: true}},
+	))
+
+	t.Run("kafka/disabled", testConfig(
+		"kafka",
+		"kafka.message_key",
+		"user-1234",
+		"user-1234",
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("span-types/key-value", testConfig(
+		"etcd",
+		"db.statement",
+		"PUT /config/db/password s3cr3t",
+		"PUT /config/db/password ?",
+		&config.ObfuscationConfig{
+			KeyValue:  config.Enablable{Enabled: true},
+			SpanTypes: map[string]string{"etcd": config.ObfuscatorKeyValue},
+		},
+	))
+
+	t.Run("span-types/override", testConfig(
+		"valkey",
+		"db.statement",
+		"SET key val",
+		"SET key ?",
+		&config.ObfuscationConfig{
+			Redis:     config.Enablable{Enabled: true},
+			KeyValue:  config.Enablable{Enabled: true},
+			SpanTypes: map[string]string{"valkey": config.ObfuscatorKeyValue},
+		},
+	))
+
+	t.Run("span-types/none", testConfig(
+		"elasticsearch",
+		"elasticsearch.body",
+		`{"role": "database"}`,
+		`{"role": "database"}`,
+		&config.ObfuscationConfig{
+			ES:        config.JSONObfuscationConfig{Enabled: true},
+			SpanTypes: map[string]string{"elasticsearch": config.ObfuscatorNone},
+		},
+	))
+}
+
+func TestObfuscateKeyValueResource(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.Obfuscation = &config.ObfuscationConfig{
+		KeyValue:  config.Enablable{Enabled: true},
+		SpanTypes: map[string]string{"etcd": config.ObfuscatorKeyValue},
+	}
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	agnt := NewAgent(ctx, cfg)
+
+	span := &pb.Span{Type: "etcd", Resource: "PUT /config/db/password s3cr3t"}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "PUT /config/db/password ?", span.Resource)
+
+	stats := &pb.ClientGroupedStats{Type: "etcd", Resource: "PUT /config/db/password s3cr3t"}
+	agnt.obfuscateStatsGroup(stats)
+	assert.Equal(t, "PUT /config/db/password ?", stats.Resource)
 }
 
 func SQLSpan(query string) *pb.Span {
@@ -249,6 +366,57 @@ func TestSQLResourceQuery(t *testing.T) {
 	assert.Equal("SELECT * FROM users WHERE id = 42", span.Meta["sql.query"])
 }
 
+func TestSQLResourceDBType(t *testing.T) {
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	for _, tt := range []struct {
+		dbType, in, out string
+	}{
+		{"mysql", "SELECT `id` FROM `users` WHERE name IN (\"alice\", \"bob\")", "SELECT id FROM users WHERE name IN ( ? )"},
+		{"mariadb", `SELECT * FROM users WHERE name = "alice"`, "SELECT * FROM users WHERE name = ?"},
+		{"postgres", `SELECT foo$bar FROM "public"."users" WHERE name = E'O\'Brien'`, "SELECT foo$bar FROM public . users WHERE name = ?"},
+		{"sqlserver", "SELECT [1st] FROM [Order Details] WHERE name = N'alice'", "SELECT [ ? st ] FROM [ Order Details ] WHERE name = ?"},
+		{"oracle", "SELECT q'[It's a secret]' FROM dual", "SELECT ? FROM dual"},
+		{"", "SELECT [1st] FROM users", "SELECT [ ? st ] FROM users"},
+		{"sqlite", "SELECT [1st] FROM users", "SELECT [ ? st ] FROM users"},
+	} {
+		t.Run(tt.dbType, func(t *testing.T) {
+			span := &pb.Span{
+				Resource: tt.in,
+				Type:     "sql",
+				Meta:     map[string]string{"db.type": tt.dbType},
+			}
+			agnt.obfuscateSpan(span)
+			assert.Equal(t, tt.out, span.Resource)
+		})
+	}
+}
+
+func TestSQLResourceKeepIdentifierQuotation(t *testing.T) {
+	defer features.Set(os.Getenv("DD_APM_FEATURES"))
+	features.Set("keep_identifier_quotation")
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	for _, tt := range []struct {
+		dbType, in, out string
+	}{
+		{"mysql", "SELECT `id` FROM `users` WHERE name IN (\"alice\", \"bob\")", "SELECT `id` FROM `users` WHERE name IN ( ? )"},
+		{"postgres", `SELECT foo$bar FROM "public"."users" WHERE name = E'O\'Brien'`, `SELECT foo$bar FROM "public"."users" WHERE name = ?`},
+		{"sqlserver", "SELECT [1st] FROM [Order Details] WHERE name = N'alice'", "SELECT [1st] FROM [Order Details] WHERE name = ?"},
+		{"", "SELECT [1st] FROM users", "SELECT [ ? st ] FROM users"},
+	} {
+		t.Run(tt.dbType, func(t *testing.T) {
+			span := &pb.Span{
+				Resource: tt.in,
+				Type:     "sql",
+				Meta:     map[string]string{"db.type": tt.dbType},
+			}
+			agnt.obfuscateSpan(span)
+			assert.Equal(t, tt.out, span.Resource)
+		})
+	}
+}
+
 func TestSQLResourceWithoutQuery(t *testing.T) {
 	assert := assert.New(t)
 	span := &pb.Span{
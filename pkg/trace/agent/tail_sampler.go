@@ -0,0 +1,337 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+)
+
+// tailFlushPeriod is the frequency at which the traces at the end of their
+// decision window are sampled.
+const tailFlushPeriod = time.Second
+
+// tailChunk is a chunk dropped by the samplers, buffered by the tailSampler
+// until its trace is sampled.
+type tailChunk struct {
+	// payload holds the fields of the tracer payload of the chunk. Its Chunks are not set.
+	payload *pb.TracerPayload
+	// trace is the chunk along with the trace-level metadata computed by the agent.
+	trace traceutil.ProcessedTrace
+	// events is a copy of the chunk flagged as dropped, only holding the numEvents
+	// events extracted from it, sent in place of the chunk when the trace is dropped.
+	events    *pb.TraceChunk
+	numEvents int64
+	// source holds the stats of the client which sent the chunk.
+	source *info.TagStats
+}
+
+// tailSampler buffers the chunks dropped by the samplers by trace ID, during
+// a decision window starting with the first chunk of the trace. The trace is
+// then kept if it matches at least one of the configured policies. The chunks
+// of a kept trace received after its decision are kept too.
+//
+// The decision is given for each chunk of a trace to the decide function, which
+// is never called with the lock of the sampler held.
+type tailSampler struct {
+	// Variables access through the 'atomic' package must be 64bits aligned.
+	kept         int64
+	dropped      int64
+	evicted      int64
+	totalKept    int64
+	totalDropped int64
+	totalEvicted int64
+
+	decisionWait time.Duration
+	maxBytes     int64
+	policies     []*config.TailSamplingPolicy
+	decide       func(c tailChunk, keep bool)
+
+	mu sync.Mutex
+	// traces are the buffered traces by trace ID.
+	traces map[uint64]*tailTrace
+	// queue holds the buffered traces ordered by deadline.
+	queue []*tailTrace
+	// keptUntil holds the trace IDs of the traces recently kept, until when their
+	// late chunks are kept.
+	keptUntil map[uint64]time.Time
+	bytes     int64
+	spans     int64
+	// closed is set once the buffered traces are sampled by Stop, the chunks
+	// received afterwards are decided right away.
+	closed bool
+
+	exit    chan struct{}
+	stopped chan struct{}
+}
+
+// tailTrace holds the buffered chunks of a trace.
+type tailTrace struct {
+	id       uint64
+	deadline time.Time
+	chunks   []tailChunk
+	bytes    int64
+	spans    int64
+}
+
+// newTailSampler returns a tailSampler giving its decisions to decide.
+func newTailSampler(conf *config.AgentConfig, decide func(c tailChunk, keep bool)) *tailSampler {
+	return &tailSampler{
+		decisionWait: conf.TailSampling.DecisionWait,
+		maxBytes:     conf.TailSampling.MaxBufferedBytes,
+		policies:     conf.TailSampling.Policies,
+		decide:       decide,
+		traces:       make(map[uint64]*tailTrace),
+		keptUntil:    make(map[uint64]time.Time),
+		exit:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Start starts sampling the buffered traces at the end of their decision window.
+// It is a no-op on a nil tailSampler, when tail sampling is disabled.
+func (s *tailSampler) Start() {
+	if s == nil {
+		return
+	}
+	info.UpdateTailSamplerInfo(info.TailSamplerInfo{Enabled: true})
+	go func() {
+		defer watchdog.LogOnPanic()
+		flushTicker := time.NewTicker(tailFlushPeriod)
+		statsTicker := time.NewTicker(10 * time.Second)
+		defer flushTicker.Stop()
+		defer statsTicker.Stop()
+		for {
+			select {
+			case now := <-flushTicker.C:
+				s.flush(now, false)
+			case <-statsTicker.C:
+				s.report()
+			case <-s.exit:
+				close(s.stopped)
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the tailSampler and samples all the buffered traces, the chunks
+// added afterwards are dropped unless their trace was kept.
+// It is a no-op on a nil tailSampler, when tail sampling is disabled.
+func (s *tailSampler) Stop() {
+	if s == nil {
+		return
+	}
+	close(s.exit)
+	<-s.stopped
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.flush(time.Now(), true)
+	s.report()
+}
+
+// add buffers a chunk dropped by the samplers, received at now.
+func (s *tailSampler) add(now time.Time, c tailChunk) {
+	id := c.trace.Root.TraceID
+	size := int64(c.trace.TraceChunk.Msgsize())
+
+	s.mu.Lock()
+	if until, ok := s.keptUntil[id]; ok && now.Before(until) {
+		s.mu.Unlock()
+		s.decide(c, true)
+		return
+	}
+	if s.closed {
+		s.mu.Unlock()
+		atomic.AddInt64(&s.dropped, 1)
+		s.decide(c, false)
+		return
+	}
+	if size > s.maxBytes {
+		s.mu.Unlock()
+		atomic.AddInt64(&s.evicted, 1)
+		s.decide(c, false)
+		return
+	}
+	var evicted []*tailTrace
+	for s.bytes+size > s.maxBytes && len(s.queue) > 0 {
+		evicted = append(evicted, s.pop())
+	}
+	t, ok := s.traces[id]
+	if !ok {
+		t = &tailTrace{id: id, deadline: now.Add(s.decisionWait)}
+		s.traces[id] = t
+		s.queue = append(s.queue, t)
+	}
+	spans := int64(len(c.trace.TraceChunk.Spans))
+	t.chunks = append(t.chunks, c)
+	t.bytes += size
+	t.spans += spans
+	s.bytes += size
+	s.spans += spans
+	s.mu.Unlock()
+
+	for _, t := range evicted {
+		atomic.AddInt64(&s.evicted, 1)
+		s.decideTrace(t, false)
+	}
+}
+
+// pop removes the trace with the earliest deadline from the buffer. It must be
+// called with the lock held, on a non-empty queue.
+func (s *tailSampler) pop() *tailTrace {
+	t := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	delete(s.traces, t.id)
+	s.bytes -= t.bytes
+	s.spans -= t.spans
+	return t
+}
+
+// flush samples the traces at the end of their decision window, or all the
+// buffered traces when all is set.
+func (s *tailSampler) flush(now time.Time, all bool) {
+	var decided []*tailTrace
+	var keep []bool
+
+	s.mu.Lock()
+	for len(s.queue) > 0 && (all || !now.Before(s.queue[0].deadline)) {
+		t := s.pop()
+		k := s.matches(t)
+		if k {
+			s.keptUntil[t.id] = now.Add(s.decisionWait)
+		}
+		decided = append(decided, t)
+		keep = append(keep, k)
+	}
+	for id, until := range s.keptUntil {
+		if !now.Before(until) {
+			delete(s.keptUntil, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for i, t := range decided {
+		if keep[i] {
+			atomic.AddInt64(&s.kept, 1)
+		} else {
+			atomic.AddInt64(&s.dropped, 1)
+		}
+		s.decideTrace(t, keep[i])
+	}
+}
+
+func (s *tailSampler) decideTrace(t *tailTrace, keep bool) {
+	for _, c := range t.chunks {
+		s.decide(c, keep)
+	}
+}
+
+// matches returns whether the trace matches at least one of the policies.
+func (s *tailSampler) matches(t *tailTrace) bool {
+	for _, p := range s.policies {
+		if policyMatches(p, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func policyMatches(p *config.TailSamplingPolicy, t *tailTrace) bool {
+	switch p.Type {
+	case config.TailSamplingPolicyError:
+		return t.anySpan(func(span *pb.Span) bool {
+			return span.Error != 0
+		})
+	case config.TailSamplingPolicyLatency:
+		return t.duration() >= p.MinDurationMs*int64(time.Millisecond)
+	case config.TailSamplingPolicyTag:
+		return t.anySpan(func(span *pb.Span) bool {
+			v, ok := span.Meta[p.TagKey]
+			return ok && (len(p.TagValues) == 0 || contains(p.TagValues, v))
+		})
+	case config.TailSamplingPolicyService:
+		return t.anySpan(func(span *pb.Span) bool {
+			return contains(p.Services, span.Service)
+		})
+	}
+	return false
+}
+
+func (t *tailTrace) anySpan(f func(*pb.Span) bool) bool {
+	for _, c := range t.chunks {
+		for _, span := range c.trace.TraceChunk.Spans {
+			if f(span) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// duration returns the time elapsed between the start of the first span and
+// the end of the last span of the buffered chunks, in nanoseconds.
+func (t *tailTrace) duration() int64 {
+	var start, end int64
+	first := true
+	for _, c := range t.chunks {
+		for _, span := range c.trace.TraceChunk.Spans {
+			if first || span.Start < start {
+				start = span.Start
+			}
+			if first || span.Start+span.Duration > end {
+				end = span.Start + span.Duration
+			}
+			first = false
+		}
+	}
+	return end - start
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *tailSampler) report() {
+	kept := atomic.SwapInt64(&s.kept, 0)
+	dropped := atomic.SwapInt64(&s.dropped, 0)
+	evicted := atomic.SwapInt64(&s.evicted, 0)
+	metrics.Count("datadog.trace_agent.sampler.tail.kept", kept, nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.dropped", dropped, nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.evicted", evicted, nil, 1)
+
+	s.mu.Lock()
+	traces, spans, bytes := int64(len(s.traces)), s.spans, s.bytes
+	s.mu.Unlock()
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_traces", float64(traces), nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_spans", float64(spans), nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_bytes", float64(bytes), nil, 1)
+
+	info.UpdateTailSamplerInfo(info.TailSamplerInfo{
+		Enabled:        true,
+		BufferedTraces: traces,
+		BufferedSpans:  spans,
+		BufferedBytes:  bytes,
+		KeptTraces:     atomic.AddInt64(&s.totalKept, kept),
+		DroppedTraces:  atomic.AddInt64(&s.totalDropped, dropped),
+		EvictedTraces:  atomic.AddInt64(&s.totalEvicted, evicted),
+	})
+}
@@ -0,0 +1,223 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type tailDecisions map[uint64][]bool
+
+func newTestTailSampler(wait time.Duration, maxBytes int64, policies ...*config.TailSamplingPolicy) (*tailSampler, tailDecisions) {
+	conf := config.New()
+	conf.TailSampling = &config.TailSamplingConfig{
+		Enabled:          true,
+		DecisionWait:     wait,
+		MaxBufferedBytes: maxBytes,
+		Policies:         policies,
+	}
+	decisions := make(tailDecisions)
+	return newTailSampler(conf, func(c tailChunk, keep bool) {
+		decisions[c.trace.Root.TraceID] = append(decisions[c.trace.Root.TraceID], keep)
+	}), decisions
+}
+
+func tailTestChunk(spans ...*pb.Span) tailChunk {
+	return tailChunk{
+		payload: &pb.TracerPayload{},
+		trace: traceutil.ProcessedTrace{
+			TraceChunk: &pb.TraceChunk{Spans: spans},
+			Root:       traceutil.GetRoot(spans),
+		},
+		source: info.NewReceiverStats().GetTagStats(info.Tags{}),
+	}
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	now := time.Now()
+	start := now.UnixNano()
+	for name, tt := range map[string]struct {
+		policy *config.TailSamplingPolicy
+		// chunks are the chunks of the trace, they are all kept or dropped
+		chunks [][]*pb.Span
+		keep   bool
+	}{
+		"error": {
+			policy: &config.TailSamplingPolicy{Type: "error"},
+			chunks: [][]*pb.Span{
+				{{TraceID: 1, SpanID: 1}},
+				{{TraceID: 1, SpanID: 2, ParentID: 1, Error: 1}},
+			},
+			keep: true,
+		},
+		"error/none": {
+			policy: &config.TailSamplingPolicy{Type: "error"},
+			chunks: [][]*pb.Span{{{TraceID: 1, SpanID: 1}}},
+			keep:   false,
+		},
+		"latency": {
+			policy: &config.TailSamplingPolicy{Type: "latency", MinDurationMs: 100},
+			chunks: [][]*pb.Span{
+				{{TraceID: 1, SpanID: 1, Start: start, Duration: int64(60 * time.Millisecond)}},
+				{{TraceID: 1, SpanID: 2, ParentID: 1, Start: start + int64(50*time.Millisecond), Duration: int64(50 * time.Millisecond)}},
+			},
+			keep: true,
+		},
+		"latency/short": {
+			policy: &config.TailSamplingPolicy{Type: "latency", MinDurationMs: 100},
+			chunks: [][]*pb.Span{
+				{{TraceID: 1, SpanID: 1, Start: start, Duration: int64(60 * time.Millisecond)}},
+				{{TraceID: 1, SpanID: 2, ParentID: 1, Start: start + int64(10*time.Millisecond), Duration: int64(80 * time.Millisecond)}},
+			},
+			keep: false,
+		},
+		"tag": {
+			policy: &config.TailSamplingPolicy{Type: "tag", TagKey: "http.status_code", TagValues: []string{"500", "503"}},
+			chunks: [][]*pb.Span{
+				{{TraceID: 1, SpanID: 1, Meta: map[string]string{"http.status_code": "200"}}},
+				{{TraceID: 1, SpanID: 2, ParentID: 1, Meta: map[string]string{"http.status_code": "503"}}},
+			},
+			keep: true,
+		},
+		"tag/value": {
+			policy: &config.TailSamplingPolicy{Type: "tag", TagKey: "http.status_code", TagValues: []string{"500", "503"}},
+			chunks: [][]*pb.Span{{{TraceID: 1, SpanID: 1, Meta: map[string]string{"http.status_code": "200"}}}},
+			keep:   false,
+		},
+		"tag/any-value": {
+			policy: &config.TailSamplingPolicy{Type: "tag", TagKey: "customer.tier"},
+			chunks: [][]*pb.Span{{{TraceID: 1, SpanID: 1, Meta: map[string]string{"customer.tier": "gold"}}}},
+			keep:   true,
+		},
+		"service": {
+			policy: &config.TailSamplingPolicy{Type: "service", Services: []string{"billing"}},
+			chunks: [][]*pb.Span{
+				{{TraceID: 1, SpanID: 1, Service: "web"}},
+				{{TraceID: 1, SpanID: 2, ParentID: 1, Service: "billing"}},
+			},
+			keep: true,
+		},
+		"service/other": {
+			policy: &config.TailSamplingPolicy{Type: "service", Services: []string{"billing"}},
+			chunks: [][]*pb.Span{{{TraceID: 1, SpanID: 1, Service: "web"}}},
+			keep:   false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, decisions := newTestTailSampler(time.Second, 1e6, tt.policy)
+			for _, spans := range tt.chunks {
+				s.add(now, tailTestChunk(spans...))
+			}
+			s.flush(now.Add(time.Second), false)
+
+			expected := make([]bool, len(tt.chunks))
+			for i := range expected {
+				expected[i] = tt.keep
+			}
+			assert.Equal(t, tailDecisions{1: expected}, decisions)
+		})
+	}
+}
+
+func TestTailSamplerDecisionWait(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	s, decisions := newTestTailSampler(10*time.Second, 1e6, &config.TailSamplingPolicy{Type: "error"})
+
+	s.add(now, tailTestChunk(&pb.Span{TraceID: 1, SpanID: 1, Error: 1}))
+	s.add(now.Add(time.Second), tailTestChunk(&pb.Span{TraceID: 2, SpanID: 1}))
+	s.add(now.Add(2*time.Second), tailTestChunk(&pb.Span{TraceID: 1, SpanID: 2, ParentID: 1}))
+
+	s.flush(now.Add(9*time.Second), false)
+	assert.Empty(decisions)
+
+	s.flush(now.Add(10*time.Second), false)
+	assert.Equal(tailDecisions{1: {true, true}}, decisions)
+	assert.Len(s.traces, 1)
+
+	s.flush(now.Add(11*time.Second), false)
+	assert.Equal(tailDecisions{1: {true, true}, 2: {false}}, decisions)
+	assert.Empty(s.traces)
+	assert.Empty(s.queue)
+	assert.Zero(s.bytes)
+	assert.Zero(s.spans)
+
+	// the late chunks of a kept trace are kept right away, until the end of another window
+	s.add(now.Add(15*time.Second), tailTestChunk(&pb.Span{TraceID: 1, SpanID: 3, ParentID: 1}))
+	assert.Equal(tailDecisions{1: {true, true, true}, 2: {false}}, decisions)
+	s.flush(now.Add(20*time.Second), false)
+	s.add(now.Add(21*time.Second), tailTestChunk(&pb.Span{TraceID: 1, SpanID: 4, ParentID: 1}))
+	assert.Equal(tailDecisions{1: {true, true, true}, 2: {false}}, decisions)
+	assert.Len(s.traces, 1)
+
+	assert.EqualValues(1, atomic.LoadInt64(&s.kept))
+	assert.EqualValues(1, atomic.LoadInt64(&s.dropped))
+}
+
+func TestTailSamplerMaxBufferedBytes(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	chunk := func(traceID uint64) tailChunk {
+		return tailTestChunk(&pb.Span{TraceID: traceID, SpanID: 1, Error: 1})
+	}
+	size := int64(chunk(1).trace.TraceChunk.Msgsize())
+	s, decisions := newTestTailSampler(10*time.Second, 2*size, &config.TailSamplingPolicy{Type: "error"})
+
+	s.add(now, chunk(1))
+	s.add(now, chunk(2))
+	assert.Empty(decisions)
+	assert.Equal(2*size, s.bytes)
+
+	// the oldest trace is dropped to make room for the new chunk
+	s.add(now, chunk(3))
+	assert.Equal(tailDecisions{1: {false}}, decisions)
+	assert.Equal(2*size, s.bytes)
+	assert.Len(s.traces, 2)
+
+	// a chunk larger than the buffer is dropped right away
+	s.add(now, tailTestChunk(
+		&pb.Span{TraceID: 4, SpanID: 1, Error: 1},
+		&pb.Span{TraceID: 4, SpanID: 2, ParentID: 1},
+		&pb.Span{TraceID: 4, SpanID: 3, ParentID: 1},
+	))
+	assert.Equal(tailDecisions{1: {false}, 4: {false}}, decisions)
+	assert.Len(s.traces, 2)
+	assert.EqualValues(2, atomic.LoadInt64(&s.evicted))
+
+	s.flush(now.Add(10*time.Second), false)
+	assert.Equal(tailDecisions{1: {false}, 2: {true}, 3: {true}, 4: {false}}, decisions)
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s, decisions := newTestTailSampler(time.Hour, 1e6, &config.TailSamplingPolicy{Type: "service", Services: []string{"billing"}})
+	s.Start()
+	s.add(time.Now(), tailTestChunk(&pb.Span{TraceID: 1, SpanID: 1, Service: "billing"}))
+	s.add(time.Now(), tailTestChunk(&pb.Span{TraceID: 2, SpanID: 1, Service: "web"}))
+	s.Stop()
+
+	// the buffered traces are all sampled when stopping
+	assert.Equal(t, tailDecisions{1: {true}, 2: {false}}, decisions)
+	assert.Empty(t, s.traces)
+
+	// the chunks received after stopping are not buffered, the late chunks
+	// of the kept traces are still kept
+	s.add(time.Now(), tailTestChunk(&pb.Span{TraceID: 1, SpanID: 2, Service: "billing"}))
+	s.add(time.Now(), tailTestChunk(&pb.Span{TraceID: 3, SpanID: 1, Service: "billing"}))
+	assert.Equal(t, tailDecisions{1: {true, true}, 2: {false}, 3: {false}}, decisions)
+	assert.Empty(t, s.traces)
+
+	var nilSampler *tailSampler
+	nilSampler.Start()
+	nilSampler.Stop()
+}
@@ -80,6 +80,23 @@ type ObfuscationConfig struct {
 	// for spans of type "memcached".
 	Memcached Enablable `mapstructure:"memcached"`
 
+	// DynamoDB holds the obfuscation configuration for the DynamoDB JSON requests
+	// found in the "dynamodb.query" tag.
+	DynamoDB JSONObfuscationConfig `mapstructure:"dynamodb"`
+
+	// Kafka holds the configuration for obfuscating the message key tags of
+	// spans of type "kafka".
+	Kafka Enablable `mapstructure:"kafka"`
+
+	// KeyValue holds the configuration for obfuscating the values in the commands
+	// of generic key/value stores, found in the resource and the "db.statement" tag.
+	KeyValue Enablable `mapstructure:"key_value"`
+
+	// SpanTypes maps span types to the obfuscator applied to their spans, in addition
+	// to, or in place of, the default mapping. The obfuscators are identified by the
+	// Obfuscator* constants.
+	SpanTypes map[string]string `mapstructure:"span_types"`
+
 	// CreditCards holds the configuration for obfuscating credit cards.
 	CreditCards CreditCardsConfig `mapstructure:"credit_cards"`
 }
@@ -88,11 +105,12 @@ type ObfuscationConfig struct {
 func (o *ObfuscationConfig) Export() obfuscate.Config {
 	return obfuscate.Config{
 		SQL: obfuscate.SQLConfig{
-			TableNames:       features.Has("table_names"),
-			ReplaceDigits:    features.Has("quantize_sql_tables") || features.Has("replace_sql_digits"),
-			KeepSQLAlias:     features.Has("keep_sql_alias"),
-			DollarQuotedFunc: features.Has("dollar_quoted_func"),
-			Cache:            features.Has("sql_cache"),
+			TableNames:              features.Has("table_names"),
+			ReplaceDigits:           features.Has("quantize_sql_tables") || features.Has("replace_sql_digits"),
+			KeepSQLAlias:            features.Has("keep_sql_alias"),
+			DollarQuotedFunc:        features.Has("dollar_quoted_func"),
+			KeepIdentifierQuotation: features.Has("keep_identifier_quotation"),
+			Cache:                   features.Has("sql_cache"),
 		},
 		ES: obfuscate.JSONConfig{
 			Enabled:            o.ES.Enabled,
@@ -104,6 +122,11 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 			KeepValues:         o.Mongo.KeepValues,
 			ObfuscateSQLValues: o.Mongo.ObfuscateSQLValues,
 		},
+		DynamoDB: obfuscate.JSONConfig{
+			Enabled:            o.DynamoDB.Enabled,
+			KeepValues:         o.DynamoDB.KeepValues,
+			ObfuscateSQLValues: o.DynamoDB.ObfuscateSQLValues,
+		},
 		SQLExecPlan: obfuscate.JSONConfig{
 			Enabled:            o.SQLExecPlan.Enabled,
 			KeepValues:         o.SQLExecPlan.KeepValues,
@@ -122,6 +145,34 @@ func (o *ObfuscationConfig) Export() obfuscate.Config {
 	}
 }
 
+// Obfuscators which can be assigned to span types in ObfuscationConfig.SpanTypes.
+const (
+	ObfuscatorSQL           = "sql"
+	ObfuscatorRedis         = "redis"
+	ObfuscatorMemcached     = "memcached"
+	ObfuscatorHTTP          = "http"
+	ObfuscatorMongoDB       = "mongodb"
+	ObfuscatorElasticsearch = "elasticsearch"
+	ObfuscatorDynamoDB      = "dynamodb"
+	ObfuscatorKafka         = "kafka"
+	ObfuscatorKeyValue      = "key_value"
+	// ObfuscatorNone disables the obfuscation of a span type.
+	ObfuscatorNone = "none"
+)
+
+// validateSpanTypes removes the span types mapped to an unknown obfuscator.
+func (o *ObfuscationConfig) validateSpanTypes() {
+	for typ, name := range o.SpanTypes {
+		switch name {
+		case ObfuscatorSQL, ObfuscatorRedis, ObfuscatorMemcached, ObfuscatorHTTP, ObfuscatorMongoDB,
+			ObfuscatorElasticsearch, ObfuscatorDynamoDB, ObfuscatorKafka, ObfuscatorKeyValue, ObfuscatorNone:
+			continue
+		}
+		log.Warnf("Ignoring obfuscation of span type %q: unknown obfuscator %q", typ, name)
+		delete(o.SpanTypes, typ)
+	}
+}
+
 type debugLogger struct{}
 
 func (debugLogger) Debugf(format string, params ...interface{}) {
@@ -193,6 +244,53 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// Filter rule actions.
+const (
+	// FilterActionDropTrace drops the traces having a span matching the rule.
+	FilterActionDropTrace = "drop_trace"
+	// FilterActionDropSpan only drops the spans matching the rule.
+	FilterActionDropSpan = "drop_span"
+)
+
+// FilterRule specifies a rule dropping the traces, or the spans, matching all of
+// its criteria. At least one criterion must be set.
+type FilterRule struct {
+	// Name identifies the rule in the logs.
+	Name string `mapstructure:"name"`
+
+	// Action is one of the FilterAction constants, FilterActionDropTrace by default.
+	Action string `mapstructure:"action"`
+
+	// Service, OperationName and Resource are regexp patterns that must match
+	// the service, the name and the resource of the span.
+	Service       string `mapstructure:"service"`
+	OperationName string `mapstructure:"operation_name"`
+	Resource      string `mapstructure:"resource"`
+
+	// Tags are tags of the form `key:pattern`, where pattern is a regexp that
+	// must match the value of the tag key of the span.
+	Tags []string `mapstructure:"tags"`
+
+	// MinDurationMs and MaxDurationMs bound the duration of the span, in milliseconds.
+	MinDurationMs float64 `mapstructure:"min_duration_ms"`
+	MaxDurationMs float64 `mapstructure:"max_duration_ms"`
+
+	// ServiceRe, OperationNameRe and ResourceRe hold the compiled patterns and
+	// are only used internally.
+	ServiceRe       *regexp.Regexp `mapstructure:"-"`
+	OperationNameRe *regexp.Regexp `mapstructure:"-"`
+	ResourceRe      *regexp.Regexp `mapstructure:"-"`
+
+	// TagsRe holds the compiled Tags and is only used internally.
+	TagsRe []FilterRuleTag `mapstructure:"-"`
+}
+
+// FilterRuleTag is a compiled tag criterion of a FilterRule.
+type FilterRuleTag struct {
+	Key string
+	Re  *regexp.Regexp
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -208,6 +306,76 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+// TailSamplingConfig specifies the configuration of the tail-based sampling.
+type TailSamplingConfig struct {
+	// Enabled reports whether the traces dropped by the samplers are buffered
+	// to be sampled by the policies once complete.
+	Enabled bool
+
+	// DecisionWait is the time during which the chunks of a trace are buffered,
+	// starting from its first chunk, before applying the policies.
+	DecisionWait time.Duration
+
+	// MaxBufferedBytes is the maximum size of the buffered chunks. The oldest
+	// traces are dropped when it is reached.
+	MaxBufferedBytes int64
+
+	// Policies keep a trace when at least one of them matches.
+	Policies []*TailSamplingPolicy
+}
+
+// Tail sampling policy types.
+const (
+	// TailSamplingPolicyError matches the traces having an error span.
+	TailSamplingPolicyError = "error"
+	// TailSamplingPolicyLatency matches the traces lasting at least MinDurationMs.
+	TailSamplingPolicyLatency = "latency"
+	// TailSamplingPolicyTag matches the traces having a span with the tag TagKey,
+	// set to one of the TagValues when there are TagValues.
+	TailSamplingPolicyTag = "tag"
+	// TailSamplingPolicyService matches the traces having a span of one of the Services.
+	TailSamplingPolicyService = "service"
+)
+
+// TailSamplingPolicy specifies a tail sampling policy.
+type TailSamplingPolicy struct {
+	// Type is one of the TailSamplingPolicy constants.
+	Type string `mapstructure:"type" json:"type"`
+
+	// MinDurationMs is the duration of the trace, in milliseconds, above which
+	// the latency policy matches.
+	MinDurationMs int64 `mapstructure:"min_duration_ms" json:"min_duration_ms"`
+
+	// TagKey and TagValues configure the tag policy.
+	TagKey    string   `mapstructure:"tag_key" json:"tag_key"`
+	TagValues []string `mapstructure:"tag_values" json:"tag_values"`
+
+	// Services configures the service policy.
+	Services []string `mapstructure:"services" json:"services"`
+}
+
+// validate returns an error when the policy can't match any trace.
+func (p *TailSamplingPolicy) validate() error {
+	switch p.Type {
+	case TailSamplingPolicyError:
+	case TailSamplingPolicyLatency:
+		if p.MinDurationMs <= 0 {
+			return errors.New("min_duration_ms must be positive")
+		}
+	case TailSamplingPolicyTag:
+		if p.TagKey == "" {
+			return errors.New("tag_key must be set")
+		}
+	case TailSamplingPolicyService:
+		if len(p.Services) == 0 {
+			return errors.New("services must be set")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", p.Type)
+	}
+	return nil
+}
+
 // appendEndpoints appends any endpoint configuration found at the given cfgKey.
 // The format for cfgKey should be a map which has the URL as a key and one or
 // more API keys as an array value.
@@ -325,6 +493,51 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		c.MaxRemoteTPS = config.Datadog.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
 
+	if k := "apm_config.tail_sampling.enabled"; config.Datadog.IsSet(k) {
+		c.TailSampling.Enabled = config.Datadog.GetBool(k)
+	}
+	if k := "apm_config.tail_sampling.decision_wait_seconds"; config.Datadog.IsSet(k) {
+		if wait := config.Datadog.GetInt(k); wait > 0 {
+			c.TailSampling.DecisionWait = getDuration(wait)
+		} else {
+			log.Warnf("%s must be positive, using %s", k, c.TailSampling.DecisionWait)
+		}
+	}
+	if k := "apm_config.tail_sampling.max_buffered_bytes"; config.Datadog.IsSet(k) {
+		if max := config.Datadog.GetInt64(k); max > 0 {
+			c.TailSampling.MaxBufferedBytes = max
+		} else {
+			log.Warnf("%s must be positive, using %d", k, c.TailSampling.MaxBufferedBytes)
+		}
+	}
+	if k := "apm_config.tail_sampling.policies"; config.Datadog.IsSet(k) {
+		var policies []*TailSamplingPolicy
+		if err := config.Datadog.UnmarshalKey(k, &policies); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"type\": \"latency\", \"min_duration_ms\": 500}]', error: %v", k, err)
+		}
+		for i, p := range policies {
+			if err := p.validate(); err != nil {
+				log.Errorf("Invalid tail sampling policy %d (skipping): %v", i, err)
+				continue
+			}
+			c.TailSampling.Policies = append(c.TailSampling.Policies, p)
+		}
+	}
+	if c.TailSampling.Enabled && len(c.TailSampling.Policies) == 0 {
+		log.Warn("Tail sampling is enabled without any policy: the traces dropped by the samplers are still dropped")
+	}
+
+	if k := "apm_config.stats_span_tags"; config.Datadog.IsSet(k) {
+		c.StatsSpanTags = config.Datadog.GetStringSlice(k)
+	}
+	if k := "apm_config.stats_span_tags_max_cardinality"; config.Datadog.IsSet(k) {
+		if max := config.Datadog.GetInt(k); max > 0 {
+			c.MaxStatsSpanTagsCardinality = max
+		} else {
+			log.Warnf("%s must be positive, using %d", k, c.MaxStatsSpanTagsCardinality)
+		}
+	}
+
 	if k := "apm_config.ignore_resources"; config.Datadog.IsSet(k) {
 		c.Ignore["resource"] = config.Datadog.GetStringSlice(k)
 	}
@@ -399,6 +612,10 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		if config.Datadog.IsSet("apm_config.obfuscation.credit_cards.luhn") {
 			c.Obfuscation.CreditCards.Luhn = config.Datadog.GetBool("apm_config.obfuscation.credit_cards.luhn")
 		}
+		if config.Datadog.IsSet("apm_config.obfuscation.span_types") {
+			c.Obfuscation.SpanTypes = config.Datadog.GetStringMapString("apm_config.obfuscation.span_types")
+		}
+		c.Obfuscation.validateSpanTypes()
 	}
 
 	if config.Datadog.IsSet("apm_config.filter_tags.require") {
@@ -414,6 +631,20 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		}
 	}
 
+	if k := "apm_config.filter_rules"; config.Datadog.IsSet(k) {
+		var rules []*FilterRule
+		if err := config.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"health-checks\", \"action\": \"drop_trace\", \"tags\": [\"http.url:/healthz\"]}]', error: %v", k, err)
+		}
+		for i, r := range rules {
+			if err := compileFilterRule(r); err != nil {
+				log.Errorf("Invalid filter rule %d %q (skipping): %v", i, r.Name, err)
+				continue
+			}
+			c.FilterRules = append(c.FilterRules, r)
+		}
+	}
+
 	// undocumented
 	if config.Datadog.IsSet("apm_config.max_cpu_percent") {
 		c.MaxCPU = config.Datadog.GetFloat64("apm_config.max_cpu_percent") / 100
@@ -574,6 +805,53 @@ func compileReplaceRules(rules []*ReplaceRule) error {
 	return nil
 }
 
+// compileFilterRule validates the filter rule and compiles its patterns.
+func compileFilterRule(r *FilterRule) error {
+	switch r.Action {
+	case "":
+		r.Action = FilterActionDropTrace
+	case FilterActionDropTrace, FilterActionDropSpan:
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.Service == "" && r.OperationName == "" && r.Resource == "" && len(r.Tags) == 0 && r.MinDurationMs == 0 && r.MaxDurationMs == 0 {
+		return errors.New("at least one of service, operation_name, resource, tags, min_duration_ms and max_duration_ms must be set")
+	}
+	if r.MinDurationMs < 0 || r.MaxDurationMs < 0 || (r.MaxDurationMs > 0 && r.MaxDurationMs < r.MinDurationMs) {
+		return errors.New("min_duration_ms and max_duration_ms must be positive, min_duration_ms being lower")
+	}
+	for _, p := range []struct {
+		pattern string
+		re      **regexp.Regexp
+	}{
+		{r.Service, &r.ServiceRe},
+		{r.OperationName, &r.OperationNameRe},
+		{r.Resource, &r.ResourceRe},
+	} {
+		if p.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return err
+		}
+		*p.re = re
+	}
+	r.TagsRe = nil
+	for _, tag := range r.Tags {
+		i := strings.IndexByte(tag, ':')
+		if i <= 0 {
+			return fmt.Errorf("tag %q must be of the form key:pattern", tag)
+		}
+		re, err := regexp.Compile(tag[i+1:])
+		if err != nil {
+			return fmt.Errorf("tag %q: %s", tag[:i], err)
+		}
+		r.TagsRe = append(r.TagsRe, FilterRuleTag{Key: tag[:i], Re: re})
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
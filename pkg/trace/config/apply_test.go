@@ -29,6 +29,59 @@ func TestParseRepaceRules(t *testing.T) {
 	}
 }
 
+func TestTailSamplingPolicyValidate(t *testing.T) {
+	for _, tt := range []struct {
+		policy TailSamplingPolicy
+		valid  bool
+	}{
+		{policy: TailSamplingPolicy{Type: "error"}, valid: true},
+		{policy: TailSamplingPolicy{Type: "latency", MinDurationMs: 100}, valid: true},
+		{policy: TailSamplingPolicy{Type: "latency"}, valid: false},
+		{policy: TailSamplingPolicy{Type: "tag", TagKey: "env"}, valid: true},
+		{policy: TailSamplingPolicy{Type: "tag", TagValues: []string{"prod"}}, valid: false},
+		{policy: TailSamplingPolicy{Type: "service", Services: []string{"web"}}, valid: true},
+		{policy: TailSamplingPolicy{Type: "service"}, valid: false},
+		{policy: TailSamplingPolicy{Type: "probabilistic"}, valid: false},
+	} {
+		t.Run(tt.policy.Type, func(t *testing.T) {
+			err := tt.policy.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCompileFilterRule(t *testing.T) {
+	for name, tt := range map[string]struct {
+		rule  FilterRule
+		valid bool
+	}{
+		"tags":            {rule: FilterRule{Tags: []string{"http.url:/healthz", "http.useragent:^kube-probe"}}, valid: true},
+		"drop_span":       {rule: FilterRule{Action: "drop_span", Service: "^web$"}, valid: true},
+		"duration":        {rule: FilterRule{MinDurationMs: 1, MaxDurationMs: 2}, valid: true},
+		"no-criterion":    {rule: FilterRule{Name: "empty"}, valid: false},
+		"unknown-action":  {rule: FilterRule{Action: "keep", Service: "web"}, valid: false},
+		"bad-pattern":     {rule: FilterRule{Resource: "("}, valid: false},
+		"bad-tag":         {rule: FilterRule{Tags: []string{"http.url"}}, valid: false},
+		"bad-tag-pattern": {rule: FilterRule{Tags: []string{"http.url:("}}, valid: false},
+		"bad-duration":    {rule: FilterRule{MinDurationMs: 2, MaxDurationMs: 1}, valid: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := compileFilterRule(&tt.rule)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tt.rule.Action)
+			assert.Len(t, tt.rule.TagsRe, len(tt.rule.Tags))
+		})
+	}
+}
+
 func TestSplitTag(t *testing.T) {
 	for _, tt := range []struct {
 		tag string
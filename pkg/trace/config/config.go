@@ -67,6 +67,12 @@ type AgentConfig struct {
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
 
+	// StatsSpanTags are the span tags added as extra dimensions to the stats.
+	StatsSpanTags []string
+	// MaxStatsSpanTagsCardinality is the maximum number of distinct combinations of
+	// values of the StatsSpanTags per stats bucket.
+	MaxStatsSpanTagsCardinality int
+
 	// Sampler configuration
 	ExtraSampleRate    float64
 	TargetTPS          float64
@@ -75,6 +81,10 @@ type AgentConfig struct {
 	MaxEPS             float64
 	MaxRemoteTPS       float64
 
+	// TailSampling holds the configuration of the tail-based sampling of the
+	// traces dropped by the samplers.
+	TailSampling *TailSamplingConfig
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -110,6 +120,9 @@ type AgentConfig struct {
 	// filtering
 	Ignore map[string][]string
 
+	// FilterRules drop the traces, or the spans, matching them before sampling and stats.
+	FilterRules []*FilterRule
+
 	// ReplaceTags is used to filter out sensitive information from tag values.
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
@@ -164,12 +177,19 @@ func New() *AgentConfig {
 
 		BucketInterval: time.Duration(10) * time.Second,
 
+		MaxStatsSpanTagsCardinality: 1000,
+
 		ExtraSampleRate: 1.0,
 		TargetTPS:       10,
 		ErrorTPS:        10,
 		MaxEPS:          200,
 		MaxRemoteTPS:    100,
 
+		TailSampling: &TailSamplingConfig{
+			DecisionWait:     10 * time.Second,
+			MaxBufferedBytes: 50 * 1024 * 1024, // 50MB
+		},
+
 		ReceiverHost:    "localhost",
 		ReceiverPort:    8126,
 		MaxRequestBytes: 50 * 1024 * 1024, // 50MB
@@ -244,6 +244,17 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.Equal(0.5, c.ExtraSampleRate)
 	assert.Equal(5.0, c.TargetTPS)
 	assert.Equal(50.0, c.MaxEPS)
+	assert.Equal([]string{"peer.service", "db.instance"}, c.StatsSpanTags)
+	assert.Equal(200, c.MaxStatsSpanTagsCardinality)
+	assert.True(c.TailSampling.Enabled)
+	assert.Equal(30*time.Second, c.TailSampling.DecisionWait)
+	assert.EqualValues(1000000, c.TailSampling.MaxBufferedBytes)
+	assert.Equal([]*TailSamplingPolicy{
+		{Type: "error"},
+		{Type: "latency", MinDurationMs: 500},
+		{Type: "tag", TagKey: "http.status_code", TagValues: []string{"500", "503"}},
+		{Type: "service", Services: []string{"billing"}},
+	}, c.TailSampling.Policies)
 	assert.Equal(0.5, c.MaxCPU)
 	assert.EqualValues(123.4, c.MaxMemory)
 	assert.Equal("0.0.0.0", c.ReceiverHost)
@@ -268,6 +279,16 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.ElementsMatch([]*Tag{{K: "env", V: "prod"}, {K: "db", V: "mongodb"}}, c.RequireTags)
 	assert.ElementsMatch([]*Tag{{K: "outcome", V: "success"}}, c.RejectTags)
 
+	assert.Len(c.FilterRules, 2)
+	assert.Equal("health-checks", c.FilterRules[0].Name)
+	assert.Equal("drop_trace", c.FilterRules[0].Action)
+	assert.Len(c.FilterRules[0].TagsRe, 2)
+	assert.Equal("http.useragent", c.FilterRules[0].TagsRe[1].Key)
+	assert.Equal("^kube-probe", c.FilterRules[0].TagsRe[1].Re.String())
+	assert.Equal("drop_span", c.FilterRules[1].Action)
+	assert.Equal(`^redis\.command$`, c.FilterRules[1].OperationNameRe.String())
+	assert.Equal(0.5, c.FilterRules[1].MaxDurationMs)
+
 	assert.ElementsMatch([]*ReplaceRule{
 		{
 			Name:    "http.method",
@@ -306,6 +327,15 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(o.RemoveStackTraces)
 	assert.True(c.Obfuscation.Redis.Enabled)
 	assert.True(c.Obfuscation.Memcached.Enabled)
+	assert.True(o.DynamoDB.Enabled)
+	assert.EqualValues([]string{"ExclusiveStartKey"}, o.DynamoDB.KeepValues)
+	assert.True(o.Kafka.Enabled)
+	assert.True(o.KeyValue.Enabled)
+	assert.Equal(map[string]string{
+		"etcd":       "key_value",
+		"opensearch": "elasticsearch",
+		"graphql":    "none",
+	}, o.SpanTypes)
 	assert.True(c.Obfuscation.CreditCards.Enabled)
 	assert.True(c.Obfuscation.CreditCards.Luhn)
 }
@@ -265,6 +265,64 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(cfg.RequireTags, []*Tag{{K: "important1", V: ""}, {K: "important2", V: "value1"}})
 	})
 
+	env = "DD_APM_STATS_SPAN_TAGS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `peer.service db.instance`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]string{"peer.service", "db.instance"}, cfg.StatsSpanTags)
+	})
+
+	env = "DD_APM_FILTER_RULES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"name": "health-checks", "tags": ["http.url:/healthz$"]}, {"action": "drop_span", "service": "^cache$"}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Len(cfg.FilterRules, 2)
+		assert.Equal("health-checks", cfg.FilterRules[0].Name)
+		assert.Equal("http.url", cfg.FilterRules[0].TagsRe[0].Key)
+		assert.Equal("drop_span", cfg.FilterRules[1].Action)
+		assert.Equal("^cache$", cfg.FilterRules[1].ServiceRe.String())
+	})
+
+	env = "DD_APM_OBFUSCATION_SPAN_TYPES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `{"valkey": "key_value", "search": "elasticsearch", "custom": "unknown"}`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(map[string]string{
+			"valkey": "key_value",
+			"search": "elasticsearch",
+		}, cfg.Obfuscation.SpanTypes)
+	})
+
+	env = "DD_APM_TAIL_SAMPLING_POLICIES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"type": "latency", "min_duration_ms": 250}, {"type": "service", "services": ["auth"]}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := Load("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*TailSamplingPolicy{
+			{Type: "latency", MinDurationMs: 250},
+			{Type: "service", Services: []string{"auth"}},
+		}, cfg.TailSampling.Policies)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REJECT"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
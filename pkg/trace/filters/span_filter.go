@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// SpanFilter is a filter which drops the traces, or only the spans, matching
+// its rules.
+type SpanFilter struct {
+	traceRules []*config.FilterRule
+	spanRules  []*config.FilterRule
+}
+
+// NewSpanFilter returns a new SpanFilter using the given compiled rules.
+func NewSpanFilter(rules []*config.FilterRule) *SpanFilter {
+	f := &SpanFilter{}
+	for _, r := range rules {
+		if r.Action == config.FilterActionDropSpan {
+			f.spanRules = append(f.spanRules, r)
+		} else {
+			f.traceRules = append(f.traceRules, r)
+		}
+	}
+	return f
+}
+
+// Filter applies the rules to the trace. It returns true when the whole trace
+// must be dropped, otherwise the spans matching a span rule are removed from
+// the trace, which is returned.
+func (f *SpanFilter) Filter(trace pb.Trace) (pb.Trace, bool) {
+	for _, s := range trace {
+		for _, r := range f.traceRules {
+			if matchesSpan(r, s) {
+				return nil, true
+			}
+		}
+	}
+	if len(f.spanRules) == 0 {
+		return trace, false
+	}
+	n := 0
+	for _, s := range trace {
+		if f.dropsSpan(s) {
+			continue
+		}
+		trace[n] = s
+		n++
+	}
+	for i := n; i < len(trace); i++ {
+		trace[i] = nil
+	}
+	return trace[:n], false
+}
+
+func (f *SpanFilter) dropsSpan(s *pb.Span) bool {
+	for _, r := range f.spanRules {
+		if matchesSpan(r, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsStat returns false when the stats group can only come from spans
+// matching a rule, which is the case of the rules only matching the service,
+// the operation name or the resource.
+func (f *SpanFilter) AllowsStat(stat *pb.ClientGroupedStats) bool {
+	for _, rules := range [][]*config.FilterRule{f.traceRules, f.spanRules} {
+		for _, r := range rules {
+			if len(r.TagsRe) > 0 || r.MinDurationMs > 0 || r.MaxDurationMs > 0 {
+				continue
+			}
+			if matches(r, stat.Service, stat.Name, stat.Resource) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesSpan(r *config.FilterRule, s *pb.Span) bool {
+	if !matches(r, s.Service, s.Name, s.Resource) {
+		return false
+	}
+	if r.MinDurationMs > 0 && float64(s.Duration) < r.MinDurationMs*1e6 {
+		return false
+	}
+	if r.MaxDurationMs > 0 && float64(s.Duration) > r.MaxDurationMs*1e6 {
+		return false
+	}
+	for _, tag := range r.TagsRe {
+		v, ok := s.Meta[tag.Key]
+		if !ok || !tag.Re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matches(r *config.FilterRule, service, name, resource string) bool {
+	if r.ServiceRe != nil && !r.ServiceRe.MatchString(service) {
+		return false
+	}
+	if r.OperationNameRe != nil && !r.OperationNameRe.MatchString(name) {
+		return false
+	}
+	if r.ResourceRe != nil && !r.ResourceRe.MatchString(resource) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanFilter(t *testing.T) {
+	healthChecks := &config.FilterRule{
+		Action: config.FilterActionDropTrace,
+		TagsRe: []config.FilterRuleTag{
+			{Key: "http.url", Re: regexp.MustCompile("/healthz$")},
+			{Key: "http.useragent", Re: regexp.MustCompile("^kube-probe")},
+		},
+	}
+	fastCache := &config.FilterRule{
+		Action:        config.FilterActionDropSpan,
+		ServiceRe:     regexp.MustCompile("^cache$"),
+		MaxDurationMs: 1,
+	}
+	slowOperation := &config.FilterRule{
+		Action:          config.FilterActionDropSpan,
+		OperationNameRe: regexp.MustCompile("^batch\\."),
+		ResourceRe:      regexp.MustCompile("^cleanup"),
+		MinDurationMs:   1000,
+	}
+	filter := NewSpanFilter([]*config.FilterRule{healthChecks, fastCache, slowOperation})
+
+	for name, tt := range map[string]struct {
+		trace     pb.Trace
+		dropTrace bool
+		// kept are the span IDs of the spans kept
+		kept []uint64
+	}{
+		"health-check": {
+			trace: pb.Trace{
+				{SpanID: 1, Service: "web", Meta: map[string]string{"http.url": "http://host/healthz", "http.useragent": "kube-probe/1.21"}},
+				{SpanID: 2, ParentID: 1, Service: "web"},
+			},
+			dropTrace: true,
+		},
+		"health-check/other-agent": {
+			trace: pb.Trace{
+				{SpanID: 1, Service: "web", Meta: map[string]string{"http.url": "http://host/healthz", "http.useragent": "curl/7.79"}},
+				{SpanID: 2, ParentID: 1, Service: "web"},
+			},
+			kept: []uint64{1, 2},
+		},
+		"health-check/child-span": {
+			trace: pb.Trace{
+				{SpanID: 1, Service: "web"},
+				{SpanID: 2, ParentID: 1, Service: "web", Meta: map[string]string{"http.url": "/healthz", "http.useragent": "kube-probe/1.21"}},
+			},
+			dropTrace: true,
+		},
+		"fast-cache": {
+			trace: pb.Trace{
+				{SpanID: 1, Service: "web", Duration: int64(time.Millisecond)},
+				{SpanID: 2, ParentID: 1, Service: "cache", Duration: int64(500 * time.Microsecond)},
+				{SpanID: 3, ParentID: 1, Service: "cache", Duration: int64(2 * time.Millisecond)},
+				{SpanID: 4, ParentID: 1, Service: "cache", Duration: int64(time.Millisecond)},
+			},
+			kept: []uint64{1, 3},
+		},
+		"slow-operation": {
+			trace: pb.Trace{
+				{SpanID: 1, Name: "batch.run", Resource: "cleanup users", Duration: int64(2 * time.Second)},
+				{SpanID: 2, ParentID: 1, Name: "batch.run", Resource: "cleanup users", Duration: int64(500 * time.Millisecond)},
+				{SpanID: 3, ParentID: 1, Name: "batch.run", Resource: "index users", Duration: int64(2 * time.Second)},
+			},
+			kept: []uint64{2, 3},
+		},
+		"all-spans": {
+			trace: pb.Trace{
+				{SpanID: 1, Service: "cache"},
+			},
+			kept: []uint64{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			trace, dropTrace := filter.Filter(tt.trace)
+			assert.Equal(t, tt.dropTrace, dropTrace)
+			if tt.dropTrace {
+				return
+			}
+			kept := []uint64{}
+			for _, s := range trace {
+				kept = append(kept, s.SpanID)
+			}
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
+
+func TestSpanFilterNoRules(t *testing.T) {
+	trace := pb.Trace{{SpanID: 1}, {SpanID: 2, ParentID: 1}}
+	filtered, dropTrace := NewSpanFilter(nil).Filter(trace)
+	assert.False(t, dropTrace)
+	assert.Equal(t, trace, filtered)
+	assert.True(t, NewSpanFilter(nil).AllowsStat(&pb.ClientGroupedStats{Service: "web"}))
+}
+
+func TestSpanFilterAllowsStat(t *testing.T) {
+	filter := NewSpanFilter([]*config.FilterRule{
+		{Action: config.FilterActionDropTrace, ServiceRe: regexp.MustCompile("^health$")},
+		{Action: config.FilterActionDropSpan, OperationNameRe: regexp.MustCompile("^redis\\.")},
+		// the stats don't have the tags nor the duration of the spans
+		{Action: config.FilterActionDropTrace, TagsRe: []config.FilterRuleTag{{Key: "http.url", Re: regexp.MustCompile(".*")}}},
+		{Action: config.FilterActionDropSpan, ResourceRe: regexp.MustCompile("^GET"), MaxDurationMs: 1},
+	})
+	assert.False(t, filter.AllowsStat(&pb.ClientGroupedStats{Service: "health", Name: "http.request"}))
+	assert.False(t, filter.AllowsStat(&pb.ClientGroupedStats{Service: "web", Name: "redis.command"}))
+	assert.True(t, filter.AllowsStat(&pb.ClientGroupedStats{Service: "web", Name: "http.request", Resource: "GET /"}))
+}
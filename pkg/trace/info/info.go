@@ -33,6 +33,7 @@ var (
 
 	traceWriterInfo TraceWriterInfo
 	statsWriterInfo StatsWriterInfo
+	tailSamplerInfo TailSamplerInfo
 
 	watchdogInfo     watchdog.Info
 	rateByService    map[string]float64
@@ -84,6 +85,14 @@ const (
   {{if gt .Status.TraceWriter.Errors 0}}WARNING: Traces API errors (1 min): {{.Status.TraceWriter.Errors}}{{end}}
   Stats: {{.Status.StatsWriter.Payloads}} payloads, {{.Status.StatsWriter.StatsBuckets}} stats buckets, {{.Status.StatsWriter.Bytes}} bytes
   {{if gt .Status.StatsWriter.Errors 0}}WARNING: Stats API errors (1 min): {{.Status.StatsWriter.Errors}}{{end}}
+  {{if .Status.TailSampler.Enabled}}
+
+  --- Tail sampler ---
+
+  Buffered: {{.Status.TailSampler.BufferedTraces}} traces, {{.Status.TailSampler.BufferedSpans}} spans, {{.Status.TailSampler.BufferedBytes}} bytes
+  Decided: {{.Status.TailSampler.KeptTraces}} traces kept, {{.Status.TailSampler.DroppedTraces}} traces dropped
+  {{if gt .Status.TailSampler.EvictedTraces 0}}WARNING: Traces evicted from the full buffer: {{.Status.TailSampler.EvictedTraces}}{{end}}
+  {{end}}
 `
 
 	notRunningTmplSrc = `{{.Banner}}
@@ -216,6 +225,7 @@ func InitInfo(conf *config.AgentConfig) error {
 		expvar.Publish("receiver", expvar.Func(publishReceiverStats))
 		expvar.Publish("trace_writer", expvar.Func(publishTraceWriterInfo))
 		expvar.Publish("stats_writer", expvar.Func(publishStatsWriterInfo))
+		expvar.Publish("tail_sampler", expvar.Func(publishTailSamplerInfo))
 		expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 		expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
 		expvar.Publish("ratelimiter", expvar.Func(publishRateLimiterStats))
@@ -274,6 +284,7 @@ type StatusInfo struct {
 	RateByService map[string]float64 `json:"ratebyservice"`
 	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
 	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
+	TailSampler   TailSamplerInfo    `json:"tail_sampler"`
 	Watchdog      watchdog.Info      `json:"watchdog"`
 	RateLimiter   RateLimiterStats   `json:"ratelimiter"`
 	Config        config.AgentConfig `json:"config"`
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+// TailSamplerInfo represents statistics from the tail sampler. The buffered
+// values are the current ones, the traces decided are counted since the start.
+type TailSamplerInfo struct {
+	Enabled        bool
+	BufferedTraces int64
+	BufferedSpans  int64
+	BufferedBytes  int64
+	KeptTraces     int64
+	DroppedTraces  int64
+	// EvictedTraces are the traces dropped before the end of their decision
+	// window because the buffer was full.
+	EvictedTraces int64
+}
+
+// UpdateTailSamplerInfo updates internal tail sampler stats
+func UpdateTailSamplerInfo(tsi TailSamplerInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	tailSamplerInfo = tsi
+}
+
+func publishTailSamplerInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return tailSamplerInfo
+}
@@ -34,6 +34,10 @@ type BucketsAggregationKey struct {
 	Type       string
 	StatusCode uint32
 	Synthetics bool
+
+	// SpanTags holds the values of the span tags configured as extra dimensions,
+	// as `key:value` tags joined with spanTagsSeparator.
+	SpanTags string
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -82,6 +86,7 @@ func NewAggregationFromGroup(g pb.ClientGroupedStats) Aggregation {
 			Name:       g.Name,
 			StatusCode: g.HTTPStatusCode,
 			Synthetics: g.Synthetics,
+			SpanTags:   joinSpanTags(g.SpanTags),
 		},
 	}
 }
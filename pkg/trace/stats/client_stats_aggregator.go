@@ -45,6 +45,10 @@ type ClientStatsAggregator struct {
 	agentEnv      string
 	agentHostname string
 
+	// spanTags and maxSpanTagsCardinality configure the extra span tags dimension
+	spanTags               []string
+	maxSpanTagsCardinality int
+
 	exit chan struct{}
 	done chan struct{}
 }
@@ -61,6 +65,9 @@ func NewClientStatsAggregator(conf *config.AgentConfig, out chan pb.StatsPayload
 		oldestTs:      alignAggTs(time.Now().Add(bucketDuration - oldestBucketStart)),
 		exit:          make(chan struct{}),
 		done:          make(chan struct{}),
+
+		spanTags:               conf.StatsSpanTags,
+		maxSpanTagsCardinality: conf.MaxStatsSpanTagsCardinality,
 	}
 }
 
@@ -130,9 +137,10 @@ func (a *ClientStatsAggregator) add(now time.Time, p pb.ClientStatsPayload) {
 		}
 		b, ok := a.buckets[ts.Unix()]
 		if !ok {
-			b = &bucket{ts: ts}
+			b = &bucket{ts: ts, spanTags: newSpanTagsLimiter(a.spanTags, a.maxSpanTagsCardinality)}
 			a.buckets[ts.Unix()] = b
 		}
+		b.limitSpanTags(clientBucket)
 		p.Stats = []pb.ClientStatsBucket{clientBucket}
 		a.flush(b.add(p))
 	}
@@ -170,6 +178,19 @@ type bucket struct {
 	n int
 	// agg contains the aggregated Hits/Errors/Duration counts
 	agg map[PayloadAggregationKey]map[BucketsAggregationKey]*aggregatedCounts
+	// spanTags computes the extra span tags dimension, nil when there is none
+	spanTags *spanTagsLimiter
+}
+
+// limitSpanTags only keeps the configured span tags of the grouped stats, within
+// the cardinality limit of the bucket.
+func (b *bucket) limitSpanTags(s pb.ClientStatsBucket) {
+	for i, g := range s.Stats {
+		if len(g.SpanTags) == 0 {
+			continue
+		}
+		s.Stats[i].SpanTags = splitSpanTags(b.spanTags.fromTags(g.SpanTags))
+	}
 }
 
 func (b *bucket) add(p pb.ClientStatsPayload) []pb.ClientStatsPayload {
@@ -236,6 +257,7 @@ func (b *bucket) aggregationToPayloads() []pb.ClientStatsPayload {
 				HTTPStatusCode: aggrKey.StatusCode,
 				Type:           aggrKey.Type,
 				Synthetics:     aggrKey.Synthetics,
+				SpanTags:       splitSpanTags(aggrKey.SpanTags),
 				Hits:           counts.hits,
 				Errors:         counts.errors,
 				Duration:       counts.duration,
@@ -270,6 +292,7 @@ func newBucketAggregationKey(b pb.ClientGroupedStats) BucketsAggregationKey {
 		Type:       b.Type,
 		Synthetics: b.Synthetics,
 		StatusCode: b.HTTPStatusCode,
+		SpanTags:   joinSpanTags(b.SpanTags),
 	}
 }
 
@@ -70,6 +70,9 @@ func getTestStatsWithStart(start time.Time) pb.ClientStatsPayload {
 	b := pb.ClientStatsBucket{}
 	fuzzer.Fuzz(&b)
 	b.Start = uint64(start.UnixNano())
+	for i := range b.Stats {
+		b.Stats[i].SpanTags = nil
+	}
 	p := pb.ClientStatsPayload{}
 	fuzzer.Fuzz(&p)
 	p.Tags = nil
@@ -332,6 +335,46 @@ func TestCountAggregation(t *testing.T) {
 	}
 }
 
+func TestCountAggregationSpanTags(t *testing.T) {
+	assert := assert.New(t)
+	conf := &config.AgentConfig{
+		DefaultEnv:                  "agentEnv",
+		Hostname:                    "agentHostname",
+		StatsSpanTags:               []string{"peer.service", "db.instance"},
+		MaxStatsSpanTagsCardinality: 2,
+	}
+	a := NewClientStatsAggregator(conf, make(chan pb.StatsPayload, 100))
+	testTime := time.Unix(time.Now().Unix(), 0)
+
+	payload := func(hits uint64, spanTags ...string) pb.ClientStatsPayload {
+		p := payloadWithCounts(testTime, BucketsAggregationKey{Service: "s"}, hits, 0, 0)
+		p.Stats[0].Stats[0].SpanTags = spanTags
+		return p
+	}
+	a.add(testTime, payload(1, "db.instance:users", "other:value", "peer.service:users-db"))
+	a.add(testTime, payload(2, "peer.service:users-db", "db.instance:users"))
+	a.add(testTime, payload(4, "peer.service:orders-db"))
+	a.add(testTime, payload(8, "peer.service:billing-db"))
+	a.add(testTime, payload(16, "other:value"))
+	assert.Len(a.out, 4)
+	distributions := <-a.out
+	assert.Equal([]string{"peer.service:users-db", "db.instance:users"}, distributions.Stats[0].Stats[0].Stats[0].SpanTags)
+	assert.Equal([]string{"peer.service:users-db", "db.instance:users"}, distributions.Stats[1].Stats[0].Stats[0].SpanTags)
+	assert.Equal([]string{"peer.service:orders-db"}, (<-a.out).Stats[0].Stats[0].Stats[0].SpanTags)
+	assert.Equal([]string{spanTagsOverflow}, (<-a.out).Stats[0].Stats[0].Stats[0].SpanTags)
+	assert.Nil((<-a.out).Stats[0].Stats[0].Stats[0].SpanTags)
+
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	aggCounts := <-a.out
+	assertAggCountsPayload(t, aggCounts)
+	assert.ElementsMatch([]pb.ClientGroupedStats{
+		{Service: "s", Hits: 3, SpanTags: []string{"peer.service:users-db", "db.instance:users"}},
+		{Service: "s", Hits: 4, SpanTags: []string{"peer.service:orders-db"}},
+		{Service: "s", Hits: 8, SpanTags: []string{spanTagsOverflow}},
+		{Service: "s", Hits: 16},
+	}, aggCounts.Stats[0].Stats[0].Stats)
+}
+
 func deepCopy(p pb.ClientStatsPayload) pb.ClientStatsPayload {
 	new := p
 	new.Stats = deepCopyStatsBucket(p.Stats)
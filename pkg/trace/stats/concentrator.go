@@ -47,6 +47,10 @@ type Concentrator struct {
 	mu            sync.Mutex
 	agentEnv      string
 	agentHostname string
+
+	// spanTags and maxSpanTagsCardinality configure the extra span tags dimension
+	spanTags               []string
+	maxSpanTagsCardinality int
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -65,6 +69,9 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		exit:          make(chan struct{}),
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
+
+		spanTags:               conf.StatsSpanTags,
+		maxSpanTagsCardinality: conf.MaxStatsSpanTagsCardinality,
 	}
 	return &c
 }
@@ -178,6 +185,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 		b, ok := c.buckets[btime]
 		if !ok {
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
+			b.spanTags = newSpanTagsLimiter(c.spanTags, c.maxSpanTagsCardinality)
 			c.buckets[btime] = b
 		}
 		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey)
@@ -510,3 +510,46 @@ func TestDistributions(t *testing.T) {
 		}
 	})
 }
+
+// TestConcentratorSpanTags tests that the configured span tags are extra dimensions of the stats,
+// up to the cardinality limit.
+func TestConcentratorSpanTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	cfg := config.AgentConfig{
+		BucketInterval:              time.Duration(testBucketInterval),
+		DefaultEnv:                  "env",
+		Hostname:                    "hostname",
+		StatsSpanTags:               []string{"peer.service", "db.instance"},
+		MaxStatsSpanTagsCardinality: 2,
+	}
+	c := NewConcentrator(&cfg, make(chan pb.StatsPayload), now)
+
+	spans := []*pb.Span{
+		testSpan(1, 0, 10, 0, "A1", "resource1", 0),
+		testSpan(2, 1, 20, 0, "A2", "resource1", 0),
+		testSpan(3, 1, 30, 0, "A2", "resource1", 0),
+		testSpan(4, 1, 40, 0, "A3", "resource1", 0),
+		testSpan(5, 1, 50, 0, "A4", "resource1", 0),
+	}
+	spans[1].Meta = map[string]string{"peer.service": "users-db", "db.instance": "users"}
+	spans[2].Meta = map[string]string{"db.instance": "users", "peer.service": "users-db", "other": "value"}
+	spans[3].Meta = map[string]string{"peer.service": "orders-db"}
+	spans[4].Meta = map[string]string{"peer.service": "billing-db"}
+	traceutil.ComputeTopLevel(spans)
+	c.addNow(toProcessedTrace(spans, "none", ""), "")
+
+	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+	assert.Len(stats.Stats, 1)
+	assert.Len(stats.Stats[0].Stats, 1)
+	hits := make(map[string]uint64)
+	for _, g := range stats.Stats[0].Stats[0].Stats {
+		hits[joinSpanTags(g.SpanTags)] += g.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"": 1,
+		joinSpanTags([]string{"peer.service:users-db", "db.instance:users"}): 2,
+		"peer.service:orders-db": 1,
+		spanTagsOverflow:         1,
+	}, hits)
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const (
+	// spanTagsSeparator separates the `key:value` tags in BucketsAggregationKey.SpanTags.
+	spanTagsSeparator = "\x00"
+	// spanTagsOverflow replaces the span tags of the stats once the cardinality
+	// of the span tags of a bucket is reached.
+	spanTagsOverflow = "span_tags_overflow:true"
+)
+
+// spanTagsLimiter computes the extra span tags dimension of the stats of a bucket.
+// It keeps at most maxCardinality distinct combinations of span tags, the other
+// combinations are aggregated together under spanTagsOverflow.
+// All the methods can be called on a nil limiter, when no span tag is configured.
+type spanTagsLimiter struct {
+	keys           []string
+	maxCardinality int
+	// seen maps the combinations of span tags kept to themselves, to reuse the keys
+	seen map[string]string
+	// buf is used to build the combinations without allocating - not threadsafe
+	buf []byte
+}
+
+// newSpanTagsLimiter returns a limiter of the span tags of keys, or nil when keys is empty.
+func newSpanTagsLimiter(keys []string, maxCardinality int) *spanTagsLimiter {
+	if len(keys) == 0 {
+		return nil
+	}
+	return &spanTagsLimiter{
+		keys:           keys,
+		maxCardinality: maxCardinality,
+		seen:           make(map[string]string),
+	}
+}
+
+// fromSpan returns the span tags dimension of a span, in the order of the configured keys.
+func (l *spanTagsLimiter) fromSpan(s *pb.Span) string {
+	if l == nil {
+		return ""
+	}
+	l.buf = l.buf[:0]
+	for _, k := range l.keys {
+		if v := s.Meta[k]; v != "" {
+			l.appendTag(k, v)
+		}
+	}
+	return l.limit()
+}
+
+// fromTags returns the span tags dimension of grouped stats computed by a
+// client, only keeping the configured keys.
+func (l *spanTagsLimiter) fromTags(tags []string) string {
+	if l == nil {
+		return ""
+	}
+	l.buf = l.buf[:0]
+	for _, k := range l.keys {
+		for _, t := range tags {
+			if len(t) > len(k) && t[len(k)] == ':' && strings.HasPrefix(t, k) {
+				l.appendTag(k, t[len(k)+1:])
+				break
+			}
+		}
+	}
+	return l.limit()
+}
+
+func (l *spanTagsLimiter) appendTag(k, v string) {
+	if len(l.buf) > 0 {
+		l.buf = append(l.buf, spanTagsSeparator...)
+	}
+	l.buf = append(l.buf, k...)
+	l.buf = append(l.buf, ':')
+	l.buf = append(l.buf, v...)
+}
+
+func (l *spanTagsLimiter) limit() string {
+	if len(l.buf) == 0 {
+		return ""
+	}
+	if tags, ok := l.seen[string(l.buf)]; ok {
+		return tags
+	}
+	if len(l.seen) >= l.maxCardinality {
+		return spanTagsOverflow
+	}
+	tags := string(l.buf)
+	l.seen[tags] = tags
+	return tags
+}
+
+func joinSpanTags(tags []string) string {
+	return strings.Join(tags, spanTagsSeparator)
+}
+
+func splitSpanTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, spanTagsSeparator)
+}
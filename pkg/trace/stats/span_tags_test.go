@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanTagsLimiter(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		var l *spanTagsLimiter
+		assert.Nil(t, newSpanTagsLimiter(nil, 10))
+		assert.Equal(t, "", l.fromSpan(&pb.Span{Meta: map[string]string{"peer.service": "db"}}))
+		assert.Equal(t, "", l.fromTags([]string{"peer.service:db"}))
+	})
+
+	t.Run("span", func(t *testing.T) {
+		l := newSpanTagsLimiter([]string{"peer.service", "db.instance"}, 10)
+		assert.Equal(t, "", l.fromSpan(&pb.Span{}))
+		assert.Equal(t, "", l.fromSpan(&pb.Span{Meta: map[string]string{"other": "value", "peer.service": ""}}))
+		assert.Equal(t, "peer.service:db", l.fromSpan(&pb.Span{Meta: map[string]string{"peer.service": "db"}}))
+		assert.Equal(t, []string{"peer.service:db", "db.instance:users"}, splitSpanTags(l.fromSpan(&pb.Span{
+			Meta: map[string]string{"db.instance": "users", "peer.service": "db", "other": "value"},
+		})))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		l := newSpanTagsLimiter([]string{"peer.service", "db.instance"}, 10)
+		assert.Equal(t, "", l.fromTags(nil))
+		assert.Equal(t, "", l.fromTags([]string{"other:value", "peer.service", "peer.servicename:db"}))
+		assert.Equal(t, []string{"peer.service:db", "db.instance:users"}, splitSpanTags(l.fromTags([]string{"db.instance:users", "other:value", "peer.service:db"})))
+	})
+
+	t.Run("cardinality", func(t *testing.T) {
+		l := newSpanTagsLimiter([]string{"tenant"}, 2)
+		assert.Equal(t, "tenant:a", l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": "a"}}))
+		assert.Equal(t, "tenant:b", l.fromTags([]string{"tenant:b"}))
+		assert.Equal(t, spanTagsOverflow, l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": "c"}}))
+		assert.Equal(t, spanTagsOverflow, l.fromTags([]string{"tenant:d"}))
+		assert.Equal(t, "tenant:a", l.fromTags([]string{"tenant:a"}))
+		assert.Equal(t, "", l.fromSpan(&pb.Span{}))
+	})
+}
@@ -73,6 +73,7 @@ func (s *groupedStats) export(a Aggregation) (pb.ClientGroupedStats, error) {
 		OkSummary:      okSummary,
 		ErrorSummary:   errSummary,
 		Synthetics:     a.Synthetics,
+		SpanTags:       splitSpanTags(a.SpanTags),
 	}, nil
 }
 
@@ -103,6 +104,9 @@ type RawBucket struct {
 	// this should really remain private as it's subject to refactoring
 	data map[Aggregation]*groupedStats
 
+	// spanTags computes the extra span tags dimension, nil when there is none
+	spanTags *spanTagsLimiter
+
 	// internal buffer for aggregate strings - not threadsafe
 	keyBuf strings.Builder
 }
@@ -153,6 +157,7 @@ func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin s
 		panic("env should never be empty")
 	}
 	aggr := NewAggregationFromSpan(s, origin, aggKey)
+	aggr.SpanTags = sb.spanTags.fromSpan(s)
 	sb.add(s, weight, isTop, aggr)
 }
 